@@ -0,0 +1,215 @@
+// Package asciicast reads and writes terminal recordings in the asciicast v2 format used by asciinema.
+// See https://github.com/asciinema/asciinema/blob/develop/doc/asciicast-v2.md
+package asciicast
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Version is the version of the format which is read and written
+const Version = 2
+
+// Header is the first line of a recording
+type Header struct {
+	Version       int               `json:"version"`
+	Width         int               `json:"width"`
+	Height        int               `json:"height"`
+	Timestamp     int64             `json:"timestamp,omitempty"`
+	IdleTimeLimit float64           `json:"idle_time_limit,omitempty"`
+	Title         string            `json:"title,omitempty"`
+	Env           map[string]string `json:"env,omitempty"`
+}
+
+// EventType is the kind of data in an event
+type EventType string
+
+const (
+	EventOutput EventType = "o" // data written to the terminal
+	EventInput  EventType = "i" // data typed by the user
+	EventResize EventType = "r" // the terminal was resized, data is COLSxROWS
+	EventMarker EventType = "m" // a marker which players may pause at
+)
+
+// Event is a line of a recording after the header
+type Event struct {
+	Time float64 // seconds since the start of the recording
+	Type EventType
+	Data string
+}
+
+// Writer writes a recording, timing each event from when the writer was created
+type Writer struct {
+	lock    sync.Mutex
+	w       io.Writer
+	start   time.Time
+	partial []byte // the start of a UTF-8 sequence which has not been completely written yet
+}
+
+// NewWriter writes the header of a recording and returns a writer for its events
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	header.Version = Version
+	line, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(append(line, '\n')); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w, start: time.Now()}, nil
+}
+
+// Output records data written to the terminal. UTF-8 sequences may be split across calls. Bytes which are not
+// valid UTF-8 are recorded as the Latin-1 characters with the same values, as asciinema does.
+func (writer *Writer) Output(data []byte) error {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+
+	// events hold strings, so hold back an incomplete UTF-8 sequence until the rest of it arrives
+	data, writer.partial = SplitIncompleteUTF8(append(writer.partial, data...))
+	if len(data) == 0 {
+		return nil
+	}
+	return writer.writeEvent(EventOutput, decodeOutput(data))
+}
+
+// Flush records the start of a UTF-8 sequence held back by Output, as it will not be completed
+func (writer *Writer) Flush() error {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+
+	if len(writer.partial) == 0 {
+		return nil
+	}
+	data := writer.partial
+	writer.partial = nil
+	return writer.writeEvent(EventOutput, decodeOutput(data))
+}
+
+// SplitIncompleteUTF8 splits off an incomplete UTF-8 sequence at the end of the data, which the data read next may
+// complete, so that a character split across reads can be held back until the rest of it arrives
+func SplitIncompleteUTF8(data []byte) (complete []byte, partial []byte) {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return data[:i], append([]byte{}, data[i:]...)
+			}
+			break
+		}
+	}
+	return data, nil
+}
+
+// decodeOutput converts output to a string, decoding the bytes which are not valid UTF-8 as Latin-1
+func decodeOutput(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	var out strings.Builder
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size == 1 {
+			r = rune(data[0])
+		}
+		out.WriteRune(r)
+		data = data[size:]
+	}
+	return out.String()
+}
+
+// Resize records a change in the size of the terminal
+func (writer *Writer) Resize(cols int, rows int) error {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+	return writer.writeEvent(EventResize, fmt.Sprintf("%dx%d", cols, rows))
+}
+
+func (writer *Writer) writeEvent(eventType EventType, data string) error {
+	elapsed := time.Since(writer.start).Seconds()
+	line, err := json.Marshal([]interface{}{elapsed, eventType, data})
+	if err != nil {
+		return err
+	}
+	_, err = writer.w.Write(append(line, '\n'))
+	return err
+}
+
+// Reader reads the events of a recording in order
+type Reader struct {
+	Header Header
+	r      *bufio.Reader
+	line   int
+}
+
+// NewReader reads the header of a recording
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := &Reader{r: bufio.NewReader(r)}
+
+	line, err := reader.readLine()
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("Recording is empty")
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(line, &reader.Header); err != nil {
+		return nil, fmt.Errorf("Invalid asciicast header: %s", err)
+	}
+	if reader.Header.Version != Version {
+		return nil, fmt.Errorf("Unsupported asciicast version %d", reader.Header.Version)
+	}
+	return reader, nil
+}
+
+// readLine returns the next line which is not blank
+func (reader *Reader) readLine() ([]byte, error) {
+	for {
+		line, err := reader.r.ReadBytes('\n')
+		reader.line++
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return line, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Next returns the next event, or io.EOF at the end of the recording
+func (reader *Reader) Next() (Event, error) {
+	line, err := reader.readLine()
+	if err != nil {
+		return Event{}, err
+	}
+
+	var fields []interface{}
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return Event{}, fmt.Errorf("Invalid event on line %d: %s", reader.line, err)
+	}
+	if len(fields) != 3 {
+		return Event{}, fmt.Errorf("Invalid event on line %d: expected 3 fields, found %d", reader.line, len(fields))
+	}
+
+	elapsed, ok1 := fields[0].(float64)
+	eventType, ok2 := fields[1].(string)
+	data, ok3 := fields[2].(string)
+	if !ok1 || !ok2 || !ok3 {
+		return Event{}, fmt.Errorf("Invalid event on line %d", reader.line)
+	}
+	return Event{Time: elapsed, Type: EventType(eventType), Data: data}, nil
+}
+
+// ParseSize reads the size from the data of a resize event
+func ParseSize(data string) (cols int, rows int, err error) {
+	if _, err := fmt.Sscanf(data, "%dx%d", &cols, &rows); err != nil {
+		return 0, 0, fmt.Errorf("Invalid size %q", data)
+	}
+	return cols, rows, nil
+}
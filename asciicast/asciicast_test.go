@@ -0,0 +1,188 @@
+package asciicast
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	file := &bytes.Buffer{}
+	writer, err := NewWriter(file, Header{Width: 80, Height: 24, Env: map[string]string{"TERM": "xterm-256color"}})
+	require.NoError(t, err)
+
+	require.NoError(t, writer.Output([]byte("hello \x1b[1mworld\r\n")))
+	require.NoError(t, writer.Resize(100, 30))
+	// a character split across reads is held back until it is complete
+	require.NoError(t, writer.Output([]byte("caf\xc3")))
+	require.NoError(t, writer.Output([]byte("\xa9")))
+
+	reader, err := NewReader(file)
+	require.NoError(t, err)
+	assert.Equal(t, 2, reader.Header.Version)
+	assert.Equal(t, 80, reader.Header.Width)
+	assert.Equal(t, 24, reader.Header.Height)
+	assert.Equal(t, "xterm-256color", reader.Header.Env["TERM"])
+
+	expected := []Event{
+		{Type: EventOutput, Data: "hello \x1b[1mworld\r\n"},
+		{Type: EventResize, Data: "100x30"},
+		{Type: EventOutput, Data: "caf"},
+		{Type: EventOutput, Data: "é"},
+	}
+	last := 0.0
+	for _, e := range expected {
+		event, err := reader.Next()
+		require.NoError(t, err)
+		assert.Equal(t, e.Type, event.Type)
+		assert.Equal(t, e.Data, event.Data)
+		assert.True(t, event.Time >= last)
+		last = event.Time
+	}
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestInvalidUTF8IsRecordedAsLatin1(t *testing.T) {
+	file := &bytes.Buffer{}
+	writer, err := NewWriter(file, Header{Width: 80, Height: 24})
+	require.NoError(t, err)
+
+	require.NoError(t, writer.Output([]byte("caf\xe9 \xef\xbf\xbd\xff\r\n")))
+	// a sequence which is never completed is recorded when the writer is flushed
+	require.NoError(t, writer.Output([]byte("na\xe2\x82")))
+	require.NoError(t, writer.Flush())
+	require.NoError(t, writer.Flush())
+
+	reader, err := NewReader(file)
+	require.NoError(t, err)
+	for _, expected := range []string{"café \ufffd\u00ff\r\n", "na", "\u00e2\u0082"} {
+		event, err := reader.Next()
+		require.NoError(t, err)
+		assert.Equal(t, expected, event.Data)
+	}
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestSplitIncompleteUTF8(t *testing.T) {
+	for _, test := range []struct {
+		data     string
+		complete string
+		partial  string
+	}{
+		{"abc", "abc", ""},
+		{"ab\xc3", "ab", "\xc3"},
+		{"ab\xe2\x82", "ab", "\xe2\x82"},
+		{"ab\xe2\x82\xac", "ab\xe2\x82\xac", ""},
+		{"ab\xff", "ab\xff", ""},
+		{"ab\x82", "ab\x82", ""},
+	} {
+		complete, partial := SplitIncompleteUTF8([]byte(test.data))
+		assert.Equal(t, test.complete, string(complete), "%q", test.data)
+		assert.Equal(t, test.partial, string(partial), "%q", test.data)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	_, err := NewReader(strings.NewReader(""))
+	assert.Error(t, err)
+
+	_, err = NewReader(strings.NewReader(`{"version": 1, "width": 80, "height": 24}`))
+	assert.Error(t, err)
+
+	reader, err := NewReader(strings.NewReader("{\"version\": 2, \"width\": 80, \"height\": 24}\n[0.5, \"o\"]\n"))
+	require.NoError(t, err)
+	_, err = reader.Next()
+	assert.Error(t, err)
+}
+
+func TestParseSize(t *testing.T) {
+	cols, rows, err := ParseSize("132x43")
+	require.NoError(t, err)
+	assert.Equal(t, 132, cols)
+	assert.Equal(t, 43, rows)
+
+	_, _, err = ParseSize("wide")
+	assert.Error(t, err)
+}
+
+const recording = `{"version": 2, "width": 80, "height": 24, "idle_time_limit": 2}
+[1.0, "o", "a"]
+[11.0, "o", "b"]
+[11.5, "r", "100x30"]
+[11.5, "i", "typed"]
+[12.0, "o", "c"]
+`
+
+func TestPlay(t *testing.T) {
+	reader, err := NewReader(strings.NewReader(recording))
+	require.NoError(t, err)
+
+	player := NewPlayer(reader, PlayerOptions{Speed: 2})
+	delays := []time.Duration{}
+	player.sleep = func(d time.Duration) {
+		delays = append(delays, d)
+	}
+
+	output := &bytes.Buffer{}
+	sizes := []string{}
+	require.NoError(t, player.Play(output, func(cols int, rows int) {
+		sizes = append(sizes, fmt.Sprintf("%dx%d", cols, rows))
+	}))
+
+	assert.Equal(t, "abc", output.String())
+	assert.Equal(t, []string{"100x30"}, sizes)
+	// the ten second gap is capped by the idle limit in the header, then everything is halved
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, time.Second, 250 * time.Millisecond, 250 * time.Millisecond}, delays)
+}
+
+func TestPlayIdleLimitOption(t *testing.T) {
+	reader, err := NewReader(strings.NewReader(recording))
+	require.NoError(t, err)
+
+	player := NewPlayer(reader, PlayerOptions{IdleLimit: 100 * time.Millisecond})
+	total := time.Duration(0)
+	player.sleep = func(d time.Duration) {
+		total += d
+	}
+	require.NoError(t, player.Play(&bytes.Buffer{}, nil))
+	assert.Equal(t, 400*time.Millisecond, total)
+}
+
+func TestPause(t *testing.T) {
+	reader, err := NewReader(strings.NewReader(recording))
+	require.NoError(t, err)
+
+	player := NewPlayer(reader, PlayerOptions{})
+	player.sleep = func(time.Duration) {}
+	player.TogglePause()
+	assert.True(t, player.IsPaused())
+
+	done := make(chan struct{})
+	output := &bytes.Buffer{}
+	go func() {
+		player.Play(output, nil)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Playback finished while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	player.TogglePause()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Playback did not resume")
+	}
+	assert.Equal(t, "abc", output.String())
+}
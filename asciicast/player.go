@@ -0,0 +1,96 @@
+package asciicast
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// PlayerOptions controls how a recording is played back
+type PlayerOptions struct {
+	Speed     float64       // playback speed, 2 is twice as fast, 0 for normal speed
+	IdleLimit time.Duration // the longest pause between events, 0 to use the limit in the header if any
+}
+
+// Player replays the output of a recording in real time
+type Player struct {
+	reader *Reader
+	opts   PlayerOptions
+	lock   sync.Mutex
+	cond   *sync.Cond
+	paused bool
+	sleep  func(time.Duration)
+}
+
+// NewPlayer creates a player for a recording
+func NewPlayer(reader *Reader, opts PlayerOptions) *Player {
+	if opts.Speed <= 0 {
+		opts.Speed = 1
+	}
+	if opts.IdleLimit == 0 && reader.Header.IdleTimeLimit > 0 {
+		opts.IdleLimit = time.Duration(reader.Header.IdleTimeLimit * float64(time.Second))
+	}
+	player := &Player{reader: reader, opts: opts, sleep: time.Sleep}
+	player.cond = sync.NewCond(&player.lock)
+	return player
+}
+
+// TogglePause pauses or resumes playback
+func (player *Player) TogglePause() {
+	player.lock.Lock()
+	defer player.lock.Unlock()
+	player.paused = !player.paused
+	player.cond.Broadcast()
+}
+
+// IsPaused returns true if playback is paused
+func (player *Player) IsPaused() bool {
+	player.lock.Lock()
+	defer player.lock.Unlock()
+	return player.paused
+}
+
+// waitWhilePaused blocks until playback is resumed
+func (player *Player) waitWhilePaused() {
+	player.lock.Lock()
+	defer player.lock.Unlock()
+	for player.paused {
+		player.cond.Wait()
+	}
+}
+
+// Play writes the output events to w as they occurred, calling resize for each resize event. It returns
+// once the whole recording has been played.
+func (player *Player) Play(w io.Writer, resize func(cols int, rows int)) error {
+	last := 0.0
+	for {
+		event, err := player.reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		delay := time.Duration((event.Time - last) * float64(time.Second))
+		last = event.Time
+		if player.opts.IdleLimit > 0 && delay > player.opts.IdleLimit {
+			delay = player.opts.IdleLimit
+		}
+		if delay > 0 {
+			player.sleep(time.Duration(float64(delay) / player.opts.Speed))
+		}
+		player.waitWhilePaused()
+
+		switch event.Type {
+		case EventOutput:
+			if _, err := io.WriteString(w, event.Data); err != nil {
+				return err
+			}
+		case EventResize:
+			if cols, rows, err := ParseSize(event.Data); err == nil && resize != nil {
+				resize(cols, rows)
+			}
+		}
+	}
+}
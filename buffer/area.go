@@ -0,0 +1,218 @@
+package buffer
+
+// Rectangle is an area of the view in zero-based view coordinates. All bounds are inclusive.
+type Rectangle struct {
+	Top    uint16
+	Left   uint16
+	Bottom uint16
+	Right  uint16
+}
+
+// AttributeMask selects the character attributes which DECCARA and DECRARA are allowed to modify
+type AttributeMask struct {
+	Bold      bool
+	Underline bool
+	Blink     bool
+	Inverse   bool
+}
+
+// clipArea restricts the rectangle to the view, returning false if nothing is left
+func (buffer *Buffer) clipArea(area Rectangle) (Rectangle, bool) {
+	if area.Bottom >= buffer.ViewHeight() {
+		area.Bottom = buffer.ViewHeight() - 1
+	}
+	if area.Right >= buffer.ViewWidth() {
+		area.Right = buffer.ViewWidth() - 1
+	}
+	return area, area.Top <= area.Bottom && area.Left <= area.Right
+}
+
+// ensures the line has cells up to and including col
+func (buffer *Buffer) padLine(line *Line, col uint16) {
+	for int(col) >= len(line.cells) {
+		line.appendCells(buffer.defaultCell(false))
+	}
+}
+
+// CopyArea copies the contents of the area to the given position (DECCRA)
+func (buffer *Buffer) CopyArea(area Rectangle, destTop uint16, destLeft uint16) {
+	defer buffer.emitDisplayChange()
+
+	area, ok := buffer.clipArea(area)
+	if !ok {
+		return
+	}
+
+	// copy the source first, so overlapping areas are handled correctly
+	blank := buffer.defaultCell(false)
+	rows := make([][]storedCell, 0, area.Bottom-area.Top+1)
+	for y := area.Top; y <= area.Bottom; y++ {
+		line := buffer.getViewLine(y)
+		row := make([]storedCell, 0, area.Right-area.Left+1)
+		for x := area.Left; x <= area.Right; x++ {
+			if int(x) < len(line.cells) {
+				row = append(row, line.cells[x])
+			} else {
+				row = append(row, blank)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	for offsetY, row := range rows {
+		y := int(destTop) + offsetY
+		if y >= int(buffer.ViewHeight()) {
+			break
+		}
+		line := buffer.getViewLine(uint16(y))
+		for offsetX, cell := range row {
+			x := int(destLeft) + offsetX
+			if x >= int(buffer.ViewWidth()) {
+				break
+			}
+			buffer.padLine(line, uint16(x))
+			line.cells[x] = cell
+		}
+	}
+}
+
+// FillArea fills the area with the given rune using the current cursor attributes (DECFRA)
+func (buffer *Buffer) FillArea(r rune, area Rectangle) {
+	defer buffer.emitDisplayChange()
+
+	area, ok := buffer.clipArea(area)
+	if !ok {
+		return
+	}
+
+	for y := area.Top; y <= area.Bottom; y++ {
+		line := buffer.getViewLine(y)
+		buffer.padLine(line, area.Right)
+		for x := area.Left; x <= area.Right; x++ {
+			line.setCell(int(x), r, buffer.terminalState.CursorAttr)
+		}
+	}
+}
+
+// EraseArea erases all characters in the area, regardless of protection (DECERA)
+func (buffer *Buffer) EraseArea(area Rectangle) {
+	defer buffer.emitDisplayChange()
+
+	area, ok := buffer.clipArea(area)
+	if !ok {
+		return
+	}
+
+	for y := area.Top; y <= area.Bottom; y++ {
+		line := buffer.getViewLine(y)
+		for x := int(area.Left); x <= int(area.Right) && x < len(line.cells); x++ {
+			line.erase(x, buffer.terminalState.CursorAttr.BgColour)
+		}
+	}
+}
+
+// SelectiveEraseArea erases all unprotected characters in the area (DECSERA)
+func (buffer *Buffer) SelectiveEraseArea(area Rectangle) {
+	defer buffer.emitDisplayChange()
+
+	area, ok := buffer.clipArea(area)
+	if !ok {
+		return
+	}
+
+	for y := area.Top; y <= area.Bottom; y++ {
+		buffer.getViewLine(y).selectiveErase(int(area.Left), int(area.Right)+1)
+	}
+}
+
+// forEachAreaCell applies fn to every cell in the area, honouring the extent selected by DECSACE
+func (buffer *Buffer) forEachAreaCell(area Rectangle, fn func(attr *CellAttributes)) {
+	area, ok := buffer.clipArea(area)
+	if !ok && (buffer.terminalState.RectangularAttributeExtent || area.Top >= area.Bottom) {
+		return
+	}
+
+	for y := area.Top; y <= area.Bottom; y++ {
+		left, right := area.Left, area.Right
+		if !buffer.terminalState.RectangularAttributeExtent {
+			// stream extent: from the start position to the end position, wrapping at the line ends
+			if y > area.Top {
+				left = 0
+			}
+			if y < area.Bottom {
+				right = buffer.ViewWidth() - 1
+			}
+		}
+		if left > right {
+			continue
+		}
+		line := buffer.getViewLine(y)
+		buffer.padLine(line, right)
+		for x := left; x <= right; x++ {
+			attr := line.attributes(int(x))
+			fn(&attr)
+			line.setAttributes(int(x), attr)
+		}
+	}
+}
+
+// ChangeAreaAttributes sets and then resets the selected attributes of all cells in the area (DECCARA)
+func (buffer *Buffer) ChangeAreaAttributes(area Rectangle, set AttributeMask, reset AttributeMask) {
+	defer buffer.emitDisplayChange()
+
+	buffer.forEachAreaCell(area, func(attr *CellAttributes) {
+		attr.Bold = (attr.Bold || set.Bold) && !reset.Bold
+		attr.Underline = (attr.Underline || set.Underline) && !reset.Underline
+		attr.Blink = (attr.Blink || set.Blink) && !reset.Blink
+		attr.Inverse = (attr.Inverse || set.Inverse) && !reset.Inverse
+	})
+}
+
+// ReverseAreaAttributes toggles the selected attributes of all cells in the area (DECRARA)
+func (buffer *Buffer) ReverseAreaAttributes(area Rectangle, toggle AttributeMask) {
+	defer buffer.emitDisplayChange()
+
+	buffer.forEachAreaCell(area, func(attr *CellAttributes) {
+		attr.Bold = attr.Bold != toggle.Bold
+		attr.Underline = attr.Underline != toggle.Underline
+		attr.Blink = attr.Blink != toggle.Blink
+		attr.Inverse = attr.Inverse != toggle.Inverse
+	})
+}
+
+// AreaChecksum calculates the DEC style checksum of the area (DECRQCRA).
+// Blank cells count as spaces, and bold, blink, inverse and underline add to the character value.
+// The result is the two's complement of the 16-bit sum, as a VT420 would report it.
+func (buffer *Buffer) AreaChecksum(area Rectangle) uint16 {
+	area, ok := buffer.clipArea(area)
+	if !ok {
+		return 0
+	}
+
+	var sum uint16
+	for y := area.Top; y <= area.Bottom; y++ {
+		rawLine := buffer.convertViewLineToRawLine(y)
+		for x := area.Left; x <= area.Right; x++ {
+			cell := buffer.GetRawCell(x, rawLine)
+			if cell == nil || cell.r == 0 {
+				sum += ' '
+				continue
+			}
+			sum += uint16(cell.r)
+			if cell.attr.Underline {
+				sum += 0x10
+			}
+			if cell.attr.Inverse {
+				sum += 0x20
+			}
+			if cell.attr.Blink {
+				sum += 0x40
+			}
+			if cell.attr.Bold {
+				sum += 0x80
+			}
+		}
+	}
+
+	return -sum
+}
@@ -0,0 +1,116 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeBufferForTestingAreas() *Buffer {
+	b := NewBuffer(NewTerminalState(10, 4, CellAttributes{}, 1000))
+	b.Write([]rune("abcdefghij")...)
+	b.Write([]rune("klmnopqrst")...)
+	b.Write([]rune("uvwxyz0123")...)
+	b.Write([]rune("456789ABCD")...)
+	return b
+}
+
+func TestCopyArea(t *testing.T) {
+	b := makeBufferForTestingAreas()
+	b.CopyArea(Rectangle{Top: 0, Left: 0, Bottom: 1, Right: 2}, 2, 8)
+	lines := b.GetVisibleLines()
+	assert.Equal(t, "abcdefghij", lines[0].String())
+	assert.Equal(t, "klmnopqrst", lines[1].String())
+	assert.Equal(t, "uvwxyz01ab", lines[2].String())
+	assert.Equal(t, "456789ABkl", lines[3].String())
+}
+
+func TestCopyOverlappingArea(t *testing.T) {
+	b := makeBufferForTestingAreas()
+	b.CopyArea(Rectangle{Top: 0, Left: 0, Bottom: 0, Right: 4}, 0, 2)
+	assert.Equal(t, "ababcdehij", b.GetVisibleLines()[0].String())
+}
+
+func TestFillArea(t *testing.T) {
+	b := makeBufferForTestingAreas()
+	b.CursorAttr().Bold = true
+	b.FillArea('*', Rectangle{Top: 1, Left: 1, Bottom: 2, Right: 3})
+	lines := b.GetVisibleLines()
+	assert.Equal(t, "abcdefghij", lines[0].String())
+	assert.Equal(t, "k***opqrst", lines[1].String())
+	assert.Equal(t, "u***yz0123", lines[2].String())
+	assert.True(t, lines[1].Cells()[1].Attr().Bold)
+	assert.False(t, lines[1].Cells()[4].Attr().Bold)
+}
+
+func TestFillAreaBeyondLineEnd(t *testing.T) {
+	b := NewBuffer(NewTerminalState(10, 4, CellAttributes{}, 1000))
+	b.Write([]rune("ab")...)
+	b.FillArea('x', Rectangle{Top: 0, Left: 4, Bottom: 1, Right: 5})
+	lines := b.GetVisibleLines()
+	assert.Equal(t, "ab\x00\x00xx", lines[0].String())
+	assert.Equal(t, "\x00\x00\x00\x00xx", lines[1].String())
+}
+
+func TestEraseArea(t *testing.T) {
+	b := makeBufferForTestingAreas()
+	b.lines[1].setAttributes(2, CellAttributes{Protected: true})
+	b.EraseArea(Rectangle{Top: 1, Left: 1, Bottom: 2, Right: 3})
+	lines := b.GetVisibleLines()
+	assert.Equal(t, "k\x00\x00\x00opqrst", lines[1].String())
+	assert.Equal(t, "u\x00\x00\x00yz0123", lines[2].String())
+}
+
+func TestSelectiveEraseArea(t *testing.T) {
+	b := makeBufferForTestingAreas()
+	b.lines[1].setAttributes(2, CellAttributes{Protected: true})
+	b.SelectiveEraseArea(Rectangle{Top: 1, Left: 1, Bottom: 2, Right: 3})
+	lines := b.GetVisibleLines()
+	assert.Equal(t, "k\x00m\x00opqrst", lines[1].String())
+	assert.Equal(t, "u\x00\x00\x00yz0123", lines[2].String())
+}
+
+func TestChangeAreaAttributesRectangle(t *testing.T) {
+	b := makeBufferForTestingAreas()
+	b.terminalState.RectangularAttributeExtent = true
+	b.ChangeAreaAttributes(Rectangle{Top: 0, Left: 8, Bottom: 1, Right: 9}, AttributeMask{Bold: true}, AttributeMask{})
+	assert.False(t, b.lines[0].Cells()[7].Attr().Bold)
+	assert.True(t, b.lines[0].Cells()[8].Attr().Bold)
+	assert.False(t, b.lines[1].Cells()[0].Attr().Bold)
+	assert.True(t, b.lines[1].Cells()[9].Attr().Bold)
+
+	b.ChangeAreaAttributes(Rectangle{Top: 0, Left: 0, Bottom: 3, Right: 9}, AttributeMask{}, AttributeMask{Bold: true})
+	assert.False(t, b.lines[0].Cells()[8].Attr().Bold)
+}
+
+func TestChangeAreaAttributesStream(t *testing.T) {
+	b := makeBufferForTestingAreas()
+	b.ChangeAreaAttributes(Rectangle{Top: 0, Left: 8, Bottom: 1, Right: 1}, AttributeMask{Underline: true}, AttributeMask{})
+	assert.False(t, b.lines[0].Cells()[7].Attr().Underline)
+	assert.True(t, b.lines[0].Cells()[9].Attr().Underline)
+	assert.True(t, b.lines[1].Cells()[0].Attr().Underline)
+	assert.True(t, b.lines[1].Cells()[1].Attr().Underline)
+	assert.False(t, b.lines[1].Cells()[2].Attr().Underline)
+}
+
+func TestReverseAreaAttributes(t *testing.T) {
+	b := makeBufferForTestingAreas()
+	b.terminalState.RectangularAttributeExtent = true
+	b.lines[0].setAttributes(1, CellAttributes{Inverse: true})
+	b.ReverseAreaAttributes(Rectangle{Top: 0, Left: 0, Bottom: 0, Right: 1}, AttributeMask{Inverse: true})
+	assert.True(t, b.lines[0].Cells()[0].Attr().Inverse)
+	assert.False(t, b.lines[0].Cells()[1].Attr().Inverse)
+}
+
+func TestAreaChecksum(t *testing.T) {
+	b := NewBuffer(NewTerminalState(10, 4, CellAttributes{}, 1000))
+	b.Write([]rune("AB")...)
+	area := Rectangle{Top: 0, Left: 0, Bottom: 0, Right: 2}
+	sum := uint16('A' + 'B' + ' ')
+	assert.Equal(t, -sum, b.AreaChecksum(area))
+
+	// blank cells are counted as plain spaces, whatever their attributes
+	b.ChangeAreaAttributes(area, AttributeMask{Bold: true}, AttributeMask{})
+	sum += 0x80 * 2
+	assert.Equal(t, -sum, b.AreaChecksum(area))
+}
@@ -0,0 +1,131 @@
+package buffer
+
+import (
+	"image"
+	"sync"
+)
+
+// initial size at which an attribute table is compacted
+const attributeTableLimit = 1 << 14
+
+// attributeEntry is one distinct combination of cell attributes, along with the image drawn in the cell, if any
+type attributeEntry struct {
+	attr  CellAttributes
+	image *image.RGBA
+}
+
+// attributeTable interns the attributes of the cells in a buffer, so that each cell only needs to store an index
+// into the table alongside its rune. Entries are never removed individually; instead the buffer compacts the table
+// once it grows past its limit, keeping only the entries which are still in use. The renderer adds entries when it
+// decompresses lines from the scrollback store, while the parser adds them as it writes, so the table is locked.
+type attributeTable struct {
+	mutex     sync.Mutex
+	entries   []attributeEntry
+	indexes   map[attributeEntry]uint32
+	last      attributeEntry // the entry interned most recently, as neighbouring cells usually share their attributes
+	lastIndex uint32
+	limit     int
+	state     *TerminalState // resolves the colours of the attributes
+}
+
+func newAttributeTable(state *TerminalState) *attributeTable {
+	table := &attributeTable{
+		limit: attributeTableLimit,
+		state: state,
+	}
+	table.reset()
+	return table
+}
+
+// reset removes all entries except the zero entry, which is always at index 0
+func (table *attributeTable) reset() {
+	table.entries = []attributeEntry{{}}
+	table.indexes = map[attributeEntry]uint32{{}: 0}
+	table.last = attributeEntry{}
+	table.lastIndex = 0
+}
+
+// intern returns the index of the entry, adding it to the table if need be
+func (table *attributeTable) intern(entry attributeEntry) uint32 {
+	table.mutex.Lock()
+	if entry != table.last {
+		index, ok := table.indexes[entry]
+		if !ok {
+			index = uint32(len(table.entries))
+			table.entries = append(table.entries, entry)
+			table.indexes[entry] = index
+		}
+		table.last, table.lastIndex = entry, index
+	}
+	index := table.lastIndex
+	table.mutex.Unlock()
+	return index
+}
+
+// get returns the entry at the index, or the zero entry for lines without a table. Compacting the table moves its
+// entries, so an index kept in a copy of a line from before then may give another entry, or the zero entry if it is
+// past the end; such copies must be read before the buffer is written to again.
+func (table *attributeTable) get(index uint32) attributeEntry {
+	if table == nil {
+		return attributeEntry{}
+	}
+	var entry attributeEntry
+	table.mutex.Lock()
+	if int(index) < len(table.entries) {
+		entry = table.entries[index]
+	}
+	table.mutex.Unlock()
+	return entry
+}
+
+// full returns true if the table should be compacted
+func (table *attributeTable) full() bool {
+	table.mutex.Lock()
+	full := len(table.entries) >= table.limit
+	table.mutex.Unlock()
+	return full
+}
+
+// compact removes the entries which are not marked as used, returning the new index of each old one. Entries added
+// since used was made are kept. The limit is raised if most of the entries are still in use, so that the table
+// isn't compacted over and over again.
+func (table *attributeTable) compact(used []bool) []uint32 {
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+
+	entries := table.entries
+	remap := make([]uint32, len(entries))
+
+	table.reset()
+	for i, entry := range entries {
+		if i == 0 || i < len(used) && !used[i] {
+			continue
+		}
+		index := uint32(len(table.entries))
+		table.entries = append(table.entries, entry)
+		table.indexes[entry] = index
+		remap[i] = index
+	}
+
+	for len(table.entries)*2 > table.limit {
+		table.limit *= 2
+	}
+	return remap
+}
+
+// cell materializes a stored cell, resolving its colours
+func (table *attributeTable) cell(stored storedCell) Cell {
+	entry := table.get(stored.attr)
+	var state *TerminalState
+	if table != nil {
+		state = table.state
+	}
+	fg, bg := state.resolveColours(entry.attr)
+	return Cell{
+		r:     stored.r,
+		attr:  entry.attr,
+		fg:    fg,
+		bg:    bg,
+		image: entry.image,
+	}
+}
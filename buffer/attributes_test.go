@@ -0,0 +1,100 @@
+package buffer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttributeTableInterns(t *testing.T) {
+	table := newAttributeTable(nil)
+	bold := table.intern(attributeEntry{attr: CellAttributes{Bold: true}})
+	red := table.intern(attributeEntry{attr: CellAttributes{FgColour: PaletteColour(1)}})
+
+	assert.Equal(t, uint32(0), table.intern(attributeEntry{}))
+	assert.Equal(t, bold, table.intern(attributeEntry{attr: CellAttributes{Bold: true}}))
+	assert.NotEqual(t, bold, red)
+	assert.Len(t, table.entries, 3)
+	assert.True(t, table.get(bold).attr.Bold)
+	assert.Equal(t, attributeEntry{}, table.get(1000))
+}
+
+func TestCellsShareAttributes(t *testing.T) {
+	b := NewBuffer(NewTerminalState(80, 5, CellAttributes{}, 1000))
+	b.CursorAttr().Bold = true
+	b.Write([]rune("hello")...)
+	b.CursorAttr().Bold = false
+	b.Write([]rune("world")...)
+
+	assert.Len(t, b.attrs.entries, 2)
+	cells := b.lines[0].Cells()
+	assert.True(t, cells[4].Attr().Bold)
+	assert.False(t, cells[5].Attr().Bold)
+}
+
+func TestCellsResolveColoursWithPalette(t *testing.T) {
+	state := NewTerminalState(80, 5, CellAttributes{}, 1000)
+	b := NewBuffer(state)
+	b.CursorAttr().FgColour = PaletteColour(2)
+	b.CursorAttr().BgColour = RGBColour(0, 0, 0xff)
+	b.Write('x')
+
+	var palette Palette
+	palette.Colours[2] = [3]float32{0, 1, 0}
+	state.SetPalette(palette)
+
+	cell := b.GetCell(0, 0)
+	require.NotNil(t, cell)
+	assert.Equal(t, [3]float32{0, 1, 0}, cell.Fg())
+	assert.Equal(t, [3]float32{0, 0, 1}, cell.Bg())
+
+	// changing the palette changes the colours of the text which is already there
+	palette.Colours[2] = [3]float32{1, 0, 1}
+	state.SetPalette(palette)
+	assert.Equal(t, [3]float32{1, 0, 1}, b.GetCell(0, 0).Fg())
+}
+
+func TestScreenModeSwapsColours(t *testing.T) {
+	state := NewTerminalState(80, 5, CellAttributes{}, 1000)
+	state.SetPalette(Palette{DefaultFg: [3]float32{1, 1, 1}})
+	b := NewBuffer(state)
+	b.Write('x')
+
+	state.ScreenMode = true
+	cell := b.lines[0].Cells()[0]
+	assert.Equal(t, [3]float32{0, 0, 0}, cell.Fg())
+	assert.Equal(t, [3]float32{1, 1, 1}, cell.Bg())
+	blank := state.DefaultCell(true)
+	assert.Equal(t, [3]float32{1, 1, 1}, blank.Bg())
+
+	state.ScreenMode = false
+	assert.Equal(t, [3]float32{1, 1, 1}, b.lines[0].Cells()[0].Fg())
+}
+
+func TestAttributeTableCompaction(t *testing.T) {
+	b := NewBuffer(NewTerminalState(10, 3, CellAttributes{}, 3))
+	for i := 0; i < attributeTableLimit+10; i++ {
+		b.CursorAttr().FgColour = RGBColour(uint8(i>>16), uint8(i>>8), uint8(i))
+		b.Write([]rune(fmt.Sprintf("%d", i%10))...)
+	}
+
+	// only the colours of the cells still in the buffer are kept
+	assert.True(t, len(b.attrs.entries) < 40)
+	assert.Equal(t, attributeTableLimit, b.attrs.limit)
+	cells := b.lines[len(b.lines)-1].Cells()
+	last := attributeTableLimit + 9
+	assert.Equal(t, RGBColour(uint8(last>>16), uint8(last>>8), uint8(last)), cells[len(cells)-1].Attr().FgColour)
+}
+
+func TestAttributeTableGrowsWhenMostEntriesAreUsed(t *testing.T) {
+	b := NewBuffer(NewTerminalState(100, 100, CellAttributes{}, 1000))
+	for i := 0; i < attributeTableLimit+10; i++ {
+		b.CursorAttr().FgColour = RGBColour(uint8(i>>16), uint8(i>>8), uint8(i))
+		b.Write('x')
+	}
+
+	assert.Equal(t, attributeTableLimit*2, b.attrs.limit)
+	assert.Equal(t, RGBColour(0, 0, 1), b.lines[0].Cells()[1].Attr().FgColour)
+}
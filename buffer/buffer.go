@@ -3,10 +3,12 @@ package buffer
 import (
 	"bytes"
 	"fmt"
+	"image"
 	"io/ioutil"
 	"net/url"
 	"os"
 	"strings"
+	"unsafe"
 )
 
 type SelectionMode int
@@ -19,6 +21,13 @@ const (
 
 type Buffer struct {
 	lines                 []Line
+	attrs                 *attributeTable // the attributes of the cells of the lines
+	cursorX               uint16
+	cursorY               uint16
+	maxLines              uint64
+	maxBytes              uint64
+	store                 *scrollbackStore // the oldest lines of the scrollback, if they are compressed
+	memoryLines           uint64           // lines kept in memory when there is a store
 	displayChangeHandlers []chan bool
 	savedX                uint16
 	savedY                uint16
@@ -31,6 +40,9 @@ type Buffer struct {
 	terminalState         *TerminalState
 	savedCharsets         []*map[rune]rune
 	savedCurrentCharset   int
+	savedCurrentCharsetGR int
+	savedSingleShift      int
+	scrollbackHandler     func(line *Line) // called with each line as it scrolls off the top of the view
 }
 
 type Position struct {
@@ -53,20 +65,130 @@ func comparePositions(pos1 *Position, pos2 *Position) int {
 func NewBuffer(terminalState *TerminalState) *Buffer {
 	b := &Buffer{
 		lines:               []Line{},
+		attrs:               newAttributeTable(terminalState),
 		selectionStart:      nil,
 		selectionEnd:        nil,
 		selectionMode:       SelectionChar,
 		isSelectionComplete: true,
 		terminalState:       terminalState,
+		maxLines:            terminalState.maxLines,
 	}
 	return b
 }
 
+// SetScrollbackHandler sets a function to be called with each line as it scrolls off the top of the view into
+// the scrollback. Lines scrolled out of a region within the margins are not passed to it, as they are discarded.
+func (buffer *Buffer) SetScrollbackHandler(handler func(line *Line)) {
+	buffer.scrollbackHandler = handler
+}
+
+// SetMaxLines sets the number of lines kept by the buffer, including the scrollback
+func (buffer *Buffer) SetMaxLines(maxLines uint64) {
+	buffer.maxLines = maxLines
+}
+
+// SetMaxBytes caps the memory used by the lines of the buffer, so that very wide views or huge amounts
+// of wrapped output cannot exhaust it. Zero means no limit beyond the number of lines.
+func (buffer *Buffer) SetMaxBytes(maxBytes uint64) {
+	buffer.maxBytes = maxBytes
+}
+
+// EnableScrollbackStore keeps at most memoryLines lines in memory, compressing older lines of the scrollback
+// rather than dropping them, up to the maximum number of lines. The compressed lines are written to an encrypted
+// temporary file if spillToDisk is set.
+func (buffer *Buffer) EnableScrollbackStore(memoryLines uint64, spillToDisk bool) {
+	buffer.store = newScrollbackStore(spillToDisk, buffer.attrs)
+	buffer.memoryLines = memoryLines
+}
+
+// Close releases the file used by the scrollback store, if any
+func (buffer *Buffer) Close() error {
+	if buffer.store == nil {
+		return nil
+	}
+	return buffer.store.close()
+}
+
+// archiveLines moves lines which no longer fit in memory to the scrollback store, if there is one, or else
+// lets them be dropped
+func (buffer *Buffer) archiveLines(lines []Line) {
+	if buffer.store == nil {
+		return
+	}
+	buffer.store.push(lines)
+
+	// the lines in memory and in the store together are limited to the maximum number of lines
+	keep := 0
+	if memoryLines := buffer.getMaxLines(); memoryLines < buffer.maxLines {
+		keep = int(buffer.maxLines - memoryLines)
+	}
+	buffer.store.trim(keep)
+}
+
+// lineAt returns the line at an index into the lines in memory, where negative indexes count back through the
+// lines in the scrollback store. It returns nil if there is no such line.
+func (buffer *Buffer) lineAt(index int) *Line {
+	if index >= 0 {
+		if index < len(buffer.lines) {
+			return &buffer.lines[index]
+		}
+		return nil
+	}
+
+	if buffer.store == nil || buffer.store.Len()+index < 0 {
+		return nil
+	}
+	return buffer.store.line(buffer.store.Len() + index)
+}
+
+// cellAt returns the cell at a column of the line given by lineAt
+func (buffer *Buffer) cellAt(col uint16, index int) *storedCell {
+	line := buffer.lineAt(index)
+	if line == nil || int(col) >= len(line.cells) {
+		return nil
+	}
+	return &line.cells[col]
+}
+
+// viewRowToLine converts a row of the view, scrolled back through the scrollback, to an index for lineAt
+func (buffer *Buffer) viewRowToLine(viewRow uint16) int {
+	return int(buffer.convertViewLineToRawLine(viewRow)) - int(buffer.terminalState.scrollLinesFromBottom)
+}
+
+// TotalHeight returns the number of lines in the buffer, including those in the scrollback store
+func (buffer *Buffer) TotalHeight() int {
+	if buffer.store == nil {
+		return buffer.Height()
+	}
+	return buffer.store.Len() + buffer.Height()
+}
+
+// ClearScrollback removes all lines which have scrolled out of the view
+func (buffer *Buffer) ClearScrollback() {
+	defer buffer.emitDisplayChange()
+
+	if buffer.store != nil {
+		buffer.store.clear()
+	}
+
+	if len(buffer.lines) > int(buffer.ViewHeight()) {
+		lines := make([]Line, buffer.ViewHeight())
+		copy(lines, buffer.lines[len(buffer.lines)-int(buffer.ViewHeight()):])
+		buffer.lines = lines
+	}
+	buffer.terminalState.scrollLinesFromBottom = 0
+
+	// the selection refers to raw line numbers, which have just changed
+	buffer.selectionStart = nil
+	buffer.selectionEnd = nil
+	buffer.isSelectionComplete = true
+}
+
 func (buffer *Buffer) GetURLAtPosition(col uint16, viewRow uint16) string {
 
-	row := buffer.convertViewLineToRawLine((viewRow)) - uint64(buffer.terminalState.scrollLinesFromBottom)
+	row := buffer.viewRowToLine(viewRow)
 
-	cell := buffer.GetRawCell(col, row)
+	cell := buffer.cellAt(col, row)
 	if cell == nil || cell.Rune() == 0x00 {
 		return ""
 	}
@@ -74,7 +196,7 @@ func (buffer *Buffer) GetURLAtPosition(col uint16, viewRow uint16) string {
 	candidate := ""
 
 	for i := col; i >= uint16(0); i-- {
-		cell := buffer.GetRawCell(i, row)
+		cell := buffer.cellAt(i, row)
 		if cell == nil {
 			break
 		}
@@ -85,7 +207,7 @@ func (buffer *Buffer) GetURLAtPosition(col uint16, viewRow uint16) string {
 	}
 
 	for i := col + 1; i < buffer.terminalState.viewWidth; i++ {
-		cell := buffer.GetRawCell(i, row)
+		cell := buffer.cellAt(i, row)
 		if cell == nil {
 			break
 		}
@@ -114,7 +236,7 @@ func (buffer *Buffer) IsSelectionComplete() bool {
 func (buffer *Buffer) findEndOfWord(col int, row int) int {
 	end := col
 	for i := col; i < int(buffer.terminalState.viewWidth); i++ {
-		cell := buffer.GetRawCell(uint16(i), uint64(row))
+		cell := buffer.cellAt(uint16(i), row)
 		if cell == nil {
 			break
 		}
@@ -129,7 +251,7 @@ func (buffer *Buffer) findEndOfWord(col int, row int) int {
 func (buffer *Buffer) findBeginningOfWord(col int, row int) int {
 	start := col
 	for i := col; i >= 0; i-- {
-		cell := buffer.GetRawCell(uint16(i), uint64(row))
+		cell := buffer.cellAt(uint16(i), row)
 		if cell == nil {
 			break
 		}
@@ -170,12 +292,11 @@ func (buffer *Buffer) GetSelectedText() string {
 	builder.Grow(int(buffer.terminalState.viewWidth) * (end.Line - start.Line + 1)) // reserve space to minimize allocations
 
 	for row := start.Line; row <= end.Line; row++ {
-		if row >= len(buffer.lines) {
+		line := buffer.lineAt(row)
+		if line == nil {
 			break
 		}
 
-		line := buffer.lines[row]
-
 		minX := 0
 		maxX := int(buffer.terminalState.viewWidth) - 1
 		if row == start.Line {
@@ -202,8 +323,47 @@ func (buffer *Buffer) GetSelectedText() string {
 	return builder.String()
 }
 
+// GetSelectedLines returns copies of the selected parts of the lines, with their attributes
+func (buffer *Buffer) GetSelectedLines() []Line {
+	start, end := buffer.getActualSelection()
+	if start == nil || end == nil {
+		return nil
+	}
+
+	lines := []Line{}
+	for row := start.Line; row <= end.Line; row++ {
+		line := buffer.lineAt(row)
+		if line == nil {
+			break
+		}
+
+		minX := 0
+		maxX := len(line.cells)
+		if row == start.Line {
+			minX = start.Col
+		}
+		if row == end.Line && end.Col+1 < maxX {
+			maxX = end.Col + 1
+		}
+		if minX > maxX {
+			minX = maxX
+		}
+
+		selected := newLine(line.attrs)
+		selected.size = line.size
+		selected.cells = append(selected.cells, line.cells[minX:maxX]...)
+		if row != start.Line {
+			selected.wrapped = line.wrapped
+			selected.continued = line.continued
+		}
+		lines = append(lines, selected)
+	}
+
+	return lines
+}
+
 func (buffer *Buffer) StartSelection(col uint16, viewRow uint16, mode SelectionMode) {
-	row := buffer.convertViewLineToRawLine(viewRow) - uint64(buffer.terminalState.scrollLinesFromBottom)
+	row := buffer.viewRowToLine(viewRow)
 	buffer.selectionMode = mode
 
 	buffer.selectionStart = &Position{
@@ -238,7 +398,7 @@ func (buffer *Buffer) ExtendSelection(col uint16, viewRow uint16, complete bool)
 		return
 	}
 
-	row := buffer.convertViewLineToRawLine(viewRow) - uint64(buffer.terminalState.scrollLinesFromBottom)
+	row := buffer.viewRowToLine(viewRow)
 
 	buffer.selectionEnd = &Position{
 		Col:  int(col),
@@ -293,13 +453,13 @@ func (buffer *Buffer) getActualSelection() (*Position, *Position) {
 		end.Col = int(buffer.ViewWidth() - 1)
 	}
 
-	if start.Line >= len(buffer.lines) {
+	if line := buffer.lineAt(start.Line); line == nil {
 		start.Col = 0
-	} else if start.Col >= len(buffer.lines[start.Line].cells) {
-		start.Col = len(buffer.lines[start.Line].cells)
+	} else if start.Col >= len(line.cells) {
+		start.Col = len(line.cells)
 	}
 
-	if end.Line >= len(buffer.lines) || end.Col >= len(buffer.lines[end.Line].cells) {
+	if line := buffer.lineAt(end.Line); line == nil || end.Col >= len(line.cells) {
 		end.Col = int(buffer.ViewWidth() - 1)
 	}
 
@@ -312,7 +472,7 @@ func (buffer *Buffer) InSelection(col uint16, row uint16) bool {
 		return false
 	}
 
-	rawY := int(buffer.convertViewLineToRawLine(row) - uint64(buffer.terminalState.scrollLinesFromBottom))
+	rawY := buffer.viewRowToLine(row)
 
 	return (rawY > start.Line || (rawY == start.Line && int(col) >= start.Col)) &&
 		(rawY < end.Line || (rawY == end.Line && int(col) <= end.Col))
@@ -331,7 +491,7 @@ func (buffer *Buffer) HasScrollableRegion() bool {
 }
 
 func (buffer *Buffer) InScrollableRegion() bool {
-	return buffer.HasScrollableRegion() && uint(buffer.terminalState.cursorY) >= buffer.terminalState.topMargin && uint(buffer.terminalState.cursorY) <= buffer.terminalState.bottomMargin
+	return buffer.HasScrollableRegion() && uint(buffer.cursorY) >= buffer.terminalState.topMargin && uint(buffer.cursorY) <= buffer.terminalState.bottomMargin
 }
 
 // NOTE: bottom is exclusive
@@ -355,7 +515,7 @@ func (buffer *Buffer) AreaScrollDown(lines uint16) {
 		if i >= top+uint64(lines) {
 			buffer.lines[i] = buffer.lines[i-uint64(lines)]
 		} else {
-			buffer.lines[i] = newLine()
+			buffer.lines[i] = newLine(buffer.attrs)
 		}
 	}
 }
@@ -371,7 +531,7 @@ func (buffer *Buffer) AreaScrollUp(lines uint16) {
 		if from < bottom {
 			buffer.lines[i] = buffer.lines[from]
 		} else {
-			buffer.lines[i] = newLine()
+			buffer.lines[i] = newLine(buffer.attrs)
 		}
 	}
 }
@@ -379,11 +539,13 @@ func (buffer *Buffer) AreaScrollUp(lines uint16) {
 func (buffer *Buffer) SaveCursor() {
 	copiedAttr := buffer.terminalState.CursorAttr
 	buffer.savedCursorAttr = &copiedAttr
-	buffer.savedX = buffer.terminalState.cursorX
-	buffer.savedY = buffer.terminalState.cursorY
+	buffer.savedX = buffer.cursorX
+	buffer.savedY = buffer.cursorY
 	buffer.savedCharsets = make([]*map[rune]rune, len(buffer.terminalState.Charsets))
 	copy(buffer.savedCharsets, buffer.terminalState.Charsets)
 	buffer.savedCurrentCharset = buffer.terminalState.CurrentCharset
+	buffer.savedCurrentCharsetGR = buffer.terminalState.CurrentCharsetGR
+	buffer.savedSingleShift = buffer.terminalState.SingleShift
 }
 
 func (buffer *Buffer) RestoreCursor() {
@@ -391,12 +553,14 @@ func (buffer *Buffer) RestoreCursor() {
 		copiedAttr := *buffer.savedCursorAttr
 		buffer.terminalState.CursorAttr = copiedAttr // @todo ignore colors?
 	}
-	buffer.terminalState.cursorX = buffer.savedX
-	buffer.terminalState.cursorY = buffer.savedY
+	buffer.cursorX = buffer.savedX
+	buffer.cursorY = buffer.savedY
 	if buffer.savedCharsets != nil {
 		buffer.terminalState.Charsets = make([]*map[rune]rune, len(buffer.savedCharsets))
 		copy(buffer.terminalState.Charsets, buffer.savedCharsets)
 		buffer.terminalState.CurrentCharset = buffer.savedCurrentCharset
+		buffer.terminalState.CurrentCharsetGR = buffer.savedCurrentCharsetGR
+		buffer.terminalState.SingleShift = buffer.savedSingleShift
 	}
 }
 
@@ -418,7 +582,23 @@ func (buffer *Buffer) GetRawCell(viewCol uint16, rawLine uint64) *Cell {
 	if int(viewCol) >= len(line.cells) {
 		return nil
 	}
-	return &line.cells[viewCol]
+	cell := line.attrs.cell(line.cells[viewCol])
+	return &cell
+}
+
+// SetCellImage sets the image drawn in the cell at the view position, if there is a cell there
+func (buffer *Buffer) SetCellImage(viewCol uint16, viewRow uint16, img *image.RGBA) {
+	rawLine := buffer.convertViewLineToRawLine(viewRow)
+	if int(rawLine) >= len(buffer.lines) {
+		return
+	}
+	line := &buffer.lines[rawLine]
+	if int(viewCol) >= len(line.cells) {
+		return
+	}
+	entry := buffer.attrs.get(line.cells[viewCol].attr)
+	entry.image = img
+	line.cells[viewCol].attr = buffer.attrs.intern(entry)
 }
 
 func (buffer *Buffer) emitDisplayChange() {
@@ -428,24 +608,24 @@ func (buffer *Buffer) emitDisplayChange() {
 // Column returns cursor column
 func (buffer *Buffer) CursorColumn() uint16 {
 	// @todo originMode and left margin
-	return buffer.terminalState.cursorX
+	return buffer.cursorX
 }
 
 // CursorLineAbsolute returns absolute cursor line coordinate (ignoring Origin Mode)
 func (buffer *Buffer) CursorLineAbsolute() uint16 {
-	return buffer.terminalState.cursorY
+	return buffer.cursorY
 }
 
 // CursorLine returns cursor line (in Origin Mode it is relative to the top margin)
 func (buffer *Buffer) CursorLine() uint16 {
 	if buffer.terminalState.OriginMode {
-		result := buffer.terminalState.cursorY - uint16(buffer.terminalState.topMargin)
+		result := buffer.cursorY - uint16(buffer.terminalState.topMargin)
 		if result < 0 {
 			result = 0
 		}
 		return result
 	}
-	return buffer.terminalState.cursorY
+	return buffer.cursorY
 }
 
 func (buffer *Buffer) TopMargin() uint {
@@ -458,7 +638,7 @@ func (buffer *Buffer) BottomMargin() uint {
 
 // translates the cursor line to the raw buffer line
 func (buffer *Buffer) RawLine() uint64 {
-	return buffer.convertViewLineToRawLine(buffer.terminalState.cursorY)
+	return buffer.convertViewLineToRawLine(buffer.cursorY)
 }
 
 func (buffer *Buffer) convertViewLineToRawLine(viewLine uint16) uint64 {
@@ -486,71 +666,105 @@ func (buffer *Buffer) ViewWidth() uint16 {
 	return buffer.terminalState.viewWidth
 }
 
-func (buffer *Buffer) Height() int {
-	return len(buffer.lines)
+// CursorLineWidth returns the number of columns available on the cursor line, which is halved for double size lines
+func (buffer *Buffer) CursorLineWidth() uint16 {
+	// this is also called while rendering, so unlike getCurrentLine it must not add lines; a line which hasn't
+	// been written to yet is single width
+	var line *Line
+	if buffer.cursorY < buffer.ViewHeight() {
+		if rawLine := int(buffer.convertViewLineToRawLine(buffer.cursorY)); rawLine < len(buffer.lines) {
+			line = &buffer.lines[rawLine]
+		}
+	}
+	return buffer.lineWidth(line)
 }
 
-func (buffer *Buffer) ViewHeight() uint16 {
-	return buffer.terminalState.viewHeight
+// ResetLineSizes makes all lines in the view single width again
+func (buffer *Buffer) ResetLineSizes() {
+	defer buffer.emitDisplayChange()
+	for i := uint16(0); i < buffer.ViewHeight(); i++ {
+		rawLine := buffer.convertViewLineToRawLine(i)
+		if int(rawLine) < len(buffer.lines) {
+			buffer.lines[int(rawLine)].size = LineSizeSingle
+		}
+	}
 }
 
-func (buffer *Buffer) deleteLine() {
-	index := int(buffer.RawLine())
-	buffer.lines = buffer.lines[:index+copy(buffer.lines[index:], buffer.lines[index+1:])]
+func (buffer *Buffer) lineWidth(line *Line) uint16 {
+	if line != nil && line.IsDoubleWidth() && buffer.terminalState.viewWidth > 1 {
+		return buffer.terminalState.viewWidth / 2
+	}
+	return buffer.terminalState.viewWidth
 }
 
-func (buffer *Buffer) insertLine() {
-
+// SetLineSize changes the size of the characters on the cursor line (DECSWL, DECDWL, DECDHL).
+// Characters which no longer fit on a double width line are lost.
+func (buffer *Buffer) SetLineSize(size LineSize) {
 	defer buffer.emitDisplayChange()
 
-	if !buffer.InScrollableRegion() {
-		pos := buffer.RawLine()
-		maxLines := buffer.getMaxLines()
-		newLineCount := uint64(len(buffer.lines) + 1)
-		if newLineCount > maxLines {
-			newLineCount = maxLines
-		}
+	line := buffer.getCurrentLine()
+	line.size = size
 
-		out := make([]Line, newLineCount)
-		copy(
-			out[:pos-(uint64(len(buffer.lines))+1-newLineCount)],
-			buffer.lines[uint64(len(buffer.lines))+1-newLineCount:pos])
-		out[pos] = newLine()
-		copy(out[pos+1:], buffer.lines[pos:])
-		buffer.lines = out
-	} else {
-		topIndex := buffer.convertViewLineToRawLine(uint16(buffer.terminalState.topMargin))
-		bottomIndex := buffer.convertViewLineToRawLine(uint16(buffer.terminalState.bottomMargin))
-		before := buffer.lines[:topIndex]
-		after := buffer.lines[bottomIndex+1:]
-		out := make([]Line, len(buffer.lines))
-		copy(out[0:], before)
-
-		pos := buffer.RawLine()
-		for i := topIndex; i < bottomIndex; i++ {
-			if i < pos {
-				out[i] = buffer.lines[i]
-			} else {
-				out[i+1] = buffer.lines[i]
-			}
-		}
+	width := buffer.lineWidth(line)
+	if len(line.cells) > int(width) {
+		line.cells = line.cells[:width]
+	}
+	if buffer.cursorX >= width {
+		buffer.cursorX = width - 1
+	}
+}
 
-		copy(out[bottomIndex+1:], after)
+func (buffer *Buffer) Height() int {
+	return len(buffer.lines)
+}
 
-		out[pos] = newLine()
-		buffer.lines = out
-	}
+func (buffer *Buffer) ViewHeight() uint16 {
+	return buffer.terminalState.viewHeight
 }
 
 func (buffer *Buffer) InsertBlankCharacters(count int) {
+	defer buffer.emitDisplayChange()
 
-	index := int(buffer.RawLine())
+	line := buffer.getCurrentLine()
+	cursorX := int(buffer.cursorX)
+	width := int(buffer.ViewWidth())
+	if cursorX >= len(line.cells) || cursorX >= width {
+		// there is nothing after the cursor to shift along
+		return
+	}
+	if count > width-cursorX {
+		count = width - cursorX
+	}
+
+	cells := make([]storedCell, 0, len(line.cells)+count)
+	cells = append(cells, line.cells[:cursorX]...)
 	for i := 0; i < count; i++ {
-		cells := buffer.lines[index].cells
-		buffer.lines[index].cells = append(cells[:buffer.terminalState.cursorX], append([]Cell{buffer.terminalState.DefaultCell(true)}, cells[buffer.terminalState.cursorX:]...)...)
+		cells = append(cells, buffer.defaultCell(true))
+	}
+	cells = append(cells, line.cells[cursorX:]...)
+
+	// characters shifted past the right edge are lost
+	if len(cells) > width {
+		cells = cells[:width]
 	}
+	line.cells = cells
 }
 
+// cursorScrollRange returns the raw lines from the cursor line to the bottom margin, which insert and delete line
+// operate on. Bottom is exclusive, and limited to the lines which exist; those below are blank anyway. Height is the
+// number of view lines in the range, whether they exist or not.
+func (buffer *Buffer) cursorScrollRange() (top uint64, bottom uint64, height int) {
+	height = int(buffer.terminalState.bottomMargin) - int(buffer.cursorY) + 1
+	top = buffer.RawLine()
+	bottom = buffer.convertViewLineToRawLine(uint16(buffer.terminalState.bottomMargin)) + 1
+	if bottom > uint64(len(buffer.lines)) {
+		bottom = uint64(len(buffer.lines))
+	}
+	return top, bottom, height
+}
+
+// InsertLines inserts blank lines at the cursor line, pushing the lines below it down. Lines pushed past the bottom
+// margin are lost.
 func (buffer *Buffer) InsertLines(count int) {
 
 	if buffer.HasScrollableRegion() && !buffer.InScrollableRegion() {
@@ -558,14 +772,37 @@ func (buffer *Buffer) InsertLines(count int) {
 		return
 	}
 
-	buffer.terminalState.cursorX = 0
+	defer buffer.emitDisplayChange()
+
+	buffer.cursorX = 0
 
-	for i := 0; i < count; i++ {
-		buffer.insertLine()
+	top, bottom, height := buffer.cursorScrollRange()
+	if count > height {
+		count = height
+	}
+
+	// the lines pushed down may need lines to go to, up to the bottom margin
+	limit := buffer.convertViewLineToRawLine(uint16(buffer.terminalState.bottomMargin)) + 1
+	if needed := bottom + uint64(count); needed < limit {
+		limit = needed
+	}
+	for top < bottom && bottom < limit {
+		buffer.lines = append(buffer.lines, newLine(buffer.attrs))
+		bottom++
 	}
 
+	for i := bottom; i > top; {
+		i--
+		if i >= top+uint64(count) {
+			buffer.lines[i] = buffer.lines[i-uint64(count)]
+		} else {
+			buffer.lines[i] = newLine(buffer.attrs)
+		}
+	}
 }
 
+// DeleteLines deletes lines from the cursor line down, pulling the lines below them up. Blank lines are added at the
+// bottom margin.
 func (buffer *Buffer) DeleteLines(count int) {
 
 	if buffer.HasScrollableRegion() && !buffer.InScrollableRegion() {
@@ -573,12 +810,22 @@ func (buffer *Buffer) DeleteLines(count int) {
 		return
 	}
 
-	buffer.terminalState.cursorX = 0
+	defer buffer.emitDisplayChange()
 
-	for i := 0; i < count; i++ {
-		buffer.deleteLine()
+	buffer.cursorX = 0
+
+	top, bottom, height := buffer.cursorScrollRange()
+	if count > height {
+		count = height
 	}
 
+	for i := top; i < bottom; i++ {
+		if from := i + uint64(count); from < bottom {
+			buffer.lines[i] = buffer.lines[from]
+		} else {
+			buffer.lines[i] = newLine(buffer.attrs)
+		}
+	}
 }
 
 func (buffer *Buffer) Index() {
@@ -590,8 +837,8 @@ func (buffer *Buffer) Index() {
 
 	if buffer.InScrollableRegion() {
 
-		if uint(buffer.terminalState.cursorY) < buffer.terminalState.bottomMargin {
-			buffer.terminalState.cursorY++
+		if uint(buffer.cursorY) < buffer.terminalState.bottomMargin {
+			buffer.cursorY++
 		} else {
 			buffer.AreaScrollUp(1)
 		}
@@ -599,15 +846,25 @@ func (buffer *Buffer) Index() {
 		return
 	}
 
-	if buffer.terminalState.cursorY >= buffer.ViewHeight()-1 {
-		buffer.lines = append(buffer.lines, newLine())
-		maxLines := buffer.getMaxLines()
-		if uint64(len(buffer.lines)) > maxLines {
-			copy(buffer.lines, buffer.lines[uint64(len(buffer.lines))-maxLines:])
-			buffer.lines = buffer.lines[:maxLines]
-		}
+	if buffer.cursorY >= buffer.ViewHeight()-1 {
+		buffer.appendLine()
 	} else {
-		buffer.terminalState.cursorY++
+		buffer.cursorY++
+	}
+}
+
+// appendLine adds a blank line at the bottom of the view, scrolling the top line of the view into the scrollback
+func (buffer *Buffer) appendLine() {
+	buffer.lines = append(buffer.lines, newLine(buffer.attrs))
+	if top := len(buffer.lines) - int(buffer.ViewHeight()) - 1; top >= 0 && buffer.scrollbackHandler != nil {
+		buffer.scrollbackHandler(&buffer.lines[top])
+	}
+	maxLines := buffer.getMaxLines()
+	if uint64(len(buffer.lines)) > maxLines {
+		excess := uint64(len(buffer.lines)) - maxLines
+		buffer.archiveLines(buffer.lines[:excess])
+		copy(buffer.lines, buffer.lines[excess:])
+		buffer.lines = buffer.lines[:maxLines]
 	}
 }
 
@@ -615,16 +872,18 @@ func (buffer *Buffer) ReverseIndex() {
 
 	defer buffer.emitDisplayChange()
 
-	if uint(buffer.terminalState.cursorY) == buffer.terminalState.topMargin {
+	if uint(buffer.cursorY) == buffer.terminalState.topMargin {
 		buffer.AreaScrollDown(1)
-	} else if buffer.terminalState.cursorY > 0 {
-		buffer.terminalState.cursorY--
+	} else if buffer.cursorY > 0 {
+		buffer.cursorY--
 	}
 }
 
 // Write will write a rune to the terminal at the position of the cursor, and increment the cursor position
 func (buffer *Buffer) Write(runes ...rune) {
 	defer buffer.emitDisplayChange()
+	buffer.compactAttributes()
+	attr := buffer.attrs.intern(attributeEntry{attr: buffer.terminalState.CursorAttr})
 	// scroll to bottom on input
 	buffer.terminalState.scrollLinesFromBottom = 0
 
@@ -634,33 +893,25 @@ func (buffer *Buffer) Write(runes ...rune) {
 
 		if buffer.terminalState.ReplaceMode {
 
-			if buffer.CursorColumn() >= buffer.Width() {
+			if buffer.CursorColumn() >= buffer.lineWidth(line) {
 				// @todo replace rune at position 0 on next line down
 				return
 			}
 
-			for int(buffer.CursorColumn()) >= len(line.cells) {
-				line.Append(buffer.terminalState.DefaultCell(int(buffer.CursorColumn()) == len(line.cells)))
-			}
-			line.cells[buffer.terminalState.cursorX].attr = buffer.terminalState.CursorAttr
-			line.cells[buffer.terminalState.cursorX].setRune(r)
+			buffer.writeRune(line, int(buffer.cursorX), r, attr)
 			buffer.incrementCursorPosition()
 			continue
 		}
 
-		if buffer.CursorColumn() >= buffer.Width() { // if we're after the line, move to next
+		if buffer.CursorColumn() >= buffer.lineWidth(line) { // if we're after the line, move to next
 
 			if buffer.terminalState.AutoWrap {
 
 				buffer.NewLineEx(true)
 
 				newLine := buffer.getCurrentLine()
-				if len(newLine.cells) == 0 {
-					newLine.Append(buffer.terminalState.DefaultCell(true))
-				}
-				cell := &newLine.cells[0]
-				cell.setRune(r)
-				cell.attr = buffer.terminalState.CursorAttr
+				newLine.continued = true
+				buffer.writeRune(newLine, 0, r, attr)
 
 			} else {
 				// no more room on line and wrapping is disabled
@@ -670,24 +921,31 @@ func (buffer *Buffer) Write(runes ...rune) {
 			// @todo if next line is wrapped then prepend to it and shuffle characters along line, wrapping to next if necessary
 		} else {
 
-			for int(buffer.CursorColumn()) >= len(line.cells) {
-				line.Append(buffer.terminalState.DefaultCell(int(buffer.CursorColumn()) == len(line.cells)))
-			}
-
-			cell := &line.cells[buffer.CursorColumn()]
-			cell.setRune(r)
-			cell.attr = buffer.terminalState.CursorAttr
+			buffer.writeRune(line, int(buffer.CursorColumn()), r, attr)
 		}
 
 		buffer.incrementCursorPosition()
 	}
 }
 
+// writeRune puts a rune in the cell at a column of the line, with the attributes at an index into the table. Blank
+// cells are added to the line first if it doesn't reach the column yet.
+func (buffer *Buffer) writeRune(line *Line, col int, r rune, attrIndex uint32) {
+	for col > len(line.cells) {
+		line.appendCells(buffer.defaultCell(false))
+	}
+	if col == len(line.cells) {
+		line.appendCells(storedCell{r: r, attr: attrIndex})
+		return
+	}
+	line.writeCell(col, r, attrIndex)
+}
+
 func (buffer *Buffer) incrementCursorPosition() {
 	// we can increment one column past the end of the line.
 	// this is effectively the beginning of the next line, except when we \r etc.
-	if buffer.CursorColumn() < buffer.Width() {
-		buffer.terminalState.cursorX++
+	if buffer.CursorColumn() < buffer.CursorLineWidth() {
+		buffer.cursorX++
 	}
 }
 
@@ -695,12 +953,12 @@ func (buffer *Buffer) inDoWrap() bool {
 	// xterm uses 'do_wrap' flag for this special terminal state
 	// we use the cursor position right after the boundary
 	// let's see how it works out
-	return buffer.terminalState.cursorX == buffer.terminalState.viewWidth // @todo rightMargin
+	return buffer.cursorX == buffer.CursorLineWidth() // @todo rightMargin
 }
 
 func (buffer *Buffer) Backspace() {
 
-	if buffer.terminalState.cursorX == 0 {
+	if buffer.cursorX == 0 {
 		line := buffer.getCurrentLine()
 		if line.wrapped {
 			buffer.MovePosition(int16(buffer.Width()-1), -1)
@@ -722,25 +980,46 @@ func (buffer *Buffer) CarriageReturn() {
 		if line == nil {
 			break
 		}
-		if line.wrapped && buffer.terminalState.cursorY > 0 {
-			buffer.terminalState.cursorY--
+		if line.wrapped && buffer.cursorY > 0 {
+			buffer.cursorY--
 		} else {
 			break
 		}
 	}
 
-	buffer.terminalState.cursorX = 0
+	buffer.cursorX = 0
 }
 
 func (buffer *Buffer) Tab() {
-	for buffer.terminalState.cursorX < buffer.terminalState.viewWidth-1 { // @todo rightMargin
+	for buffer.cursorX < buffer.CursorLineWidth()-1 { // @todo rightMargin
 		buffer.Write(' ')
-		if buffer.terminalState.IsTabSetAtCursor() {
+		if buffer.isTabSetAtCursor() {
 			break
 		}
 	}
 }
 
+func (buffer *Buffer) getTabIndexFromCursor() uint16 {
+	index := buffer.cursorX
+	if index == buffer.terminalState.viewWidth {
+		index = 0
+	}
+	return index
+}
+
+func (buffer *Buffer) isTabSetAtCursor() bool {
+	_, ok := buffer.terminalState.tabStops[buffer.getTabIndexFromCursor()]
+	return ok
+}
+
+func (buffer *Buffer) TabClearAtCursor() {
+	buffer.terminalState.TabClear(buffer.getTabIndexFromCursor())
+}
+
+func (buffer *Buffer) TabSetAtCursor() {
+	buffer.terminalState.TabSet(buffer.getTabIndexFromCursor())
+}
+
 func (buffer *Buffer) NewLine() {
 	buffer.NewLineEx(false)
 }
@@ -748,7 +1027,7 @@ func (buffer *Buffer) NewLine() {
 func (buffer *Buffer) NewLineEx(forceCursorToMargin bool) {
 
 	if buffer.terminalState.IsNewLineMode() || forceCursorToMargin {
-		buffer.terminalState.cursorX = 0
+		buffer.cursorX = 0
 	}
 	buffer.Index()
 
@@ -802,40 +1081,65 @@ func (buffer *Buffer) SetPosition(col uint16, line uint16) {
 		useLine = maxLine
 	}
 
-	if useCol >= buffer.ViewWidth() {
-		useCol = buffer.ViewWidth() - 1
+	if width := buffer.lineWidth(buffer.getViewLine(useLine)); useCol >= width {
+		useCol = width - 1
 		//logrus.Errorf("Cannot set cursor position: column %d is outside of the current view width (%d columns)", col, buffer.ViewWidth())
 	}
 
-	buffer.terminalState.cursorX = useCol
-	buffer.terminalState.cursorY = useLine
+	buffer.cursorX = useCol
+	buffer.cursorY = useLine
 }
 
 func (buffer *Buffer) GetVisibleLines() []Line {
 	lines := []Line{}
 
 	for i := buffer.Height() - int(buffer.ViewHeight()); i < buffer.Height(); i++ {
-		y := i - int(buffer.terminalState.scrollLinesFromBottom)
-		if y >= 0 && y < len(buffer.lines) {
-			lines = append(lines, buffer.lines[y])
+		if i < 0 {
+			continue
+		}
+		if line := buffer.lineAt(i - int(buffer.terminalState.scrollLinesFromBottom)); line != nil {
+			lines = append(lines, *line)
 		}
 	}
 	return lines
 }
 
+// AllLines returns the lines in the scrollback followed by the lines in the view. Lines in the scrollback
+// store are decompressed.
+func (buffer *Buffer) AllLines() []Line {
+	lines := []Line{}
+	if buffer.store != nil {
+		for i := 0; i < buffer.store.Len(); i++ {
+			if line := buffer.store.line(i); line != nil {
+				lines = append(lines, *line)
+			}
+		}
+	}
+	return append(lines, buffer.lines...)
+}
+
+// ViewLines returns the lines in the view, whether or not the user has scrolled back through the scrollback
+func (buffer *Buffer) ViewLines() []Line {
+	start := len(buffer.lines) - int(buffer.ViewHeight())
+	if start < 0 {
+		start = 0
+	}
+	return append([]Line{}, buffer.lines[start:]...)
+}
+
 // tested to here
 
 func (buffer *Buffer) Clear() {
 	defer buffer.emitDisplayChange()
 	for i := 0; i < int(buffer.ViewHeight()); i++ {
-		buffer.lines = append(buffer.lines, newLine())
+		buffer.appendLine()
 	}
 	buffer.SetPosition(0, 0) // do we need to set position?
 }
 
 // creates if necessary
 func (buffer *Buffer) getCurrentLine() *Line {
-	return buffer.getViewLine(buffer.terminalState.cursorY)
+	return buffer.getViewLine(buffer.cursorY)
 }
 
 func (buffer *Buffer) getViewLine(index uint16) *Line {
@@ -846,7 +1150,7 @@ func (buffer *Buffer) getViewLine(index uint16) *Line {
 
 	if len(buffer.lines) < int(buffer.ViewHeight()) {
 		for int(index) >= len(buffer.lines) {
-			buffer.lines = append(buffer.lines, newLine())
+			buffer.lines = append(buffer.lines, newLine(buffer.attrs))
 		}
 		return &buffer.lines[int(index)]
 	}
@@ -861,15 +1165,16 @@ func (buffer *Buffer) getViewLine(index uint16) *Line {
 func (buffer *Buffer) EraseLine() {
 	defer buffer.emitDisplayChange()
 	line := buffer.getCurrentLine()
-	line.cells = []Cell{}
+	line.cells = []storedCell{}
+	line.continued = false
 }
 
 func (buffer *Buffer) EraseLineToCursor() {
 	defer buffer.emitDisplayChange()
 	line := buffer.getCurrentLine()
-	for i := 0; i <= int(buffer.terminalState.cursorX); i++ {
+	for i := 0; i <= int(buffer.cursorX); i++ {
 		if i < len(line.cells) {
-			line.cells[i].erase(buffer.terminalState.CursorAttr.BgColour)
+			line.erase(i, buffer.terminalState.CursorAttr.BgColour)
 		}
 	}
 }
@@ -879,15 +1184,15 @@ func (buffer *Buffer) EraseLineFromCursor() {
 	line := buffer.getCurrentLine()
 
 	if len(line.cells) > 0 {
-		cx := buffer.terminalState.cursorX
+		cx := buffer.cursorX
 		if int(cx) < len(line.cells) {
-			line.cells = line.cells[:buffer.terminalState.cursorX]
+			line.cells = line.cells[:buffer.cursorX]
 		}
 	}
 	max := int(buffer.ViewWidth()) - len(line.cells)
 
 	for i := 0; i < max; i++ {
-		line.Append(buffer.terminalState.DefaultCell(true))
+		line.appendCells(buffer.defaultCell(true))
 	}
 
 }
@@ -897,7 +1202,9 @@ func (buffer *Buffer) EraseDisplay() {
 	for i := uint16(0); i < (buffer.ViewHeight()); i++ {
 		rawLine := buffer.convertViewLineToRawLine(i)
 		if int(rawLine) < len(buffer.lines) {
-			buffer.lines[int(rawLine)].cells = []Cell{}
+			buffer.lines[int(rawLine)].cells = []storedCell{}
+			buffer.lines[int(rawLine)].size = LineSizeSingle
+			buffer.lines[int(rawLine)].continued = false
 		}
 	}
 }
@@ -906,14 +1213,14 @@ func (buffer *Buffer) DeleteChars(n int) {
 	defer buffer.emitDisplayChange()
 
 	line := buffer.getCurrentLine()
-	if int(buffer.terminalState.cursorX) >= len(line.cells) {
+	if int(buffer.cursorX) >= len(line.cells) {
 		return
 	}
-	before := line.cells[:buffer.terminalState.cursorX]
-	if int(buffer.terminalState.cursorX)+n >= len(line.cells) {
-		n = len(line.cells) - int(buffer.terminalState.cursorX)
+	before := line.cells[:buffer.cursorX]
+	if int(buffer.cursorX)+n >= len(line.cells) {
+		n = len(line.cells) - int(buffer.cursorX)
 	}
-	after := line.cells[int(buffer.terminalState.cursorX)+n:]
+	after := line.cells[int(buffer.cursorX)+n:]
 	line.cells = append(before, after...)
 }
 
@@ -922,13 +1229,13 @@ func (buffer *Buffer) EraseCharacters(n int) {
 
 	line := buffer.getCurrentLine()
 
-	max := int(buffer.terminalState.cursorX) + n
+	max := int(buffer.cursorX) + n
 	if max > len(line.cells) {
 		max = len(line.cells)
 	}
 
-	for i := int(buffer.terminalState.cursorX); i < max; i++ {
-		line.cells[i].erase(buffer.terminalState.CursorAttr.BgColour)
+	for i := int(buffer.cursorX); i < max; i++ {
+		line.erase(i, buffer.terminalState.CursorAttr.BgColour)
 	}
 }
 
@@ -936,15 +1243,16 @@ func (buffer *Buffer) EraseDisplayFromCursor() {
 	defer buffer.emitDisplayChange()
 	line := buffer.getCurrentLine()
 
-	max := int(buffer.terminalState.cursorX)
+	max := int(buffer.cursorX)
 	if max > len(line.cells) {
 		max = len(line.cells)
 	}
 
 	line.cells = line.cells[:max]
 
-	for rawLine := buffer.convertViewLineToRawLine(buffer.terminalState.cursorY) + 1; int(rawLine) < len(buffer.lines); rawLine++ {
-		buffer.lines[int(rawLine)].cells = []Cell{}
+	for rawLine := buffer.convertViewLineToRawLine(buffer.cursorY) + 1; int(rawLine) < len(buffer.lines); rawLine++ {
+		buffer.lines[int(rawLine)].cells = []storedCell{}
+		buffer.lines[int(rawLine)].continued = false
 	}
 }
 
@@ -952,16 +1260,78 @@ func (buffer *Buffer) EraseDisplayToCursor() {
 	defer buffer.emitDisplayChange()
 	line := buffer.getCurrentLine()
 
-	for i := 0; i <= int(buffer.terminalState.cursorX); i++ {
+	for i := 0; i <= int(buffer.cursorX); i++ {
 		if i >= len(line.cells) {
 			break
 		}
-		line.cells[i].erase(buffer.terminalState.CursorAttr.BgColour)
+		line.erase(i, buffer.terminalState.CursorAttr.BgColour)
 	}
-	for i := uint16(0); i < buffer.terminalState.cursorY; i++ {
+	for i := uint16(0); i < buffer.cursorY; i++ {
 		rawLine := buffer.convertViewLineToRawLine(i)
 		if int(rawLine) < len(buffer.lines) {
-			buffer.lines[int(rawLine)].cells = []Cell{}
+			buffer.lines[int(rawLine)].cells = []storedCell{}
+			buffer.lines[int(rawLine)].size = LineSizeSingle
+			buffer.lines[int(rawLine)].continued = false
+		}
+	}
+}
+
+// SelectiveEraseLine erases all unprotected characters on the current line (DECSEL 2)
+func (buffer *Buffer) SelectiveEraseLine() {
+	defer buffer.emitDisplayChange()
+	line := buffer.getCurrentLine()
+	line.selectiveErase(0, len(line.cells))
+}
+
+// SelectiveEraseLineToCursor erases unprotected characters from the start of the line to the cursor, inclusive (DECSEL 1)
+func (buffer *Buffer) SelectiveEraseLineToCursor() {
+	defer buffer.emitDisplayChange()
+	line := buffer.getCurrentLine()
+	line.selectiveErase(0, int(buffer.cursorX)+1)
+}
+
+// SelectiveEraseLineFromCursor erases unprotected characters from the cursor to the end of the line (DECSEL 0)
+func (buffer *Buffer) SelectiveEraseLineFromCursor() {
+	defer buffer.emitDisplayChange()
+	line := buffer.getCurrentLine()
+	line.selectiveErase(int(buffer.cursorX), len(line.cells))
+}
+
+// SelectiveEraseDisplay erases all unprotected characters in the view (DECSED 2)
+func (buffer *Buffer) SelectiveEraseDisplay() {
+	defer buffer.emitDisplayChange()
+	for i := uint16(0); i < buffer.ViewHeight(); i++ {
+		rawLine := buffer.convertViewLineToRawLine(i)
+		if int(rawLine) < len(buffer.lines) {
+			line := &buffer.lines[int(rawLine)]
+			line.selectiveErase(0, len(line.cells))
+		}
+	}
+}
+
+// SelectiveEraseDisplayFromCursor erases unprotected characters from the cursor to the end of the view (DECSED 0)
+func (buffer *Buffer) SelectiveEraseDisplayFromCursor() {
+	defer buffer.emitDisplayChange()
+	line := buffer.getCurrentLine()
+	line.selectiveErase(int(buffer.cursorX), len(line.cells))
+
+	for rawLine := buffer.convertViewLineToRawLine(buffer.cursorY) + 1; int(rawLine) < len(buffer.lines); rawLine++ {
+		line := &buffer.lines[int(rawLine)]
+		line.selectiveErase(0, len(line.cells))
+	}
+}
+
+// SelectiveEraseDisplayToCursor erases unprotected characters from the start of the view to the cursor, inclusive (DECSED 1)
+func (buffer *Buffer) SelectiveEraseDisplayToCursor() {
+	defer buffer.emitDisplayChange()
+	line := buffer.getCurrentLine()
+	line.selectiveErase(0, int(buffer.cursorX)+1)
+
+	for i := uint16(0); i < buffer.cursorY; i++ {
+		rawLine := buffer.convertViewLineToRawLine(i)
+		if int(rawLine) < len(buffer.lines) {
+			line := &buffer.lines[int(rawLine)]
+			line.selectiveErase(0, len(line.cells))
 		}
 	}
 }
@@ -978,7 +1348,7 @@ func (buffer *Buffer) ResizeView(width uint16, height uint16) {
 
 	// @todo scroll to bottom on resize
 	line := buffer.getCurrentLine()
-	cXFromEndOfLine := len(line.cells) - int(buffer.terminalState.cursorX+1)
+	cXFromEndOfLine := len(line.cells) - int(buffer.cursorX+1)
 
 	cursorYMovement := 0
 
@@ -1002,11 +1372,11 @@ func (buffer *Buffer) ResizeView(width uint16, height uint16) {
 					}
 				}
 
-				if i+1 <= int(buffer.terminalState.cursorY) {
+				if i+1 <= int(buffer.cursorY) {
 					cursorYMovement++
 				}
 
-				newLine := newLine()
+				newLine := newLine(buffer.attrs)
 				newLine.setWrapped(true)
 				newLine.cells = sillyCells
 				after := append([]Line{newLine}, buffer.lines[i+1:]...)
@@ -1032,10 +1402,10 @@ func (buffer *Buffer) ResizeView(width uint16, height uint16) {
 				if moveCount > len(nextLine.cells) {
 					moveCount = len(nextLine.cells)
 				}
-				line.Append(nextLine.cells[:moveCount]...)
+				line.appendCells(nextLine.cells[:moveCount]...)
 				if moveCount == len(nextLine.cells) {
 
-					if i+offset <= int(buffer.terminalState.cursorY) {
+					if i+offset <= int(buffer.cursorY) {
 						cursorYMovement--
 					}
 
@@ -1060,17 +1430,27 @@ func (buffer *Buffer) ResizeView(width uint16, height uint16) {
 	if cY >= buffer.terminalState.viewHeight {
 		cY = buffer.terminalState.viewHeight - 1
 	}
-	buffer.terminalState.cursorY = cY
+	buffer.cursorY = cY
 
 	// position cursorX
 	line = buffer.getCurrentLine()
-	buffer.terminalState.cursorX = uint16((len(line.cells) - cXFromEndOfLine) - 1)
+	buffer.cursorX = uint16((len(line.cells) - cXFromEndOfLine) - 1)
 
 	buffer.terminalState.ResetVerticalMargins()
 }
 
 func (buffer *Buffer) getMaxLines() uint64 {
-	result := buffer.terminalState.maxLines
+	result := buffer.maxLines
+	if buffer.store != nil && buffer.memoryLines < result {
+		result = buffer.memoryLines
+	}
+	if buffer.maxBytes > 0 && buffer.terminalState.viewWidth > 0 {
+		// a line holds at most one cell per column
+		lineBytes := uint64(buffer.terminalState.viewWidth) * uint64(unsafe.Sizeof(storedCell{}))
+		if byteLimit := buffer.maxBytes / lineBytes; byteLimit < result {
+			result = byteLimit
+		}
+	}
 	if result < uint64(buffer.terminalState.viewHeight) {
 		result = uint64(buffer.terminalState.viewHeight)
 	}
@@ -1078,6 +1458,7 @@ func (buffer *Buffer) getMaxLines() uint64 {
 	return result
 }
 
+// SaveViewLines writes the text of each line in the view to a file, one line per row
 func (buffer *Buffer) SaveViewLines(path string) {
 	f, err := os.Create(path)
 	if err != nil {
@@ -1085,11 +1466,12 @@ func (buffer *Buffer) SaveViewLines(path string) {
 	}
 	defer f.Close()
 
-	for i := uint16(0); i <= buffer.ViewHeight(); i++ {
-		f.WriteString(buffer.getViewLine(i).String())
+	for i := uint16(0); i < buffer.ViewHeight(); i++ {
+		f.WriteString(buffer.getViewLine(i).String() + "\n")
 	}
 }
 
+// CompareViewLines returns true if the view matches a file written by SaveViewLines
 func (buffer *Buffer) CompareViewLines(path string) bool {
 	f, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -1097,17 +1479,58 @@ func (buffer *Buffer) CompareViewLines(path string) bool {
 	}
 
 	bufferContent := []byte{}
-	for i := uint16(0); i <= buffer.ViewHeight(); i++ {
-		lineBytes := []byte(buffer.getViewLine(i).String())
+	for i := uint16(0); i < buffer.ViewHeight(); i++ {
+		lineBytes := []byte(buffer.getViewLine(i).String() + "\n")
 		bufferContent = append(bufferContent, lineBytes...)
 	}
 	return bytes.Equal(f, bufferContent)
 }
 
-func (buffer *Buffer) ReverseVideo() {
-	defer buffer.emitDisplayChange()
+// defaultCell returns a blank cell with the attributes given by TerminalState.DefaultCell
+func (buffer *Buffer) defaultCell(applyEffects bool) storedCell {
+	return storedCell{attr: buffer.attrs.intern(attributeEntry{attr: buffer.terminalState.defaultAttributes(applyEffects)})}
+}
+
+// compactAttributes removes the attributes no longer used by any cell from the attribute table, once it is full
+func (buffer *Buffer) compactAttributes() {
+	if !buffer.attrs.full() {
+		return
+	}
+
+	// the store is locked throughout, so that the renderer can't decompress lines with indexes into the table
+	// between their being marked and remapped
+	if buffer.store != nil {
+		buffer.store.mutex.Lock()
+		defer buffer.store.mutex.Unlock()
+	}
+
+	used := make([]bool, len(buffer.attrs.entries))
+	mark := func(line *Line) {
+		for _, cell := range line.cells {
+			if int(cell.attr) < len(used) {
+				used[cell.attr] = true
+			}
+		}
+	}
+	for i := range buffer.lines {
+		mark(&buffer.lines[i])
+	}
+	if buffer.store != nil {
+		buffer.store.forEachLoadedLine(mark)
+	}
 
-	for _, line := range buffer.lines {
-		line.ReverseVideo()
+	remap := buffer.attrs.compact(used)
+	update := func(line *Line) {
+		for i, cell := range line.cells {
+			if int(cell.attr) < len(remap) {
+				line.cells[i].attr = remap[cell.attr]
+			}
+		}
+	}
+	for i := range buffer.lines {
+		update(&buffer.lines[i])
+	}
+	if buffer.store != nil {
+		buffer.store.forEachLoadedLine(update)
 	}
 }
@@ -1,8 +1,12 @@
 package buffer
 
 import (
+	"fmt"
+	"image"
+	"runtime"
 	"strings"
 	"testing"
+	"unsafe"
 
 	"github.com/stretchr/testify/require"
 
@@ -38,6 +42,24 @@ hell    xxx good
 	require.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(strings.Join(strs, "\n")))
 }
 
+func TestTabStops(t *testing.T) {
+	ts := NewTerminalState(20, 3, CellAttributes{}, 1000)
+	assert.Equal(t, []uint16{4, 8, 12, 16}, ts.TabStops())
+
+	ts.TabSetEvery(8)
+	assert.Equal(t, []uint16{8, 16}, ts.TabStops())
+
+	ts.TabClear(8)
+	ts.TabSet(11)
+	assert.Equal(t, []uint16{11, 16}, ts.TabStops())
+
+	ts.SetTabWidth(5)
+	assert.Equal(t, []uint16{5, 10, 15}, ts.TabStops())
+
+	ts.TabZonk()
+	assert.Equal(t, []uint16{}, ts.TabStops())
+}
+
 func TestOffsets(t *testing.T) {
 	b := NewBuffer(NewTerminalState(10, 3, CellAttributes{}, 1000))
 	b.Write([]rune("hello")...)
@@ -121,19 +143,19 @@ func TestWritingNewLineAsFirstRuneOnWrappedLine(t *testing.T) {
 	b.terminalState.LineFeedMode = false
 
 	b.Write('a', 'b', 'c')
-	assert.Equal(t, uint16(3), b.terminalState.cursorX)
-	assert.Equal(t, uint16(0), b.terminalState.cursorY)
+	assert.Equal(t, uint16(3), b.cursorX)
+	assert.Equal(t, uint16(0), b.cursorY)
 	b.NewLine()
-	assert.Equal(t, uint16(0), b.terminalState.cursorX)
-	assert.Equal(t, uint16(1), b.terminalState.cursorY)
+	assert.Equal(t, uint16(0), b.cursorX)
+	assert.Equal(t, uint16(1), b.cursorY)
 
 	b.Write('d', 'e', 'f')
-	assert.Equal(t, uint16(3), b.terminalState.cursorX)
-	assert.Equal(t, uint16(1), b.terminalState.cursorY)
+	assert.Equal(t, uint16(3), b.cursorX)
+	assert.Equal(t, uint16(1), b.cursorY)
 	b.NewLine()
 
-	assert.Equal(t, uint16(0), b.terminalState.cursorX)
-	assert.Equal(t, uint16(2), b.terminalState.cursorY)
+	assert.Equal(t, uint16(0), b.cursorX)
+	assert.Equal(t, uint16(2), b.cursorY)
 
 	require.Equal(t, 3, len(b.lines))
 	assert.Equal(t, "abc", b.lines[0].String())
@@ -305,6 +327,23 @@ func TestClearWithFullView(t *testing.T) {
 	}
 }
 
+func TestClearPassesTheScreenToTheScrollbackHandler(t *testing.T) {
+	b := NewBuffer(NewTerminalState(80, 3, CellAttributes{}, 1000))
+	var scrolled []string
+	b.SetScrollbackHandler(func(line *Line) {
+		scrolled = append(scrolled, line.String())
+	})
+	b.Write([]rune("one")...)
+	b.CarriageReturn()
+	b.NewLine()
+	b.Write([]rune("two")...)
+	b.CarriageReturn()
+	b.NewLine()
+	b.Write([]rune("three")...)
+	b.Clear()
+	assert.Equal(t, []string{"one", "two", "three"}, scrolled)
+}
+
 func TestCarriageReturn(t *testing.T) {
 	b := NewBuffer(NewTerminalState(80, 20, CellAttributes{}, 1000))
 	b.Write([]rune("hello!")...)
@@ -346,10 +385,10 @@ func TestCarriageReturnOnWrappedLine(t *testing.T) {
 
 func TestCarriageReturnOnLineThatDoesntExist(t *testing.T) {
 	b := NewBuffer(NewTerminalState(6, 10, CellAttributes{}, 1000))
-	b.terminalState.cursorY = 3
+	b.cursorY = 3
 	b.CarriageReturn()
-	assert.Equal(t, uint16(0), b.terminalState.cursorX)
-	assert.Equal(t, uint16(3), b.terminalState.cursorY)
+	assert.Equal(t, uint16(0), b.cursorX)
+	assert.Equal(t, uint16(3), b.cursorY)
 }
 
 func TestGetCell(t *testing.T) {
@@ -401,10 +440,10 @@ func TestCursorAttr(t *testing.T) {
 
 func TestCursorPositionQuerying(t *testing.T) {
 	b := NewBuffer(NewTerminalState(80, 20, CellAttributes{}, 1000))
-	b.terminalState.cursorX = 17
-	b.terminalState.cursorY = 9
-	assert.Equal(t, b.terminalState.cursorX, b.CursorColumn())
-	assert.Equal(t, b.terminalState.cursorY, b.CursorLine())
+	b.cursorX = 17
+	b.cursorY = 9
+	assert.Equal(t, b.cursorX, b.CursorColumn())
+	assert.Equal(t, b.cursorY, b.CursorLine())
 }
 
 func TestRawPositionQuerying(t *testing.T) {
@@ -438,8 +477,8 @@ func TestRawPositionQuerying(t *testing.T) {
 	b.NewLine()
 	b.Write([]rune("a")...)
 
-	b.terminalState.cursorX = 3
-	b.terminalState.cursorY = 4
+	b.cursorX = 3
+	b.cursorY = 4
 	assert.Equal(t, uint64(9), b.RawLine())
 }
 
@@ -481,6 +520,103 @@ func TestEraseLineAfterCursor(t *testing.T) {
 	assert.Equal(t, "hello, this is a test", b.lines[0].String())
 	assert.Equal(t, "dele", b.lines[1].String())
 }
+
+// CSI @
+func TestInsertBlankCharacters(t *testing.T) {
+	b := NewBuffer(NewTerminalState(10, 3, CellAttributes{}, 1000))
+	b.Write([]rune("abcdefghij")...)
+	b.SetPosition(2, 0)
+	b.InsertBlankCharacters(3)
+	assert.Equal(t, "ab\x00\x00\x00cdefg", b.lines[0].String())
+
+	// characters pushed past the right margin are discarded, however many blanks are inserted
+	b.InsertBlankCharacters(1000)
+	assert.Equal(t, "ab", b.lines[0].String())
+	assert.Len(t, b.lines[0].cells, 10)
+
+	// inserting beyond the end of the line content must not panic
+	b.SetPosition(5, 1)
+	b.InsertBlankCharacters(2)
+	assert.Equal(t, "", b.lines[1].String())
+}
+
+func visibleStrings(b *Buffer) []string {
+	var lines []string
+	for _, line := range b.GetVisibleLines() {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// CSI L
+func TestInsertLines(t *testing.T) {
+	b := NewBuffer(NewTerminalState(10, 4, CellAttributes{}, 1000))
+	b.terminalState.LineFeedMode = false
+	writeNumberedLines(b, 0, 6)
+	b.SetPosition(3, 1)
+
+	b.InsertLines(1)
+	assert.Equal(t, []string{"line 3", "", "line 4", "line 5"}, visibleStrings(b))
+	assert.Equal(t, "line 2", b.lines[2].String(), "the scrollback must be left alone")
+	assert.Equal(t, uint16(0), b.CursorColumn())
+
+	b.InsertLines(999999999)
+	assert.Equal(t, []string{"line 3", "", "", ""}, visibleStrings(b))
+
+	// lines are pushed down into lines which don't exist yet
+	b = NewBuffer(NewTerminalState(10, 6, CellAttributes{}, 1000))
+	b.terminalState.LineFeedMode = false
+	writeNumberedLines(b, 0, 2)
+	b.SetPosition(0, 0)
+	b.InsertLines(2)
+	assert.Equal(t, []string{"", "", "line 0", "line 1", ""}, visibleStrings(b))
+}
+
+// CSI M
+func TestDeleteLines(t *testing.T) {
+	b := NewBuffer(NewTerminalState(10, 4, CellAttributes{}, 1000))
+	b.terminalState.LineFeedMode = false
+	writeNumberedLines(b, 0, 6)
+	b.SetPosition(0, 1)
+
+	b.DeleteLines(1)
+	assert.Equal(t, []string{"line 3", "line 5", "", ""}, visibleStrings(b))
+	assert.Equal(t, "line 2", b.lines[2].String(), "the scrollback must be left alone")
+
+	b.DeleteLines(999999999)
+	assert.Equal(t, []string{"line 3", "", "", ""}, visibleStrings(b))
+
+	// there are no lines below the cursor on a fresh screen
+	b = NewBuffer(NewTerminalState(10, 4, CellAttributes{}, 1000))
+	b.SetPosition(0, 3)
+	b.DeleteLines(9)
+	assert.Equal(t, 4, len(visibleStrings(b)))
+}
+
+func TestInsertAndDeleteLinesInScrollingRegion(t *testing.T) {
+	b := NewBuffer(NewTerminalState(10, 5, CellAttributes{}, 1000))
+	b.terminalState.LineFeedMode = false
+	for i := 0; i < 5; i++ {
+		b.SetPosition(0, uint16(i))
+		b.Write([]rune(fmt.Sprintf("line %d", i))...)
+	}
+	b.terminalState.SetVerticalMargins(1, 3)
+
+	b.SetPosition(0, 2)
+	b.InsertLines(1)
+	assert.Equal(t, []string{"line 0", "line 1", "", "line 2", "line 4"}, visibleStrings(b))
+
+	b.SetPosition(0, 1)
+	b.DeleteLines(2)
+	assert.Equal(t, []string{"line 0", "line 2", "", "", "line 4"}, visibleStrings(b))
+
+	// outside of the region, nothing happens
+	b.SetPosition(0, 4)
+	b.DeleteLines(1)
+	b.InsertLines(1)
+	assert.Equal(t, []string{"line 0", "line 2", "", "", "line 4"}, visibleStrings(b))
+}
+
 func TestEraseDisplay(t *testing.T) {
 	b := NewBuffer(NewTerminalState(80, 5, CellAttributes{}, 1000))
 	b.Write([]rune("hello")...)
@@ -531,6 +667,104 @@ func TestEraseDisplayFromCursor(t *testing.T) {
 	assert.Equal(t, "asd", lines[1].String())
 	assert.Equal(t, "", lines[2].String())
 }
+func writeProtected(b *Buffer, text string) {
+	b.CursorAttr().Protected = true
+	b.Write([]rune(text)...)
+	b.CursorAttr().Protected = false
+}
+
+// CSI ? 2 K
+func TestSelectiveEraseLine(t *testing.T) {
+	b := NewBuffer(NewTerminalState(80, 5, CellAttributes{}, 1000))
+	b.Write([]rune("name: ")...)
+	writeProtected(b, "[____]")
+	b.Write([]rune(" ok")...)
+	b.SelectiveEraseLine()
+	assert.Equal(t, "\x00\x00\x00\x00\x00\x00[____]", b.lines[0].String())
+}
+
+// CSI ? 1 K
+func TestSelectiveEraseLineToCursor(t *testing.T) {
+	b := NewBuffer(NewTerminalState(80, 5, CellAttributes{}, 1000))
+	writeProtected(b, "ab")
+	b.Write([]rune("cdef")...)
+	b.MovePosition(-2, 0)
+	b.SelectiveEraseLineToCursor()
+	assert.Equal(t, "ab\x00\x00\x00f", b.lines[0].String())
+}
+
+// CSI ? 0 K
+func TestSelectiveEraseLineFromCursor(t *testing.T) {
+	b := NewBuffer(NewTerminalState(80, 5, CellAttributes{}, 1000))
+	b.Write([]rune("abc")...)
+	writeProtected(b, "de")
+	b.Write([]rune("f")...)
+	b.MovePosition(-5, 0)
+	b.SelectiveEraseLineFromCursor()
+	assert.Equal(t, "a\x00\x00de", b.lines[0].String())
+}
+
+// CSI ? 2 J
+func TestSelectiveEraseDisplay(t *testing.T) {
+	b := NewBuffer(NewTerminalState(80, 5, CellAttributes{}, 1000))
+	writeProtected(b, "Label:")
+	b.Write([]rune(" value")...)
+	b.CarriageReturn()
+	b.NewLine()
+	b.Write([]rune("erased")...)
+	b.SelectiveEraseDisplay()
+	lines := b.GetVisibleLines()
+	assert.Equal(t, "Label:", lines[0].String())
+	assert.Equal(t, "", lines[1].String())
+}
+
+// CSI ? 1 J
+func TestSelectiveEraseDisplayToCursor(t *testing.T) {
+	b := NewBuffer(NewTerminalState(80, 5, CellAttributes{}, 1000))
+	b.Write([]rune("hello")...)
+	b.CarriageReturn()
+	b.NewLine()
+	writeProtected(b, "as")
+	b.Write([]rune("dasd")...)
+	b.CarriageReturn()
+	b.NewLine()
+	b.Write([]rune("thing")...)
+	b.MovePosition(-2, 0)
+	b.SelectiveEraseDisplayToCursor()
+	lines := b.GetVisibleLines()
+	assert.Equal(t, "", lines[0].String())
+	assert.Equal(t, "as", lines[1].String())
+	assert.Equal(t, "\x00\x00\x00\x00g", lines[2].String())
+}
+
+// CSI ? 0 J
+func TestSelectiveEraseDisplayFromCursor(t *testing.T) {
+	b := NewBuffer(NewTerminalState(80, 5, CellAttributes{}, 1000))
+	b.Write([]rune("hello")...)
+	b.CarriageReturn()
+	b.NewLine()
+	b.Write([]rune("asdasd")...)
+	b.CarriageReturn()
+	b.NewLine()
+	writeProtected(b, "th")
+	b.Write([]rune("ings")...)
+	b.MovePosition(-3, -1)
+	b.SelectiveEraseDisplayFromCursor()
+	lines := b.GetVisibleLines()
+	assert.Equal(t, "hello", lines[0].String())
+	assert.Equal(t, "asd", lines[1].String())
+	assert.Equal(t, "th", lines[2].String())
+}
+
+func TestEraseClearsProtection(t *testing.T) {
+	b := NewBuffer(NewTerminalState(80, 5, CellAttributes{}, 1000))
+	writeProtected(b, "abc")
+	b.EraseLineToCursor()
+	b.SelectiveEraseLine()
+	assert.False(t, b.lines[0].Cells()[0].Attr().Protected)
+	assert.Equal(t, "", b.lines[0].String())
+}
+
 func TestBackspace(t *testing.T) {
 	b := NewBuffer(NewTerminalState(80, 5, CellAttributes{}, 1000))
 	b.Write([]rune("hello")...)
@@ -552,8 +786,8 @@ func TestHorizontalResizeView(t *testing.T) {
 
 	b.Write([]rune(`goodbyegoodbye`)...)
 
-	require.Equal(t, uint16(14), b.terminalState.cursorX)
-	require.Equal(t, uint16(1), b.terminalState.cursorY)
+	require.Equal(t, uint16(14), b.cursorX)
+	require.Equal(t, uint16(1), b.cursorY)
 
 	b.ResizeView(40, 10)
 
@@ -561,8 +795,8 @@ func TestHorizontalResizeView(t *testing.T) {
 hellohellohellohello
 goodbyegoodbye`
 
-	require.Equal(t, uint16(14), b.terminalState.cursorX)
-	require.Equal(t, uint16(2), b.terminalState.cursorY)
+	require.Equal(t, uint16(14), b.cursorX)
+	require.Equal(t, uint16(2), b.cursorY)
 
 	lines := b.GetVisibleLines()
 	strs := []string{}
@@ -615,8 +849,8 @@ goodbyegoodbye`
 	}
 	require.Equal(t, expected, strings.Join(strs, "\n"))
 
-	require.Equal(t, uint16(1), b.terminalState.cursorY)
-	require.Equal(t, uint16(14), b.terminalState.cursorX)
+	require.Equal(t, uint16(1), b.cursorY)
+	require.Equal(t, uint16(14), b.cursorX)
 }
 
 /*
@@ -662,6 +896,23 @@ func TestSelectingChars(t *testing.T) {
 	assert.Equal(t, "e quick brown\nfox j", b.GetSelectedText())
 }
 
+func TestGetSelectedLines(t *testing.T) {
+	b := makeBufferForTestingSelection()
+	b.StartSelection(2, 0, SelectionChar)
+	b.ExtendSelection(4, 1, true)
+
+	lines := b.GetSelectedLines()
+	require.Len(t, lines, 2)
+	assert.Equal(t, "e quick brown", lines[0].String())
+	assert.Equal(t, "fox j", lines[1].String())
+	assert.False(t, lines[1].IsContinuation())
+
+	// the copies are not changed by later writes
+	b.SetPosition(0, 1)
+	b.Write('F')
+	assert.Equal(t, "fox j", lines[1].String())
+}
+
 func TestSelectingWordsDown(t *testing.T) {
 	b := makeBufferForTestingSelection()
 
@@ -711,3 +962,263 @@ func TestSelectingAfterText(t *testing.T) {
 	assert.Equal(t, end.Col, 79)
 	assert.Equal(t, end.Line, 3)
 }
+
+func TestDoubleWidthLines(t *testing.T) {
+	b := NewBuffer(NewTerminalState(10, 3, CellAttributes{}, 1000))
+	b.Write([]rune("abcdefgh")...)
+	b.SetLineSize(LineSizeDoubleWidth)
+
+	// characters beyond the half way point are lost, and the cursor stays on the line
+	assert.Equal(t, "abcde", b.lines[0].String())
+	assert.Equal(t, uint16(4), b.CursorColumn())
+	assert.Equal(t, uint16(5), b.CursorLineWidth())
+
+	b.CarriageReturn()
+	b.Write([]rune("123456")...)
+	assert.Equal(t, "12345", b.lines[0].String())
+	assert.Equal(t, "6", b.lines[1].String())
+	assert.Equal(t, LineSizeSingle, b.lines[1].Size())
+
+	b.SetPosition(9, 0)
+	assert.Equal(t, uint16(4), b.CursorColumn())
+	b.SetPosition(9, 1)
+	assert.Equal(t, uint16(9), b.CursorColumn())
+
+	b.EraseDisplay()
+	assert.Equal(t, LineSizeSingle, b.lines[0].Size())
+}
+
+func TestClearScrollback(t *testing.T) {
+	b := NewBuffer(NewTerminalState(10, 3, CellAttributes{}, 1000))
+	for i := 0; i < 10; i++ {
+		b.Write([]rune(fmt.Sprintf("line %d", i))...)
+		b.CarriageReturn()
+		b.NewLine()
+	}
+	require.Equal(t, 11, b.Height())
+
+	b.ClearScrollback()
+	assert.Equal(t, 3, b.Height())
+	lines := b.GetVisibleLines()
+	assert.Equal(t, "line 8", lines[0].String())
+	assert.Equal(t, "line 9", lines[1].String())
+	assert.Equal(t, "", lines[2].String())
+}
+
+func TestCursorLineWidthDoesNotAddLines(t *testing.T) {
+	b := NewBuffer(NewTerminalState(10, 3, CellAttributes{}, 1000))
+	b.cursorY = 2
+	assert.Equal(t, uint16(10), b.CursorLineWidth())
+	assert.Equal(t, 0, len(b.lines))
+
+	b.Write('a')
+	b.SetLineSize(LineSizeDoubleWidth)
+	assert.Equal(t, uint16(5), b.CursorLineWidth())
+	assert.Equal(t, 3, len(b.lines))
+}
+
+func TestMaxBytesLimitsLines(t *testing.T) {
+	b := NewBuffer(NewTerminalState(10, 3, CellAttributes{}, 1000))
+	b.SetMaxBytes(5 * 10 * uint64(unsafe.Sizeof(storedCell{})))
+	for i := 0; i < 20; i++ {
+		b.Write([]rune("0123456789")...)
+	}
+	assert.Equal(t, 5, b.Height())
+
+	// the view itself is always kept
+	b.SetMaxBytes(1)
+	b.Write([]rune("0123456789")...)
+	assert.Equal(t, 3, b.Height())
+}
+
+func TestGetMaxLines(t *testing.T) {
+	lineBytes := 10 * uint64(unsafe.Sizeof(storedCell{}))
+	b := NewBuffer(NewTerminalState(10, 3, CellAttributes{}, 1000))
+	assert.Equal(t, uint64(1000), b.getMaxLines())
+
+	b.SetMaxBytes(50 * lineBytes)
+	assert.Equal(t, uint64(50), b.getMaxLines())
+	b.SetMaxBytes(5000 * lineBytes)
+	assert.Equal(t, uint64(1000), b.getMaxLines())
+
+	// zero lines, as with the default alt_max_lines, keeps just the view
+	b.SetMaxLines(0)
+	assert.Equal(t, uint64(3), b.getMaxLines())
+	b.SetMaxLines(2)
+	assert.Equal(t, uint64(3), b.getMaxLines())
+
+	b.SetMaxLines(1000)
+	b.SetMaxBytes(lineBytes - 1)
+	assert.Equal(t, uint64(3), b.getMaxLines())
+	b.SetMaxBytes(0)
+	assert.Equal(t, uint64(1000), b.getMaxLines())
+
+	b.EnableScrollbackStore(20, false)
+	defer b.Close()
+	b.SetMaxBytes(10 * lineBytes)
+	assert.Equal(t, uint64(10), b.getMaxLines())
+	b.SetMaxBytes(30 * lineBytes)
+	assert.Equal(t, uint64(20), b.getMaxLines())
+}
+
+func TestMaxBytesNeverDropsBelowTheViewHeight(t *testing.T) {
+	b := NewBuffer(NewTerminalState(10, 6, CellAttributes{}, 1000))
+	b.SetMaxBytes(2 * 10 * uint64(unsafe.Sizeof(storedCell{})))
+	for i := 0; i < 20; i++ {
+		b.Write([]rune(fmt.Sprintf("line %d", i))...)
+		b.CarriageReturn()
+		b.NewLine()
+	}
+	require.Equal(t, 6, b.Height())
+	lines := b.GetVisibleLines()
+	require.Len(t, lines, 6)
+	assert.Equal(t, "line 15", lines[0].String())
+	assert.Equal(t, "line 19", lines[4].String())
+}
+
+// lsEntries imitates a line of ls --color output: names coloured by file type, separated by plain spaces
+var lsEntries = []struct {
+	name   string
+	colour uint8 // palette index, 0 for the default colour
+	bold   bool
+}{
+	{"build", 4, true},
+	{"cmd", 4, true},
+	{"go.mod", 0, false},
+	{"install.sh", 2, true},
+	{"README.md", 0, false},
+	{"release.tar.gz", 1, true},
+	{"vendor", 4, true},
+}
+
+const catLine = "The quick brown fox jumps over the lazy dog, and then it does it all over again."
+
+func writeLsLine(buf *Buffer) {
+	for _, entry := range lsEntries {
+		attr := buf.CursorAttr()
+		if entry.colour != 0 {
+			attr.FgColour = PaletteColour(entry.colour)
+		}
+		attr.Bold = entry.bold
+		buf.Write([]rune(entry.name)...)
+		*attr = CellAttributes{}
+		buf.Write([]rune("  ")...)
+	}
+}
+
+func writeCatLine(buf *Buffer) {
+	buf.Write([]rune(catLine)...)
+}
+
+func fillBuffer(buf *Buffer, lines int, writeLine func(buf *Buffer)) {
+	for i := 0; i < lines; i++ {
+		writeLine(buf)
+		buf.CarriageReturn()
+		buf.NewLine()
+	}
+}
+
+func benchmarkThroughput(b *testing.B, writeLine func(buf *Buffer)) {
+	measure := NewBuffer(NewTerminalState(100, 24, CellAttributes{}, 1))
+	writeLine(measure)
+	b.SetBytes(int64(len(measure.getCurrentLine().String()) * 1000))
+	b.ReportAllocs()
+
+	for n := 0; n < b.N; n++ {
+		buf := NewBuffer(NewTerminalState(100, 24, CellAttributes{}, 1000))
+		fillBuffer(buf, 1000, writeLine)
+	}
+}
+
+// benchmarkMemory reports the memory used by each line kept in the scrollback
+func benchmarkMemory(b *testing.B, writeLine func(buf *Buffer)) {
+	const lines = 10000
+	var before, after runtime.MemStats
+
+	for n := 0; n < b.N; n++ {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		buf := NewBuffer(NewTerminalState(100, 24, CellAttributes{}, lines))
+		fillBuffer(buf, lines, writeLine)
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		runtime.KeepAlive(buf)
+	}
+	b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/lines, "B/line")
+}
+
+func BenchmarkWriteLsOutput(b *testing.B) {
+	benchmarkThroughput(b, writeLsLine)
+}
+
+func BenchmarkWriteCatOutput(b *testing.B) {
+	benchmarkThroughput(b, writeCatLine)
+}
+
+func BenchmarkMemoryLsOutput(b *testing.B) {
+	benchmarkMemory(b, writeLsLine)
+}
+
+func BenchmarkMemoryCatOutput(b *testing.B) {
+	benchmarkMemory(b, writeCatLine)
+}
+
+// legacyCell is how cells were stored before attributes were interned, kept as a reference for the memory benchmarks
+type legacyCell struct {
+	r    rune
+	attr struct {
+		FgColour  [3]float32
+		BgColour  [3]float32
+		Bold      bool
+		Dim       bool
+		Italic    bool
+		Underline bool
+		Blink     bool
+		Inverse   bool
+		Hidden    bool
+		Protected bool
+	}
+	image *image.RGBA
+}
+
+// legacyLine is how lines were stored before attributes were interned
+type legacyLine struct {
+	wrapped bool
+	cells   []legacyCell
+}
+
+func BenchmarkMemoryLegacyLsOutput(b *testing.B) {
+	benchmarkLegacyMemory(b, writeLsLine)
+}
+
+func BenchmarkMemoryLegacyCatOutput(b *testing.B) {
+	benchmarkLegacyMemory(b, writeCatLine)
+}
+
+// benchmarkLegacyMemory reports the memory each line of the scrollback would use with the old cell layout, for
+// comparison with benchmarkMemory
+func benchmarkLegacyMemory(b *testing.B, writeLine func(buf *Buffer)) {
+	const lines = 10000
+	var before, after runtime.MemStats
+
+	measure := NewBuffer(NewTerminalState(100, 24, CellAttributes{}, 1))
+	writeLine(measure)
+	width := len(measure.getCurrentLine().Cells())
+
+	for n := 0; n < b.N; n++ {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		buf := make([]legacyLine, 0, lines)
+		for i := 0; i < lines; i++ {
+			line := legacyLine{}
+			for j := 0; j < width; j++ {
+				line.cells = append(line.cells, legacyCell{r: 'x'})
+			}
+			buf = append(buf, line)
+		}
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		runtime.KeepAlive(buf)
+	}
+	b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/lines, "B/line")
+}
@@ -4,33 +4,39 @@ import (
 	"image"
 )
 
+// Cell is a character on the screen, along with its attributes and colours. Lines don't store cells in this form;
+// they are created as they are read.
 type Cell struct {
 	r     rune
 	attr  CellAttributes
+	fg    [3]float32 // the colours of the attributes, resolved when the cell was read
+	bg    [3]float32
 	image *image.RGBA
 }
 
+// storedCell is how a line stores a cell: the rune, and the index of its attributes in the attribute table
+type storedCell struct {
+	r    rune
+	attr uint32
+}
+
 type CellAttributes struct {
-	FgColour  [3]float32
-	BgColour  [3]float32
+	FgColour  Colour
+	BgColour  Colour
 	Bold      bool
 	Dim       bool
+	Italic    bool
 	Underline bool
 	Blink     bool
 	Inverse   bool
 	Hidden    bool
+	Protected bool // DECSCA - cell cannot be erased by DECSED/DECSEL
 }
 
 func (cell *Cell) Image() *image.RGBA {
 	return cell.image
 }
 
-func (cell *Cell) SetImage(img *image.RGBA) {
-
-	cell.image = img
-
-}
-
 func (cell *Cell) Attr() CellAttributes {
 	return cell.attr
 }
@@ -41,37 +47,33 @@ func (cell *Cell) Rune() rune {
 
 func (cell *Cell) Fg() [3]float32 {
 	if cell.Attr().Inverse {
-		return cell.attr.BgColour
+		return cell.bg
 	}
-	return cell.attr.FgColour
+	return cell.fg
 }
 
 func (cell *Cell) Bg() [3]float32 {
 	if cell.Attr().Inverse {
-		return cell.attr.FgColour
+		return cell.fg
 	}
-	return cell.attr.BgColour
+	return cell.bg
 }
 
-func (cell *Cell) erase(bgColour [3]float32) {
-	cell.setRune(0)
-	cell.attr.BgColour = bgColour
+// Colours returns the foreground and background colours of the cell, before inverse video is applied. Reverse screen
+// mode has already swapped them.
+func (cell *Cell) Colours() (fg [3]float32, bg [3]float32) {
+	return cell.fg, cell.bg
 }
 
-func (cell *Cell) setRune(r rune) {
-	cell.r = r
+func (cell *storedCell) Rune() rune {
+	return cell.r
 }
 
 func NewBackgroundCell(colour [3]float32) Cell {
 	return Cell{
 		attr: CellAttributes{
-			BgColour: colour,
+			BgColour: ColourFromFloats(colour),
 		},
+		bg: colour,
 	}
 }
-
-func (cellAttr *CellAttributes) ReverseVideo() {
-	oldFgColour := cellAttr.FgColour
-	cellAttr.FgColour = cellAttr.BgColour
-	cellAttr.BgColour = oldFgColour
-}
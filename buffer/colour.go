@@ -0,0 +1,98 @@
+package buffer
+
+// Colour is the colour of a cell, packed into 32 bits. The top byte selects the kind of colour: the default colour,
+// an index into the palette, or a 24-bit RGB value. Colours are only resolved into actual colours when cells are
+// read, so that changes to the palette apply to text which is already on the screen. The zero value is the
+// default colour.
+type Colour uint32
+
+const (
+	colourKindMask Colour = 0xff000000
+	colourDefault  Colour = 0x00000000
+	colourRGB      Colour = 0x01000000
+	colourPalette  Colour = 0x02000000
+)
+
+// DefaultColour is the default foreground or background colour, depending on where it is used
+const DefaultColour = colourDefault
+
+// Palette holds the actual colours which colours resolve to
+type Palette struct {
+	Colours   [256][3]float32 // the indexed colours, as selected by SGR 30-37, 90-97, 38;5 and their background equivalents
+	DefaultFg [3]float32
+	DefaultBg [3]float32
+}
+
+// RGBColour creates a true colour
+func RGBColour(r uint8, g uint8, b uint8) Colour {
+	return colourRGB | Colour(r)<<16 | Colour(g)<<8 | Colour(b)
+}
+
+// ColourFromFloats creates a true colour from components in the range 0 to 1, as used by the renderer
+func ColourFromFloats(rgb [3]float32) Colour {
+	var components [3]uint8
+	for i, c := range rgb {
+		switch {
+		case c <= 0:
+			components[i] = 0
+		case c >= 1:
+			components[i] = 0xff
+		default:
+			components[i] = uint8(c*0xff + 0.5)
+		}
+	}
+	return RGBColour(components[0], components[1], components[2])
+}
+
+// PaletteColour creates a colour which refers to an entry in the palette
+func PaletteColour(index uint8) Colour {
+	return colourPalette | Colour(index)
+}
+
+// IsDefault returns true for the default colour
+func (colour Colour) IsDefault() bool {
+	return colour&colourKindMask == colourDefault
+}
+
+// PaletteIndex returns the index of the colour in the palette, and false if it is not a palette colour
+func (colour Colour) PaletteIndex() (uint8, bool) {
+	if colour&colourKindMask != colourPalette {
+		return 0, false
+	}
+	return uint8(colour), true
+}
+
+// RGB returns the components of a true colour. Other colours must be resolved first.
+func (colour Colour) RGB() (r uint8, g uint8, b uint8) {
+	return uint8(colour >> 16), uint8(colour >> 8), uint8(colour)
+}
+
+// Fg resolves a foreground colour. A nil palette is all black.
+func (palette *Palette) Fg(colour Colour) [3]float32 {
+	if palette == nil {
+		return palette.resolve(colour, [3]float32{})
+	}
+	return palette.resolve(colour, palette.DefaultFg)
+}
+
+// Bg resolves a background colour. A nil palette is all black.
+func (palette *Palette) Bg(colour Colour) [3]float32 {
+	if palette == nil {
+		return palette.resolve(colour, [3]float32{})
+	}
+	return palette.resolve(colour, palette.DefaultBg)
+}
+
+func (palette *Palette) resolve(colour Colour, defaultColour [3]float32) [3]float32 {
+	switch colour & colourKindMask {
+	case colourDefault:
+		return defaultColour
+	case colourPalette:
+		if palette == nil {
+			return [3]float32{}
+		}
+		return palette.Colours[uint8(colour)]
+	}
+	r, g, b := colour.RGB()
+	return [3]float32{float32(r) / 0xff, float32(g) / 0xff, float32(b) / 0xff}
+}
@@ -0,0 +1,42 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColourResolve(t *testing.T) {
+	palette := &Palette{DefaultFg: [3]float32{1, 1, 1}, DefaultBg: [3]float32{0.1, 0.1, 0.1}}
+	palette.Colours[1] = [3]float32{0.8, 0.1, 0.1}
+
+	assert.Equal(t, [3]float32{0.8, 0.1, 0.1}, palette.Fg(PaletteColour(1)))
+	assert.Equal(t, [3]float32{0.8, 0.1, 0.1}, palette.Bg(PaletteColour(1)))
+	assert.Equal(t, [3]float32{1, float32(0x80) / 0xff, 0}, palette.Fg(RGBColour(0xff, 0x80, 0)))
+	assert.Equal(t, [3]float32{1, 1, 1}, palette.Fg(DefaultColour))
+	assert.Equal(t, [3]float32{0.1, 0.1, 0.1}, palette.Bg(DefaultColour))
+
+	var none *Palette
+	assert.Equal(t, [3]float32{}, none.Fg(PaletteColour(1)))
+	assert.Equal(t, [3]float32{}, none.Bg(DefaultColour))
+}
+
+func TestColourFromFloats(t *testing.T) {
+	rgb := [3]float32{float32(0x12) / 0xff, float32(0x34) / 0xff, float32(0xab) / 0xff}
+	assert.Equal(t, RGBColour(0x12, 0x34, 0xab), ColourFromFloats(rgb))
+	assert.Equal(t, rgb, (&Palette{}).Fg(ColourFromFloats(rgb)))
+	assert.Equal(t, RGBColour(0, 0xff, 0), ColourFromFloats([3]float32{-1, 2, 0}))
+}
+
+func TestColourPaletteIndex(t *testing.T) {
+	index, ok := PaletteColour(200).PaletteIndex()
+	assert.True(t, ok)
+	assert.Equal(t, uint8(200), index)
+
+	_, ok = RGBColour(0, 0, 200).PaletteIndex()
+	assert.False(t, ok)
+	_, ok = DefaultColour.PaletteIndex()
+	assert.False(t, ok)
+	assert.True(t, DefaultColour.IsDefault())
+	assert.False(t, RGBColour(0, 0, 0).IsDefault())
+}
@@ -9,9 +9,9 @@ import (
 
 func (buffer *Buffer) GetHintAtPosition(col uint16, viewRow uint16) *hints.Hint {
 
-	row := buffer.convertViewLineToRawLine(viewRow) - uint64(buffer.terminalState.scrollLinesFromBottom)
+	row := buffer.viewRowToLine(viewRow)
 
-	cell := buffer.GetRawCell(col, row)
+	cell := buffer.cellAt(col, row)
 	if cell == nil || cell.Rune() == 0x00 {
 		return nil
 	}
@@ -19,7 +19,7 @@ func (buffer *Buffer) GetHintAtPosition(col uint16, viewRow uint16) *hints.Hint
 	candidate := ""
 
 	for i := int(col); i >= 0; i-- {
-		cell := buffer.GetRawCell(uint16(i), row)
+		cell := buffer.cellAt(uint16(i), row)
 		if cell == nil {
 			break
 		}
@@ -33,7 +33,7 @@ func (buffer *Buffer) GetHintAtPosition(col uint16, viewRow uint16) *hints.Hint
 	sx := col - uint16(len(trimmed)-1)
 
 	for i := col + 1; i < buffer.terminalState.viewWidth; i++ {
-		cell := buffer.GetRawCell(i, row)
+		cell := buffer.cellAt(i, row)
 		if cell == nil {
 			break
 		}
@@ -44,8 +44,6 @@ func (buffer *Buffer) GetHintAtPosition(col uint16, viewRow uint16) *hints.Hint
 		candidate = fmt.Sprintf("%s%c", candidate, cell.Rune())
 	}
 
-	line := buffer.lines[row]
-
-	return hints.Get(strings.Trim(candidate, " "), line.String(), sx, viewRow)
+	return hints.Get(strings.Trim(candidate, " "), buffer.lineAt(row).String(), sx, viewRow)
 
 }
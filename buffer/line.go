@@ -4,26 +4,84 @@ import (
 	"strings"
 )
 
+// LineSize is the size of the characters on a line, as selected by DECSWL, DECDWL and DECDHL
+type LineSize uint8
+
+const (
+	LineSizeSingle             LineSize = iota // DECSWL
+	LineSizeDoubleWidth                        // DECDWL
+	LineSizeDoubleHeightTop                    // DECDHL top half
+	LineSizeDoubleHeightBottom                 // DECDHL bottom half
+)
+
 type Line struct {
-	wrapped bool // whether line was wrapped onto from the previous one
-	cells   []Cell
+	wrapped   bool // whether line was wrapped onto from the previous one when the view was resized
+	continued bool // whether text automatically wrapped onto the line from the previous one as it was written
+	size      LineSize
+	cells     []storedCell
+	attrs     *attributeTable // the table holding the attributes of the cells
 }
 
-func newLine() Line {
+func newLine(attrs *attributeTable) Line {
 	return Line{
 		wrapped: false,
-		cells:   []Cell{},
+		cells:   []storedCell{},
+		attrs:   attrs,
 	}
 }
 
+// Cells returns the cells of the line, with their attributes and colours
 func (line *Line) Cells() []Cell {
-	return line.cells
+	cells := make([]Cell, len(line.cells))
+	for i, cell := range line.cells {
+		cells[i] = line.attrs.cell(cell)
+	}
+	return cells
+}
+
+func (line *Line) Size() LineSize {
+	return line.size
+}
+
+// IsDoubleWidth returns true if each character on the line takes up two columns
+func (line *Line) IsDoubleWidth() bool {
+	return line.size != LineSizeSingle
 }
 
-func (line *Line) ReverseVideo() {
-	for i, _ := range line.cells {
-		line.cells[i].attr.ReverseVideo()
+// attributes returns the attributes of the cell at index i
+func (line *Line) attributes(i int) CellAttributes {
+	return line.attrs.get(line.cells[i].attr).attr
+}
+
+// setAttributes changes the attributes of the cell at index i, keeping its image
+func (line *Line) setAttributes(i int, attr CellAttributes) {
+	entry := line.attrs.get(line.cells[i].attr)
+	entry.attr = attr
+	line.cells[i].attr = line.attrs.intern(entry)
+}
+
+// setCell changes the rune and the attributes of the cell at index i
+func (line *Line) setCell(i int, r rune, attr CellAttributes) {
+	line.cells[i].r = r
+	line.setAttributes(i, attr)
+}
+
+// writeCell changes the rune of the cell at index i, and gives it the attributes at an index into the table.
+// A cell showing an image keeps it.
+func (line *Line) writeCell(i int, r rune, attrIndex uint32) {
+	if line.attrs.get(line.cells[i].attr).image != nil {
+		line.setCell(i, r, line.attrs.get(attrIndex).attr)
+		return
 	}
+	line.cells[i] = storedCell{r: r, attr: attrIndex}
+}
+
+// erase clears the rune of the cell at index i, giving it the background colour
+func (line *Line) erase(i int, bgColour Colour) {
+	attr := line.attributes(i)
+	attr.BgColour = bgColour
+	attr.Protected = false
+	line.setCell(i, 0, attr)
 }
 
 // Cleanse removes null bytes from the end of the row
@@ -41,6 +99,24 @@ func (line *Line) Cleanse() {
 	line.cells = line.cells[:len(line.cells)-cut]
 }
 
+// selectiveErase clears the runes of all unprotected cells in the range [start, end)
+func (line *Line) selectiveErase(start int, end int) {
+	if end > len(line.cells) {
+		end = len(line.cells)
+	}
+	for i := start; i < end; i++ {
+		if !line.attributes(i).Protected {
+			line.cells[i].r = 0
+		}
+	}
+}
+
+// IsContinuation returns true if text wrapped onto the line from the previous one, either as it was written or
+// when the view was resized
+func (line *Line) IsContinuation() bool {
+	return line.wrapped || line.continued
+}
+
 func (line *Line) setWrapped(wrapped bool) {
 	line.wrapped = wrapped
 }
@@ -53,30 +129,6 @@ func (line *Line) String() string {
 	return strings.TrimRight(string(runes), "\x00 ")
 }
 
-// @todo test these (ported from legacy) ------------------
-func (line *Line) CutCellsAfter(n int) []Cell {
-	cut := line.cells[n:]
-	line.cells = line.cells[:n]
-	return cut
-}
-
-func (line *Line) CutCellsFromBeginning(n int) []Cell {
-	if n > len(line.cells) {
-		n = len(line.cells)
-	}
-	cut := line.cells[:n]
-	line.cells = line.cells[n:]
-	return cut
-}
-
-func (line *Line) CutCellsFromEnd(n int) []Cell {
-	cut := line.cells[len(line.cells)-n:]
-	line.cells = line.cells[:len(line.cells)-n]
-	return cut
-}
-
-func (line *Line) Append(cells ...Cell) {
+func (line *Line) appendCells(cells ...storedCell) {
 	line.cells = append(line.cells, cells...)
 }
-
-// -------------------------------------------------------
@@ -8,8 +8,8 @@ import (
 
 func TestLine(t *testing.T) {
 
-	line := newLine()
-	line.cells = []Cell{
+	line := newLine(nil)
+	line.cells = []storedCell{
 		{r: 'h'},
 		{r: 'e'},
 		{r: 'l'},
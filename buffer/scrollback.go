@@ -0,0 +1,540 @@
+package buffer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+const (
+	scrollbackChunkLines  = 256 // lines compressed together
+	scrollbackCacheChunks = 4   // decompressed chunks kept, so scrolling back and forth doesn't decompress them again
+)
+
+// scrollbackStore keeps the oldest lines of the scrollback, which no longer fit in the lines held in memory by the
+// buffer, compressed in chunks, along with the images drawn in their cells. The chunks can be spilled to an encrypted
+// temporary file.
+// The renderer reads lines from the store while the parser adds them, so its methods are safe to call concurrently.
+type scrollbackStore struct {
+	mutex    sync.Mutex
+	chunks   []scrollbackChunk
+	firstID  int    // id of chunks[0], which stays the same as older chunks are dropped
+	skip     int    // lines dropped from the start of the first chunk
+	pending  []Line // lines which have not been compressed yet, oldest first
+	spill    *spillFile
+	spillOK  bool // false if chunks are kept in memory
+	cache    map[int][]Line
+	cacheIDs []int           // ids of the cached chunks, least recently used first
+	attrs    *attributeTable // the attribute table of the buffer, which decompressed lines use
+}
+
+type scrollbackChunk struct {
+	data   []byte // compressed lines, or nil if the chunk is in the spill file
+	offset int64
+	size   int
+}
+
+func newScrollbackStore(spillToDisk bool, attrs *attributeTable) *scrollbackStore {
+	return &scrollbackStore{
+		spillOK: spillToDisk,
+		cache:   map[int][]Line{},
+		attrs:   attrs,
+	}
+}
+
+// Len returns the number of lines in the store
+func (store *scrollbackStore) Len() int {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.length()
+}
+
+func (store *scrollbackStore) length() int {
+	return len(store.chunks)*scrollbackChunkLines - store.skip + len(store.pending)
+}
+
+// push adds lines after the newest line in the store
+func (store *scrollbackStore) push(lines []Line) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, line := range lines {
+		store.pending = append(store.pending, line)
+		if len(store.pending) == scrollbackChunkLines {
+			store.compress()
+		}
+	}
+}
+
+// compress turns the pending lines into a chunk
+func (store *scrollbackStore) compress() {
+	var data bytes.Buffer
+	writer, _ := flate.NewWriter(&data, flate.BestSpeed)
+	encodeLines(writer, store.pending)
+	writer.Close()
+	store.pending = []Line{}
+
+	chunk := scrollbackChunk{data: data.Bytes(), size: data.Len()}
+	if store.spillOK {
+		if err := store.spillChunk(&chunk); err != nil {
+			// keep the chunks in memory rather than lose them
+			store.spillOK = false
+		}
+	}
+	store.chunks = append(store.chunks, chunk)
+}
+
+// spillChunk moves the data of a chunk to the spill file, creating it first if need be
+func (store *scrollbackStore) spillChunk(chunk *scrollbackChunk) error {
+	if store.spill == nil {
+		spill, err := newSpillFile()
+		if err != nil {
+			return err
+		}
+		store.spill = spill
+	}
+
+	offset, size, err := store.spill.write(chunk.data)
+	if err != nil {
+		return err
+	}
+	chunk.data, chunk.offset, chunk.size = nil, offset, size
+	return nil
+}
+
+// trim drops the oldest lines until there are at most maxLines
+func (store *scrollbackStore) trim(maxLines int) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	excess := store.length() - maxLines
+	for excess > 0 && len(store.chunks) > 0 {
+		n := scrollbackChunkLines - store.skip
+		if n > excess {
+			n = excess
+		}
+		store.skip += n
+		excess -= n
+
+		if store.skip == scrollbackChunkLines {
+			store.dropChunk()
+		}
+	}
+	if excess > 0 {
+		store.pending = append([]Line{}, store.pending[excess:]...)
+	}
+
+	if store.spill != nil && store.spill.wasteful() {
+		store.compactSpill()
+	}
+}
+
+// dropChunk discards the oldest chunk
+func (store *scrollbackStore) dropChunk() {
+	if store.chunks[0].data == nil {
+		store.spill.free(store.chunks[0].size)
+	}
+	store.uncache(store.firstID)
+	store.chunks = store.chunks[1:]
+	store.firstID++
+	store.skip = 0
+}
+
+// compactSpill copies the chunks still in use to a new spill file, to give back the space used by dropped chunks
+func (store *scrollbackStore) compactSpill() {
+	spill, err := newSpillFile()
+	if err != nil {
+		return
+	}
+	spill.aead = store.spill.aead // the chunks are copied as they are, still encrypted
+	chunks := make([]scrollbackChunk, len(store.chunks))
+	for i, chunk := range store.chunks {
+		if chunk.data == nil {
+			sealed := make([]byte, chunk.size)
+			if _, err := store.spill.file.ReadAt(sealed, chunk.offset); err != nil {
+				spill.close()
+				return
+			}
+			chunk.offset, err = spill.writeSealed(sealed)
+			if err != nil {
+				spill.close()
+				return
+			}
+		}
+		chunks[i] = chunk
+	}
+
+	store.spill.close()
+	store.spill, store.chunks = spill, chunks
+}
+
+// line returns a copy of the line at an index into the store, where 0 is the oldest line. It returns nil if there is
+// no such line, which can happen if it was dropped since the index was worked out, or if it cannot be read back.
+func (store *scrollbackStore) line(index int) *Line {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if index < 0 || index >= store.length() {
+		return nil
+	}
+
+	index += store.skip
+	chunk := index / scrollbackChunkLines
+	var line Line
+	if chunk >= len(store.chunks) {
+		line = store.pending[index-len(store.chunks)*scrollbackChunkLines]
+	} else {
+		lines, err := store.load(chunk)
+		if err != nil {
+			return nil
+		}
+		line = lines[index%scrollbackChunkLines]
+	}
+
+	// the cells are copied, as the indexes of their attributes are rewritten when the attribute table is compacted
+	line.cells = append([]storedCell(nil), line.cells...)
+	return &line
+}
+
+// load decompresses a chunk, or returns it from the cache
+func (store *scrollbackStore) load(index int) ([]Line, error) {
+	id := store.firstID + index
+	if lines, ok := store.cache[id]; ok {
+		store.uncache(id)
+		store.cache[id] = lines
+		store.cacheIDs = append(store.cacheIDs, id)
+		return lines, nil
+	}
+
+	chunk := store.chunks[index]
+	data := chunk.data
+	if data == nil {
+		var err error
+		if data, err = store.spill.read(chunk.offset, chunk.size); err != nil {
+			return nil, err
+		}
+	}
+	lines, err := decodeLines(flate.NewReader(bytes.NewReader(data)), store.attrs)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) != scrollbackChunkLines {
+		return nil, fmt.Errorf("Scrollback chunk has %d lines, expected %d", len(lines), scrollbackChunkLines)
+	}
+
+	if len(store.cacheIDs) == scrollbackCacheChunks {
+		store.uncache(store.cacheIDs[0])
+	}
+	store.cache[id] = lines
+	store.cacheIDs = append(store.cacheIDs, id)
+	return lines, nil
+}
+
+// forEachLoadedLine calls fn with each line which holds indexes into the attribute table: the pending lines, and
+// those of the cached chunks. The store must be locked by the caller.
+func (store *scrollbackStore) forEachLoadedLine(fn func(line *Line)) {
+	for i := range store.pending {
+		fn(&store.pending[i])
+	}
+	for _, lines := range store.cache {
+		for i := range lines {
+			fn(&lines[i])
+		}
+	}
+}
+
+// uncache removes a chunk from the cache, if it is there
+func (store *scrollbackStore) uncache(id int) {
+	delete(store.cache, id)
+	for i, cached := range store.cacheIDs {
+		if cached == id {
+			store.cacheIDs = append(store.cacheIDs[:i], store.cacheIDs[i+1:]...)
+			break
+		}
+	}
+}
+
+// clear discards all of the lines
+func (store *scrollbackStore) clear() {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.closeSpill()
+	store.firstID += len(store.chunks)
+	store.chunks = nil
+	store.skip = 0
+	store.pending = nil
+	store.cache = map[int][]Line{}
+	store.cacheIDs = nil
+}
+
+// close removes the spill file, if there is one
+func (store *scrollbackStore) close() error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.closeSpill()
+}
+
+func (store *scrollbackStore) closeSpill() error {
+	if store.spill == nil {
+		return nil
+	}
+	err := store.spill.close()
+	store.spill = nil
+	return err
+}
+
+// spillFile is a temporary file which chunks are written to, encrypted with a key which is only kept in memory
+type spillFile struct {
+	file *os.File
+	aead cipher.AEAD
+	size int64 // end of the data written so far
+	live int64 // bytes used by chunks which have not been dropped
+}
+
+func newSpillFile() (*spillFile, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := ioutil.TempFile("", "aminal-scrollback-")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create scrollback file: %s", err)
+	}
+	// the file stays usable until it is closed, and is never left behind (this fails on Windows, where it is
+	// removed on close instead)
+	os.Remove(file.Name())
+
+	return &spillFile{file: file, aead: aead}, nil
+}
+
+// write encrypts data and appends it to the file, returning where it was written
+func (spill *spillFile) write(data []byte) (int64, int, error) {
+	nonce := make([]byte, spill.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return 0, 0, err
+	}
+	sealed := spill.aead.Seal(nonce, nonce, data, nil)
+	offset, err := spill.writeSealed(sealed)
+	return offset, len(sealed), err
+}
+
+// writeSealed appends data which has already been encrypted
+func (spill *spillFile) writeSealed(sealed []byte) (int64, error) {
+	offset := spill.size
+	if _, err := spill.file.WriteAt(sealed, offset); err != nil {
+		return 0, err
+	}
+	spill.size += int64(len(sealed))
+	spill.live += int64(len(sealed))
+	return offset, nil
+}
+
+// read returns the decrypted data written at offset
+func (spill *spillFile) read(offset int64, size int) ([]byte, error) {
+	sealed := make([]byte, size)
+	if _, err := spill.file.ReadAt(sealed, offset); err != nil {
+		return nil, err
+	}
+	nonceSize := spill.aead.NonceSize()
+	if size < nonceSize {
+		return nil, fmt.Errorf("Scrollback chunk is too short")
+	}
+	return spill.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+}
+
+// free records that a chunk of the given size has been dropped
+func (spill *spillFile) free(size int) {
+	spill.live -= int64(size)
+}
+
+// wasteful returns true if most of the file is taken up by dropped chunks
+func (spill *spillFile) wasteful() bool {
+	return spill.size > 1024*1024 && spill.size > 2*spill.live
+}
+
+func (spill *spillFile) close() error {
+	err := spill.file.Close()
+	os.Remove(spill.file.Name())
+	return err
+}
+
+// attribute flags as encoded in a chunk
+const (
+	encodedBold = 1 << iota
+	encodedDim
+	encodedItalic
+	encodedUnderline
+	encodedBlink
+	encodedInverse
+	encodedHidden
+	encodedProtected
+	encodedImage // the cell is followed by the bounds and pixels of its image
+)
+
+// line flags as encoded in a chunk
+const (
+	encodedWrapped = 1 << iota
+	encodedContinued
+)
+
+// encodeLines writes lines in a compact binary form
+func encodeLines(w io.Writer, lines []Line) error {
+	buf := make([]byte, binary.MaxVarintLen64)
+	writeUvarint := func(v uint64) error {
+		_, err := w.Write(buf[:binary.PutUvarint(buf, v)])
+		return err
+	}
+	writeVarint := func(v int64) error {
+		_, err := w.Write(buf[:binary.PutVarint(buf, v)])
+		return err
+	}
+
+	for _, line := range lines {
+		flags := uint64(0)
+		if line.wrapped {
+			flags |= encodedWrapped
+		}
+		if line.continued {
+			flags |= encodedContinued
+		}
+		writeUvarint(flags)
+		writeUvarint(uint64(line.size))
+		writeUvarint(uint64(len(line.cells)))
+
+		for _, cell := range line.cells {
+			entry := line.attrs.get(cell.attr)
+			attr := entry.attr
+			writeUvarint(uint64(cell.r))
+			writeUvarint(uint64(attr.FgColour))
+			writeUvarint(uint64(attr.BgColour))
+
+			flags := uint64(0)
+			for i, enabled := range []bool{attr.Bold, attr.Dim, attr.Italic, attr.Underline, attr.Blink, attr.Inverse, attr.Hidden, attr.Protected} {
+				if enabled {
+					flags |= 1 << uint(i)
+				}
+			}
+			if entry.image != nil {
+				flags |= encodedImage
+			}
+			if err := writeUvarint(flags); err != nil {
+				return err
+			}
+
+			if entry.image != nil {
+				bounds := entry.image.Bounds()
+				for _, v := range []int{bounds.Min.X, bounds.Min.Y, bounds.Max.X, bounds.Max.Y} {
+					writeVarint(int64(v))
+				}
+				for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+					offset := entry.image.PixOffset(bounds.Min.X, y)
+					if _, err := w.Write(entry.image.Pix[offset : offset+4*bounds.Dx()]); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// decodeLines reads lines written by encodeLines, adding their attributes to the table
+func decodeLines(r io.Reader, attrs *attributeTable) ([]Line, error) {
+	reader := bufio.NewReader(r)
+	lines := []Line{}
+
+	for {
+		flags, err := binary.ReadUvarint(reader)
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line := newLine(attrs)
+		line.wrapped = flags&encodedWrapped != 0
+		line.continued = flags&encodedContinued != 0
+		size, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return nil, err
+		}
+		line.size = LineSize(size)
+		count, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		line.cells = make([]storedCell, count)
+		var values [4]uint64 // rune, colours and the attribute flags
+		for i := range line.cells {
+			for j := range values {
+				if values[j], err = binary.ReadUvarint(reader); err != nil {
+					return nil, err
+				}
+			}
+
+			flags := values[3]
+			attr := CellAttributes{
+				FgColour:  Colour(values[1]),
+				BgColour:  Colour(values[2]),
+				Bold:      flags&encodedBold != 0,
+				Dim:       flags&encodedDim != 0,
+				Italic:    flags&encodedItalic != 0,
+				Underline: flags&encodedUnderline != 0,
+				Blink:     flags&encodedBlink != 0,
+				Inverse:   flags&encodedInverse != 0,
+				Hidden:    flags&encodedHidden != 0,
+				Protected: flags&encodedProtected != 0,
+			}
+			entry := attributeEntry{attr: attr}
+			if flags&encodedImage != 0 {
+				if entry.image, err = decodeImage(reader); err != nil {
+					return nil, err
+				}
+			}
+			line.cells[i] = storedCell{r: rune(values[0]), attr: attrs.intern(entry)}
+		}
+		lines = append(lines, line)
+	}
+}
+
+// decodeImage reads the image of a cell written by encodeLines
+func decodeImage(reader *bufio.Reader) (*image.RGBA, error) {
+	var bounds [4]int64
+	for i := range bounds {
+		var err error
+		if bounds[i], err = binary.ReadVarint(reader); err != nil {
+			return nil, err
+		}
+	}
+	rect := image.Rect(int(bounds[0]), int(bounds[1]), int(bounds[2]), int(bounds[3]))
+	if rect.Dx()*rect.Dy() > 1<<24 {
+		return nil, fmt.Errorf("Scrollback image is too large: %s", rect)
+	}
+	img := image.NewRGBA(rect)
+	if _, err := io.ReadFull(reader, img.Pix); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
@@ -0,0 +1,228 @@
+package buffer
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeBufferWithStore(memoryLines uint64, maxLines uint64, spill bool) *Buffer {
+	b := NewBuffer(NewTerminalState(10, 5, CellAttributes{}, maxLines))
+	b.terminalState.LineFeedMode = false
+	b.EnableScrollbackStore(memoryLines, spill)
+	return b
+}
+
+func writeNumberedLines(b *Buffer, from int, to int) {
+	for i := from; i < to; i++ {
+		b.Write([]rune(fmt.Sprintf("line %d", i))...)
+		b.NewLine()
+	}
+}
+
+func TestScrollbackEncoding(t *testing.T) {
+	attrs := newAttributeTable(nil)
+	line := newLine(attrs)
+	line.continued = true
+	line.size = LineSizeDoubleWidth
+	line.cells = []storedCell{{r: 'a'}, {r: '世'}}
+	line.setAttributes(0, CellAttributes{FgColour: RGBColour(10, 20, 30), BgColour: PaletteColour(4), Bold: true, Italic: true})
+	line.setAttributes(1, CellAttributes{Underline: true, Protected: true})
+
+	var data bytes.Buffer
+	require.NoError(t, encodeLines(&data, []Line{line, newLine(attrs)}))
+	decoded := newAttributeTable(nil)
+	lines, err := decodeLines(&data, decoded)
+	require.NoError(t, err)
+
+	require.Len(t, lines, 2)
+	assert.Equal(t, line.Cells(), lines[0].Cells())
+	assert.True(t, lines[0].continued)
+	assert.Equal(t, LineSizeDoubleWidth, lines[0].size)
+	assert.Len(t, lines[1].cells, 0)
+}
+
+func testImage(shade uint8) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 6))
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 4; x++ {
+			img.Set(x, y, color.RGBA{R: shade, G: uint8(x), B: uint8(y), A: 0xff})
+		}
+	}
+	return img
+}
+
+func TestScrollbackEncodingKeepsImages(t *testing.T) {
+	attrs := newAttributeTable(nil)
+	line := newLine(attrs)
+	line.cells = []storedCell{{r: ' '}, {r: 'x'}}
+	line.cells[0].attr = attrs.intern(attributeEntry{attr: CellAttributes{Bold: true}, image: testImage(1)})
+
+	// images taken from a larger one have their own bounds and stride
+	sub := testImage(2).SubImage(image.Rect(1, 2, 3, 5)).(*image.RGBA)
+	line.cells[1].attr = attrs.intern(attributeEntry{image: sub})
+
+	var data bytes.Buffer
+	require.NoError(t, encodeLines(&data, []Line{line}))
+	lines, err := decodeLines(&data, newAttributeTable(nil))
+	require.NoError(t, err)
+
+	cells := lines[0].Cells()
+	require.NotNil(t, cells[0].Image())
+	assert.Equal(t, testImage(1).Pix, cells[0].Image().Pix)
+	assert.True(t, cells[0].Attr().Bold)
+
+	require.NotNil(t, cells[1].Image())
+	assert.Equal(t, image.Rect(1, 2, 3, 5), cells[1].Image().Bounds())
+	for y := 2; y < 5; y++ {
+		for x := 1; x < 3; x++ {
+			assert.Equal(t, sub.At(x, y), cells[1].Image().At(x, y))
+		}
+	}
+	assert.Equal(t, 'x', cells[1].Rune())
+}
+
+func TestScrollbackStoreKeepsImages(t *testing.T) {
+	for _, spill := range []bool{false, true} {
+		b := makeBufferWithStore(10, 2000, spill)
+		b.Write([]rune("image")...)
+		b.SetCellImage(1, 0, testImage(3))
+		b.NewLine()
+		writeNumberedLines(b, 0, 600)
+		require.True(t, b.store.Len() > scrollbackChunkLines)
+
+		b.terminalState.SetScrollOffset(uint(b.TotalHeight() - int(b.ViewHeight())))
+		visible := b.GetVisibleLines()
+		require.Equal(t, "image", visible[0].String())
+		img := visible[0].Cells()[1].Image()
+		require.NotNil(t, img, "spill %v", spill)
+		assert.Equal(t, testImage(3).Pix, img.Pix)
+		assert.Nil(t, visible[0].Cells()[0].Image())
+		b.Close()
+	}
+}
+
+func TestScrollbackStoreKeepsOldLines(t *testing.T) {
+	b := makeBufferWithStore(100, 2000, false)
+	writeNumberedLines(b, 0, 1000)
+
+	assert.Equal(t, 100, b.Height())
+	assert.Equal(t, 1001, b.TotalHeight())
+	assert.Equal(t, 901, b.store.Len())
+	assert.Len(t, b.store.chunks, 3)
+
+	lines := b.AllLines()
+	require.Len(t, lines, 1001)
+	for i := 0; i < 1000; i++ {
+		assert.Equal(t, fmt.Sprintf("line %d", i), lines[i].String())
+	}
+}
+
+func TestScrollbackStoreDropsLinesOverMaximum(t *testing.T) {
+	b := makeBufferWithStore(100, 1000, false)
+	writeNumberedLines(b, 0, 3000)
+
+	assert.Equal(t, 1000, b.TotalHeight())
+	lines := b.AllLines()
+	assert.Equal(t, "line 2001", lines[0].String())
+	assert.Equal(t, "line 2999", lines[998].String())
+}
+
+func TestScrollingIntoScrollbackStore(t *testing.T) {
+	b := makeBufferWithStore(20, 2000, false)
+	writeNumberedLines(b, 0, 1000)
+
+	b.terminalState.SetScrollOffset(uint(b.TotalHeight() - int(b.ViewHeight())))
+	visible := b.GetVisibleLines()
+	require.Len(t, visible, 5)
+	assert.Equal(t, "line 0", visible[0].String())
+	assert.Equal(t, "line 4", visible[4].String())
+
+	b.StartSelection(0, 1, SelectionChar)
+	b.ExtendSelection(5, 2, true)
+	assert.Equal(t, "line 1\nline 2", b.GetSelectedText())
+}
+
+func TestScrollbackStoreSpillsToEncryptedFile(t *testing.T) {
+	b := makeBufferWithStore(10, 2000, true)
+	defer b.Close()
+	writeNumberedLines(b, 0, 600)
+
+	require.NotNil(t, b.store.spill)
+	for _, chunk := range b.store.chunks {
+		assert.Nil(t, chunk.data)
+	}
+
+	data := make([]byte, b.store.spill.size)
+	_, err := b.store.spill.file.ReadAt(data, 0)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "line")
+
+	assert.Equal(t, "line 0", b.AllLines()[0].String())
+}
+
+func TestScrollbackSpillCompaction(t *testing.T) {
+	b := makeBufferWithStore(10, 2000, true)
+	defer b.Close()
+	writeNumberedLines(b, 0, 1500)
+
+	b.store.trim(600)
+	size := b.store.spill.size
+	b.store.compactSpill()
+	assert.True(t, b.store.spill.size < size)
+
+	lines := b.AllLines()
+	assert.Equal(t, "line 891", lines[0].String())
+	assert.Equal(t, "line 1499", lines[len(lines)-2].String())
+}
+
+func TestClearScrollbackClearsStore(t *testing.T) {
+	b := makeBufferWithStore(20, 2000, true)
+	defer b.Close()
+	writeNumberedLines(b, 0, 1000)
+
+	b.ClearScrollback()
+	assert.Equal(t, 0, b.store.Len())
+	assert.Nil(t, b.store.spill)
+	assert.Equal(t, int(b.ViewHeight()), b.TotalHeight())
+
+	writeNumberedLines(b, 1000, 1400)
+	assert.Equal(t, "line 996", b.AllLines()[0].String())
+}
+
+func TestScrollbackStoreReadWhileWriting(t *testing.T) {
+	b := makeBufferWithStore(100, 2000, false)
+	writeNumberedLines(b, 0, 2000)
+
+	// the renderer reads lines from the store, decompressing them, while the parser adds and drops them
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			for _, index := range []int{-1800, -1500, -900, -300, -1} {
+				if line := b.lineAt(index); line != nil {
+					line.Cells()
+				}
+			}
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		writeNumberedLines(b, 2000+i*100, 2100+i*100)
+		for j := 0; j < 300; j++ {
+			b.CursorAttr().FgColour = PaletteColour(uint8(j)) // fill up the attribute table, so that it is compacted
+			b.Write('x')
+		}
+		b.CarriageReturn()
+		b.NewLine()
+	}
+	<-done
+
+	lines := b.AllLines()
+	assert.Equal(t, "line 3999", lines[len(lines)-32].String())
+}
@@ -1,46 +1,86 @@
 package buffer
 
+// DefaultTabWidth is the distance between the tab stops set on reset, unless configured otherwise
+const DefaultTabWidth uint16 = 4
+
 type TerminalState struct {
-	scrollLinesFromBottom uint
-	cursorX               uint16
-	cursorY               uint16
-	CursorAttr            CellAttributes
-	viewHeight            uint16
-	viewWidth             uint16
-	topMargin             uint // see DECSTBM docs - this is for scrollable regions
-	bottomMargin          uint // see DECSTBM docs - this is for scrollable regions
-	ReplaceMode           bool // overwrite character at cursor or insert new
-	OriginMode            bool // see DECOM docs - whether cursor is positioned within the margins or not
-	LineFeedMode          bool
-	ScreenMode            bool // DECSCNM (black on white background)
-	AutoWrap              bool
-	maxLines              uint64
-	tabStops              map[uint16]struct{}
-	Charsets              []*map[rune]rune // array of 2 charsets, nil means ASCII (no conversion)
-	CurrentCharset        int              // active charset index in Charsets array, valid values are 0 or 1
+	scrollLinesFromBottom      uint
+	CursorAttr                 CellAttributes
+	viewHeight                 uint16
+	viewWidth                  uint16
+	topMargin                  uint // see DECSTBM docs - this is for scrollable regions
+	bottomMargin               uint // see DECSTBM docs - this is for scrollable regions
+	ReplaceMode                bool // overwrite character at cursor or insert new
+	OriginMode                 bool // see DECOM docs - whether cursor is positioned within the margins or not
+	LineFeedMode               bool
+	ScreenMode                 bool // DECSCNM (black on white background) - swaps the colours of all cells as they are read
+	AutoWrap                   bool
+	RectangularAttributeExtent bool // DECSACE - whether DECCARA/DECRARA apply to a rectangle or a stream of characters
+	maxLines                   uint64
+	tabStops                   map[uint16]struct{}
+	tabWidth                   uint16
+	Charsets                   []*map[rune]rune // the G0 to G3 charsets, nil means ASCII (no conversion)
+	CurrentCharset             int              // charset invoked into GL, as an index in Charsets
+	CurrentCharsetGR           int              // charset invoked into GR, as an index in Charsets
+	SingleShift                int              // charset selected by SS2/SS3 for the next character only, 0 if none
+	palette                    Palette
 }
 
 // NewTerminalMode creates a new terminal state
 func NewTerminalState(viewCols uint16, viewLines uint16, attr CellAttributes, maxLines uint64) *TerminalState {
 	b := &TerminalState{
-		cursorX:      0,
-		cursorY:      0,
-		CursorAttr:   attr,
-		AutoWrap:     true,
-		maxLines:     maxLines,
-		viewWidth:    viewCols,
-		viewHeight:   viewLines,
-		topMargin:    0,
-		bottomMargin: uint(viewLines - 1),
-		Charsets:     []*map[rune]rune{nil, nil},
-		LineFeedMode: true,
+		CursorAttr:       attr,
+		AutoWrap:         true,
+		maxLines:         maxLines,
+		viewWidth:        viewCols,
+		viewHeight:       viewLines,
+		topMargin:        0,
+		bottomMargin:     uint(viewLines - 1),
+		Charsets:         []*map[rune]rune{nil, nil, nil, nil},
+		CurrentCharsetGR: 2,
+		LineFeedMode:     true,
+		tabWidth:         DefaultTabWidth,
 	}
 	b.TabReset()
 	return b
 }
 
+// SetPalette changes the actual colours which the colours of cells resolve to
+func (terminalState *TerminalState) SetPalette(palette Palette) {
+	terminalState.palette = palette
+}
+
+// Palette returns the actual colours which the colours of cells resolve to
+func (terminalState *TerminalState) Palette() *Palette {
+	return &terminalState.palette
+}
+
+// DefaultCell returns a blank cell with the attributes given by defaultAttributes
 func (terminalState *TerminalState) DefaultCell(applyEffects bool) Cell {
+	attr := terminalState.defaultAttributes(applyEffects)
+	fg, bg := terminalState.resolveColours(attr)
+	return Cell{attr: attr, fg: fg, bg: bg}
+}
+
+// resolveColours returns the actual foreground and background colours of the attributes, which are swapped in
+// reverse screen mode. Without a state, all colours are black.
+func (terminalState *TerminalState) resolveColours(attr CellAttributes) (fg [3]float32, bg [3]float32) {
+	if terminalState == nil {
+		return fg, bg
+	}
+	fg = terminalState.palette.Fg(attr.FgColour)
+	bg = terminalState.palette.Bg(attr.BgColour)
+	if terminalState.ScreenMode {
+		fg, bg = bg, fg
+	}
+	return fg, bg
+}
+
+// defaultAttributes returns the attributes of blank cells, which are those of the cursor without protection, or
+// without any effects unless applyEffects is set
+func (terminalState *TerminalState) defaultAttributes(applyEffects bool) CellAttributes {
 	attr := terminalState.CursorAttr
+	attr.Protected = false
 	if !applyEffects {
 		attr.Blink = false
 		attr.Bold = false
@@ -49,7 +89,7 @@ func (terminalState *TerminalState) DefaultCell(applyEffects bool) Cell {
 		attr.Underline = false
 		attr.Dim = false
 	}
-	return Cell{attr: attr}
+	return attr
 }
 
 func (terminalState *TerminalState) SetVerticalMargins(top uint, bottom uint) {
@@ -78,37 +118,40 @@ func (terminalState *TerminalState) TabClear(index uint16) {
 	delete(terminalState.tabStops, index)
 }
 
-func (terminalState *TerminalState) getTabIndexFromCursor() uint16 {
-	index := terminalState.cursorX
-	if index == terminalState.viewWidth {
-		index = 0
-	}
-	return index
-}
-
-func (terminalState *TerminalState) IsTabSetAtCursor() bool {
-	index := terminalState.getTabIndexFromCursor()
-	_, ok := terminalState.tabStops[index]
-	return ok
-}
-
-func (terminalState *TerminalState) TabClearAtCursor() {
-	terminalState.TabClear(terminalState.getTabIndexFromCursor())
-}
-
-func (terminalState *TerminalState) TabSetAtCursor() {
-	terminalState.TabSet(terminalState.getTabIndexFromCursor())
+// TabReset restores the default tab stops
+func (terminalState *TerminalState) TabReset() {
+	terminalState.TabSetEvery(terminalState.tabWidth)
 }
 
-func (terminalState *TerminalState) TabReset() {
+// TabSetEvery clears all tab stops, then sets one every step columns, starting step columns from the left margin
+func (terminalState *TerminalState) TabSetEvery(step uint16) {
 	terminalState.TabZonk()
 	const MaxTabs uint16 = 1024
-	const TabStep = 4
-	var i uint16
+	if step == 0 {
+		return
+	}
+	i := step
 	for i < MaxTabs {
 		terminalState.TabSet(i)
-		i += TabStep
+		i += step
+	}
+}
+
+// SetTabWidth changes the distance between default tab stops and resets the tab stops accordingly
+func (terminalState *TerminalState) SetTabWidth(width uint16) {
+	terminalState.tabWidth = width
+	terminalState.TabReset()
+}
+
+// TabStops returns the columns of all tab stops within the view, in ascending order
+func (terminalState *TerminalState) TabStops() []uint16 {
+	stops := []uint16{}
+	for i := uint16(0); i < terminalState.viewWidth; i++ {
+		if _, ok := terminalState.tabStops[i]; ok {
+			stops = append(stops, i)
+		}
 	}
+	return stops
 }
 
 func (terminalState *TerminalState) ViewHeight() uint16 {
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 
 	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/terminal"
 	"github.com/liamg/aminal/version"
 )
 
@@ -22,12 +23,16 @@ func getActuallyProvidedFlags() map[string]bool {
 	return result
 }
 
+// recordFile is the asciicast file to record the session to, if any
+var recordFile string
+
 func getConfig() *config.Config {
 	showVersion := false
 	ignoreConfig := false
 	shell := ""
 	debugMode := false
 	slomo := false
+	theme := ""
 
 	if flag.Parsed() == false {
 		flag.BoolVar(&showVersion, "version", showVersion, "Output version information")
@@ -35,6 +40,8 @@ func getConfig() *config.Config {
 		flag.StringVar(&shell, "shell", shell, "Specify the shell to use")
 		flag.BoolVar(&debugMode, "debug", debugMode, "Enable debug logging")
 		flag.BoolVar(&slomo, "slomo", slomo, "Render in slow motion (useful for debugging)")
+		flag.StringVar(&recordFile, "record", recordFile, "Record the session to an asciicast file")
+		flag.StringVar(&theme, "theme", theme, "Specify the colour theme to use")
 
 		flag.Parse() // actual parsing and fetching flags from the command line
 	}
@@ -69,9 +76,43 @@ func getConfig() *config.Config {
 		conf.Slomo = slomo
 	}
 
+	if !ignoreConfig {
+		if dir := themesDir(); dir != "" {
+			var err error
+			if conf.Themes, err = config.LoadThemes(dir); err != nil {
+				fmt.Printf("%s\n", err)
+			}
+		}
+	}
+
+	conf.RegisterConfigTheme()
+
+	if actuallyProvidedFlags["theme"] {
+		conf.Theme = theme
+	}
+
+	if conf.Theme != "" {
+		if err := conf.ApplyTheme(conf.Theme); err != nil {
+			fmt.Printf("%s\n", err)
+		}
+	}
+
 	return conf
 }
 
+// themesDir returns the directory which user themes are loaded from
+func themesDir() string {
+	if xdgHome := os.Getenv("XDG_CONFIG_HOME"); xdgHome != "" {
+		return filepath.Join(xdgHome, "aminal/themes")
+	}
+
+	usr, err := user.Current()
+	if err != nil || usr.HomeDir == "" {
+		return ""
+	}
+	return filepath.Join(usr.HomeDir, ".config/aminal/themes")
+}
+
 func loadConfigFile() *config.Config {
 
 	usr, err := user.Current()
@@ -120,3 +161,22 @@ func loadConfigFile() *config.Config {
 
 	return &config.DefaultConfig
 }
+
+// terminalSettings picks out the parts of the config used by the terminal
+func terminalSettings(conf *config.Config) terminal.Settings {
+	return terminal.Settings{
+		ColourScheme:          conf.ColourScheme,
+		MaxLines:              conf.MaxLines,
+		AltMaxLines:           conf.AltMaxLines,
+		MaxScrollbackBytes:    conf.MaxScrollbackBytes,
+		ScrollbackMemoryLines: conf.ScrollbackMemoryLines,
+		ScrollbackSpill:       conf.ScrollbackSpill,
+		TabWidth:              conf.TabWidth,
+		C1Controls:            conf.C1Controls,
+		PrintCommand:          conf.PrintCommand,
+		PrintFile:             conf.PrintFile,
+		SessionLogPath:        conf.SessionLogPath,
+		SessionLogMode:        conf.SessionLogMode,
+		Slomo:                 conf.Slomo,
+	}
+}
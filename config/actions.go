@@ -3,10 +3,18 @@ package config
 type UserAction string
 
 const (
-	ActionCopy        UserAction = "copy"
-	ActionPaste       UserAction = "paste"
-	ActionSearch      UserAction = "search"
-	ActionReportBug   UserAction = "report"
-	ActionToggleDebug UserAction = "debug"
-	ActionToggleSlomo UserAction = "slomo"
+	ActionCopy            UserAction = "copy"
+	ActionCopyHTML        UserAction = "copy_html"
+	ActionCopyANSI        UserAction = "copy_ansi"
+	ActionPaste           UserAction = "paste"
+	ActionSearch          UserAction = "search"
+	ActionReportBug       UserAction = "report"
+	ActionToggleDebug     UserAction = "debug"
+	ActionToggleSlomo     UserAction = "slomo"
+	ActionClearScrollback UserAction = "clear_scrollback"
+	ActionToggleRecording UserAction = "record"
+	ActionToggleLogging   UserAction = "session_log"
+	ActionExport          UserAction = "export"
+	ActionNextTheme       UserAction = "next_theme"
+	ActionSwitchTheme     UserAction = "switch_theme"
 )
@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math"
+	"strconv"
 	"strings"
 )
 
@@ -47,12 +48,56 @@ func (c *Colour) UnmarshalText(data []byte) error {
 func (c Colour) MarshalText() (text []byte, err error) {
 	return []byte(fmt.Sprintf(
 		"#%02x%02x%02x",
-		uint8(math.Floor(float64(255*c[0]))),
-		uint8(math.Floor(float64(255*c[1]))),
-		uint8(math.Floor(float64(255*c[2]))),
+		colourComponent(c[0]),
+		colourComponent(c[1]),
+		colourComponent(c[2]),
 	)), nil
 }
 
+// RGBA lets a colour be used as a color.Color
+func (c Colour) RGBA() (r, g, b, a uint32) {
+	return uint32(colourComponent(c[0])) * 0x101, uint32(colourComponent(c[1])) * 0x101, uint32(colourComponent(c[2])) * 0x101, 0xffff
+}
+
+// colourComponent converts a component in the range 0 to 1 to the nearest 8 bit value. Rounding rather than
+// truncating matters for colours imported from other programs, where 0.156862 is meant to be 40 rather than 39.
+func colourComponent(c float32) uint8 {
+	return uint8(math.Round(255 * math.Max(0, math.Min(1, float64(c)))))
+}
+
+// ParseColourSpec reads a colour as rgb:r/g/b with one to four hex digits per component, or as #rgb with the same
+// number of digits for each component. Unlike XParseColor, #ff0000 is full red rather than 0xff00/0xffff.
+func ParseColourSpec(spec string) (Colour, error) {
+	var parts []string
+	switch {
+	case strings.HasPrefix(spec, "rgb:"):
+		parts = strings.Split(spec[4:], "/")
+	case strings.HasPrefix(spec, "#") && len(spec) > 1 && (len(spec)-1)%3 == 0:
+		digits := (len(spec) - 1) / 3
+		for i := 0; i < 3; i++ {
+			parts = append(parts, spec[1+i*digits:1+(i+1)*digits])
+		}
+	default:
+		return Colour{}, fmt.Errorf("Unsupported colour specification: %s", spec)
+	}
+
+	var colour Colour
+	if len(parts) != 3 {
+		return colour, fmt.Errorf("Invalid colour specification: %s", spec)
+	}
+	for i, part := range parts {
+		if len(part) == 0 || len(part) > 4 {
+			return colour, fmt.Errorf("Invalid colour specification: %s", spec)
+		}
+		value, err := strconv.ParseUint(part, 16, 16)
+		if err != nil {
+			return colour, fmt.Errorf("Invalid colour specification: %s", spec)
+		}
+		colour[i] = float32(value) / float32(uint64(1)<<uint(4*len(part))-1)
+	}
+	return colour, nil
+}
+
 type ColourScheme struct {
 	Cursor       Colour `toml:"cursor"`
 	Foreground   Colour `toml:"foreground"`
@@ -2,6 +2,7 @@ package config
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
 
 	"github.com/BurntSushi/toml"
@@ -19,10 +20,24 @@ func TestColourTomlEncoding(t *testing.T) {
 	e := toml.NewEncoder(&buf)
 	err := e.Encode(target)
 	require.Nil(t, err)
-	assert.Equal(t, `colour = "#ff7f00"
+	assert.Equal(t, `colour = "#ff8000"
 `, buf.String())
 
 }
+func TestColourTomlEncodingRoundsComponents(t *testing.T) {
+	// iTerm2 stores 40/255 like this, which is just under 40 once multiplied back
+	text, err := Colour{0.15686273574829102, 1.5, -1}.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "#28ff00", string(text))
+
+	for i := 0; i < 256; i++ {
+		hex := fmt.Sprintf("#%02x%02x%02x", i, i, i)
+		text, err := strToColourNoErr(hex).MarshalText()
+		require.NoError(t, err)
+		assert.Equal(t, hex, string(text))
+	}
+}
+
 func TestColourTomlUnmarshalling(t *testing.T) {
 	target := struct {
 		Purple Colour `toml:"colour"`
@@ -33,3 +48,22 @@ func TestColourTomlUnmarshalling(t *testing.T) {
 	assert.InDelta(t, 0.0, target.Purple[1], 0.01)
 	assert.InDelta(t, 1.0, target.Purple[2], 0.01)
 }
+
+func TestParseColourSpec(t *testing.T) {
+	colour, err := ParseColourSpec("rgb:ff/80/00")
+	require.NoError(t, err)
+	assert.Equal(t, Colour{1, float32(0x80) / 0xff, 0}, colour)
+
+	colour, err = ParseColourSpec("rgb:f/ffff/0")
+	require.NoError(t, err)
+	assert.Equal(t, Colour{1, 1, 0}, colour)
+
+	colour, err = ParseColourSpec("#00ff00")
+	require.NoError(t, err)
+	assert.Equal(t, Colour{0, 1, 0}, colour)
+
+	for _, spec := range []string{"red", "rgb:ff/00", "rgb:fffff/0/0", "#12345", "rgb:zz/00/00"} {
+		_, err := ParseColourSpec(spec)
+		assert.Error(t, err, spec)
+	}
+}
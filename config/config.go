@@ -10,16 +10,48 @@ type Config struct {
 	DebugMode             bool             `toml:"debug"`
 	Slomo                 bool             `toml:"slomo"`
 	ColourScheme          ColourScheme     `toml:"colours"`
+	Theme                 string           `toml:"theme"`
+	Themes                []Theme          `toml:"-"` // the themes which can be switched to, loaded at startup
 	DPIScale              float32          `toml:"dpi-scale"`
 	Shell                 string           `toml:"shell"`
 	KeyMapping            KeyMappingConfig `toml:"keys"`
 	SearchURL             string           `toml:"search_url"`
 	MaxLines              uint64           `toml:"max_lines"`
+	AltMaxLines           uint64           `toml:"alt_max_lines"`
+	MaxScrollbackBytes    uint64           `toml:"max_scrollback_bytes"`
+	ScrollbackMemoryLines uint64           `toml:"scrollback_memory_lines"`
+	ScrollbackSpill       bool             `toml:"scrollback_spill"`
 	CopyAndPasteWithMouse bool             `toml:"copy_and_paste_with_mouse"`
+	TabWidth              uint16           `toml:"tab_width"`
+	C1Controls            C1Mode           `toml:"c1_controls"`
+	PrintCommand          string           `toml:"print_command"`
+	PrintFile             string           `toml:"print_file"`
+	RecordingDir          string           `toml:"recording_dir"`
+	SessionLogPath        string           `toml:"session_log_path"`
+	SessionLogMode        SessionLogMode   `toml:"session_log_mode"`
+	SessionLogAutoStart   bool             `toml:"session_log_auto_start"`
+	ExportPath            string           `toml:"export_path"`
 }
 
 type KeyMappingConfig map[string]string
 
+// C1Mode determines when 8-bit C1 control characters (0x80-0x9F) received from the pty are interpreted
+type C1Mode string
+
+const (
+	C1Never   C1Mode = "never"    // always treat them as printable code points
+	C1NonUTF8 C1Mode = "non-utf8" // interpret raw bytes which are not valid UTF-8
+	C1Always  C1Mode = "always"   // also interpret UTF-8 encoded code points U+0080-U+009F
+)
+
+// SessionLogMode determines what is written to a session log
+type SessionLogMode string
+
+const (
+	SessionLogRaw  SessionLogMode = "raw"  // the exact bytes received from the pty
+	SessionLogText SessionLogMode = "text" // plain text lines, as they scroll into the scrollback
+)
+
 func Parse(data []byte) (*Config, error) {
 	c := DefaultConfig
 	err := toml.Unmarshal(data, &c)
@@ -0,0 +1,438 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ImportFormat is a colour scheme format used by another program, which themes can be imported from
+type ImportFormat string
+
+const (
+	ITermColours    ImportFormat = "iterm"            // an iTerm2 .itermcolors property list
+	Xresources      ImportFormat = "xresources"       // *.colorN, *.foreground etc. entries from ~/.Xresources
+	Base16          ImportFormat = "base16"           // a base16 scheme, in YAML
+	WindowsTerminal ImportFormat = "windows-terminal" // a Windows Terminal scheme, or a settings file with schemes
+)
+
+// ParseImportFormat returns the import format with the given name
+func ParseImportFormat(name string) (ImportFormat, error) {
+	switch format := ImportFormat(strings.ToLower(name)); format {
+	case ITermColours, Xresources, Base16, WindowsTerminal:
+		return format, nil
+	}
+	return "", fmt.Errorf("Unknown theme format %q, expected iterm, xresources, base16 or windows-terminal", name)
+}
+
+// ImportFormatForPath picks the import format from the name of a file
+func ImportFormatForPath(path string) (ImportFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".itermcolors":
+		return ITermColours, nil
+	case ".xresources", ".xdefaults":
+		return Xresources, nil
+	case ".yaml", ".yml":
+		return Base16, nil
+	case ".json":
+		return WindowsTerminal, nil
+	}
+	return "", fmt.Errorf("Cannot tell the theme format from the name %q, expected .itermcolors, .Xresources, .yaml or .json", path)
+}
+
+// ImportThemes converts the schemes in data to themes. Formats which hold a single scheme without a name of its
+// own give one theme with the given name.
+func ImportThemes(format ImportFormat, name string, data []byte) ([]Theme, error) {
+	var scheme ColourScheme
+	var err error
+	switch format {
+	case ITermColours:
+		scheme, err = ParseITermColours(data)
+	case Xresources:
+		scheme, err = ParseXresources(data)
+	case Base16:
+		scheme, err = ParseBase16(data)
+	case WindowsTerminal:
+		return ParseWindowsTerminal(data)
+	default:
+		return nil, fmt.Errorf("Unknown theme format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []Theme{{Name: ThemeName(name), Colours: scheme}}, nil
+}
+
+// ThemeName turns the name of a scheme into one which can be used as a file name and typed as a flag, such as
+// "Solarized Dark (Higher Contrast)" into "solarized-dark-higher-contrast"
+func ThemeName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_')
+	})
+	if len(words) == 0 {
+		return "imported"
+	}
+	return strings.Join(words, "-")
+}
+
+// importedScheme collects the colours found in a file. Colours which are missing are taken from the default
+// scheme, except for the cursor, which follows the foreground.
+type importedScheme struct {
+	ColourScheme
+	found       int
+	cursorFound bool
+}
+
+func newImportedScheme() *importedScheme {
+	return &importedScheme{ColourScheme: DefaultConfig.ColourScheme}
+}
+
+func (scheme *importedScheme) setANSI(index int, colour Colour) {
+	if index < 0 || index > 15 {
+		return
+	}
+	scheme.set(scheme.ANSIColours()[index], colour)
+}
+
+func (scheme *importedScheme) set(field *Colour, colour Colour) {
+	*field = colour
+	scheme.found++
+	if field == &scheme.Cursor {
+		scheme.cursorFound = true
+	}
+}
+
+func (scheme *importedScheme) result() (ColourScheme, error) {
+	if scheme.found == 0 {
+		return ColourScheme{}, fmt.Errorf("No colours found")
+	}
+	if !scheme.cursorFound {
+		scheme.Cursor = scheme.Foreground
+	}
+	return scheme.ColourScheme, nil
+}
+
+// ParseITermColours reads an iTerm2 .itermcolors file, which is a property list of colour dictionaries
+func ParseITermColours(data []byte) (ColourScheme, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	start, err := plistNext(decoder)
+	if err == nil && start != nil && start.Name.Local == "plist" {
+		start, err = plistNext(decoder)
+	}
+	if err != nil {
+		return ColourScheme{}, fmt.Errorf("Invalid property list: %s", err)
+	}
+	if start == nil {
+		return ColourScheme{}, fmt.Errorf("Invalid property list: no value")
+	}
+	root, err := plistValue(decoder, *start)
+	if err != nil {
+		return ColourScheme{}, fmt.Errorf("Invalid property list: %s", err)
+	}
+	entries, ok := root.(map[string]interface{})
+	if !ok {
+		return ColourScheme{}, fmt.Errorf("Invalid iTerm2 colours: expected a dictionary of colours")
+	}
+
+	scheme := newImportedScheme()
+	for key, value := range entries {
+		components, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var colour Colour
+		for i, name := range []string{"Red Component", "Green Component", "Blue Component"} {
+			component, ok := components[name].(float64)
+			if !ok {
+				return ColourScheme{}, fmt.Errorf("Invalid iTerm2 colours: %s has no %s", key, name)
+			}
+			colour[i] = float32(component)
+		}
+
+		switch key {
+		case "Foreground Color":
+			scheme.set(&scheme.Foreground, colour)
+		case "Background Color":
+			scheme.set(&scheme.Background, colour)
+		case "Cursor Color":
+			scheme.set(&scheme.Cursor, colour)
+		case "Selection Color":
+			scheme.set(&scheme.Selection, colour)
+		default:
+			if strings.HasPrefix(key, "Ansi ") && strings.HasSuffix(key, " Color") {
+				if index, err := strconv.Atoi(key[5 : len(key)-6]); err == nil {
+					scheme.setANSI(index, colour)
+				}
+			}
+		}
+	}
+	return scheme.result()
+}
+
+// plistNext returns the next element inside the current one, or nil at the end of it
+func plistNext(decoder *xml.Decoder) (*xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			return &token, nil
+		case xml.EndElement:
+			return nil, nil
+		}
+	}
+}
+
+// plistValue reads the value which begins with the start element. Dictionaries become maps, numbers float64s and
+// strings strings; other values are skipped.
+func plistValue(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := map[string]interface{}{}
+		for {
+			keyStart, err := plistNext(decoder)
+			if err != nil || keyStart == nil {
+				return dict, err
+			}
+			var key string
+			if err := decoder.DecodeElement(&key, keyStart); err != nil {
+				return nil, err
+			}
+			valueStart, err := plistNext(decoder)
+			if err != nil {
+				return nil, err
+			}
+			if valueStart == nil {
+				return nil, fmt.Errorf("Missing value for %s", key)
+			}
+			if dict[key], err = plistValue(decoder, *valueStart); err != nil {
+				return nil, err
+			}
+		}
+	case "real", "integer":
+		var text string
+		if err := decoder.DecodeElement(&text, &start); err != nil {
+			return nil, err
+		}
+		return strconv.ParseFloat(strings.TrimSpace(text), 64)
+	case "string":
+		var text string
+		err := decoder.DecodeElement(&text, &start)
+		return text, err
+	}
+	return nil, decoder.Skip()
+}
+
+// ParseXresources reads the colours from X resources, such as *.color0 or URxvt*foreground, including values given
+// by #define
+func ParseXresources(data []byte) (ColourScheme, error) {
+	scheme := newImportedScheme()
+	defines := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#define") {
+			if fields := strings.Fields(line); len(fields) == 3 {
+				defines[fields[1]] = fields[2]
+			}
+			continue
+		}
+		if line == "" || line[0] == '!' || line[0] == '#' {
+			continue
+		}
+
+		colon := strings.Index(line, ":")
+		if colon < 0 {
+			continue
+		}
+		resource := strings.TrimSpace(line[:colon])
+		resource = resource[strings.LastIndexAny(resource, ".*")+1:]
+		value := strings.TrimSpace(line[colon+1:])
+		if defined, ok := defines[value]; ok {
+			value = defined
+		}
+
+		var field *Colour
+		index := -1
+		switch {
+		case resource == "foreground":
+			field = &scheme.Foreground
+		case resource == "background":
+			field = &scheme.Background
+		case resource == "cursorColor":
+			field = &scheme.Cursor
+		case resource == "highlightColor":
+			field = &scheme.Selection
+		case strings.HasPrefix(resource, "color"):
+			n, err := strconv.Atoi(resource[5:])
+			if err != nil || n > 15 {
+				continue
+			}
+			index = n
+		default:
+			continue
+		}
+
+		colour, err := ParseColourSpec(value)
+		if err != nil {
+			return ColourScheme{}, fmt.Errorf("Invalid colour for %s: %s", resource, err)
+		}
+		if field != nil {
+			scheme.set(field, colour)
+		} else {
+			scheme.setANSI(index, colour)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ColourScheme{}, err
+	}
+	return scheme.result()
+}
+
+var base16Entry = regexp.MustCompile(`^\s*(base0[0-9a-fA-F])\s*:\s*["']?#?([0-9a-fA-F]{6})["']?\s*(#.*)?$`)
+
+// the base16 colours used for each of the ANSI colours, as chosen by base16-shell
+var base16ANSI = [16]int{0x0, 0x8, 0xb, 0xa, 0xd, 0xe, 0xc, 0x5, 0x3, 0x8, 0xb, 0xa, 0xd, 0xe, 0xc, 0x7}
+
+// ParseBase16 reads a base16 scheme. Only the base00 to base0F entries are needed, so rather than a full YAML
+// parser, each line is matched on its own; this handles both the original flat files and those with the colours
+// nested under palette.
+func ParseBase16(data []byte) (ColourScheme, error) {
+	var base [16]*Colour
+	for _, line := range strings.Split(string(data), "\n") {
+		match := base16Entry.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+		index, _ := strconv.ParseUint(match[1][5:], 16, 8)
+		colour, err := strToColour(match[2])
+		if err != nil {
+			return ColourScheme{}, err
+		}
+		base[index] = &colour
+	}
+	for i, colour := range base {
+		if colour == nil {
+			return ColourScheme{}, fmt.Errorf("Invalid base16 scheme: base0%X is missing", i)
+		}
+	}
+
+	scheme := newImportedScheme()
+	for i, b := range base16ANSI {
+		scheme.setANSI(i, *base[b])
+	}
+	scheme.set(&scheme.Foreground, *base[0x5])
+	scheme.set(&scheme.Background, *base[0x0])
+	scheme.set(&scheme.Selection, *base[0x2])
+	return scheme.result()
+}
+
+// windowsTerminalScheme is a colour scheme as it appears in the schemes of Windows Terminal's settings
+type windowsTerminalScheme struct {
+	Name                string `json:"name"`
+	Foreground          string `json:"foreground"`
+	Background          string `json:"background"`
+	CursorColor         string `json:"cursorColor"`
+	SelectionBackground string `json:"selectionBackground"`
+	Black               string `json:"black"`
+	Red                 string `json:"red"`
+	Green               string `json:"green"`
+	Yellow              string `json:"yellow"`
+	Blue                string `json:"blue"`
+	Purple              string `json:"purple"`
+	Cyan                string `json:"cyan"`
+	White               string `json:"white"`
+	BrightBlack         string `json:"brightBlack"`
+	BrightRed           string `json:"brightRed"`
+	BrightGreen         string `json:"brightGreen"`
+	BrightYellow        string `json:"brightYellow"`
+	BrightBlue          string `json:"brightBlue"`
+	BrightPurple        string `json:"brightPurple"`
+	BrightCyan          string `json:"brightCyan"`
+	BrightWhite         string `json:"brightWhite"`
+}
+
+// ParseWindowsTerminal reads Windows Terminal colour schemes, named by their name entries. The data can be a single
+// scheme, an array of them, or a settings file with a schemes array. Settings files with comments must have them
+// removed first.
+func ParseWindowsTerminal(data []byte) ([]Theme, error) {
+	var schemes []windowsTerminalScheme
+	var settings struct {
+		Schemes []windowsTerminalScheme `json:"schemes"`
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &schemes); err != nil {
+			return nil, fmt.Errorf("Invalid Windows Terminal schemes: %s", err)
+		}
+	} else if err := json.Unmarshal(trimmed, &settings); err != nil {
+		return nil, fmt.Errorf("Invalid Windows Terminal scheme: %s", err)
+	} else if settings.Schemes != nil {
+		schemes = settings.Schemes
+	} else {
+		var single windowsTerminalScheme
+		if err := json.Unmarshal(trimmed, &single); err != nil {
+			return nil, fmt.Errorf("Invalid Windows Terminal scheme: %s", err)
+		}
+		schemes = []windowsTerminalScheme{single}
+	}
+
+	themes := make([]Theme, 0, len(schemes))
+	for _, s := range schemes {
+		scheme, err := s.scheme()
+		if err != nil {
+			return nil, fmt.Errorf("Invalid Windows Terminal scheme %q: %s", s.Name, err)
+		}
+		themes = append(themes, Theme{Name: ThemeName(s.Name), Colours: scheme})
+	}
+	if len(themes) == 0 {
+		return nil, fmt.Errorf("No Windows Terminal schemes found")
+	}
+	return themes, nil
+}
+
+func (s windowsTerminalScheme) scheme() (ColourScheme, error) {
+	scheme := newImportedScheme()
+	ansi := scheme.ANSIColours()
+	for field, value := range map[*Colour]string{
+		&scheme.Foreground: s.Foreground,
+		&scheme.Background: s.Background,
+		&scheme.Cursor:     s.CursorColor,
+		&scheme.Selection:  s.SelectionBackground,
+		ansi[0]:            s.Black,
+		ansi[1]:            s.Red,
+		ansi[2]:            s.Green,
+		ansi[3]:            s.Yellow,
+		ansi[4]:            s.Blue,
+		ansi[5]:            s.Purple,
+		ansi[6]:            s.Cyan,
+		ansi[7]:            s.White,
+		ansi[8]:            s.BrightBlack,
+		ansi[9]:            s.BrightRed,
+		ansi[10]:           s.BrightGreen,
+		ansi[11]:           s.BrightYellow,
+		ansi[12]:           s.BrightBlue,
+		ansi[13]:           s.BrightPurple,
+		ansi[14]:           s.BrightCyan,
+		ansi[15]:           s.BrightWhite,
+	} {
+		if value == "" {
+			continue
+		}
+		colour, err := strToColour(value)
+		if err != nil {
+			return ColourScheme{}, err
+		}
+		scheme.set(field, colour)
+	}
+	return scheme.result()
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testITermColours = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Ansi 1 Color</key>
+	<dict>
+		<key>Alpha Component</key>
+		<real>1</real>
+		<key>Blue Component</key>
+		<real>0.15686273574829102</real>
+		<key>Color Space</key>
+		<string>sRGB</string>
+		<key>Green Component</key>
+		<real>0</real>
+		<key>Red Component</key>
+		<real>1</real>
+	</dict>
+	<key>Ansi 12 Color</key>
+	<dict>
+		<key>Blue Component</key>
+		<real>1</real>
+		<key>Green Component</key>
+		<integer>0</integer>
+		<key>Red Component</key>
+		<real>0</real>
+	</dict>
+	<key>Background Color</key>
+	<dict>
+		<key>Blue Component</key>
+		<real>0.12549020349979401</real>
+		<key>Green Component</key>
+		<real>0.12549020349979401</real>
+		<key>Red Component</key>
+		<real>0.12549020349979401</real>
+	</dict>
+	<key>Bold Color</key>
+	<dict>
+		<key>Blue Component</key>
+		<real>1</real>
+		<key>Green Component</key>
+		<real>1</real>
+		<key>Red Component</key>
+		<real>1</real>
+	</dict>
+	<key>Use Bright Bold</key>
+	<true/>
+</dict>
+</plist>
+`
+
+func hexOf(t *testing.T, colour Colour) string {
+	text, err := colour.MarshalText()
+	require.NoError(t, err)
+	return string(text)
+}
+
+func TestParseITermColours(t *testing.T) {
+	scheme, err := ParseITermColours([]byte(testITermColours))
+	require.NoError(t, err)
+
+	assert.Equal(t, "#ff0028", hexOf(t, scheme.Red))
+	assert.Equal(t, "#0000ff", hexOf(t, scheme.LightBlue))
+	assert.Equal(t, "#202020", hexOf(t, scheme.Background))
+	assert.Equal(t, DefaultConfig.ColourScheme.Green, scheme.Green)
+	assert.Equal(t, scheme.Foreground, scheme.Cursor)
+
+	_, err = ParseITermColours([]byte(`<plist><dict><key>Ansi 0 Color</key><dict><key>Red Component</key><real>1</real></dict></dict></plist>`))
+	assert.Error(t, err)
+	_, err = ParseITermColours([]byte(`<plist><dict></dict></plist>`))
+	assert.Error(t, err)
+	_, err = ParseITermColours([]byte(`not xml`))
+	assert.Error(t, err)
+}
+
+func TestParseXresources(t *testing.T) {
+	scheme, err := ParseXresources([]byte(`! comment
+#define base00 #181818
+#define base08 #ab4642
+
+*.foreground:   #d8d8d8
+*.background:   base00
+*.cursorColor:  rgb:ff/ff/ff
+URxvt*color1:   base08
+*color9:        #dc9656
+XTerm*vt100.color15: #f8f8f8
+*.color200:     #000000
+URxvt.font:     xft:Monospace:size=10
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "#d8d8d8", hexOf(t, scheme.Foreground))
+	assert.Equal(t, "#181818", hexOf(t, scheme.Background))
+	assert.Equal(t, "#ffffff", hexOf(t, scheme.Cursor))
+	assert.Equal(t, "#ab4642", hexOf(t, scheme.Red))
+	assert.Equal(t, "#dc9656", hexOf(t, scheme.LightRed))
+	assert.Equal(t, "#f8f8f8", hexOf(t, scheme.White))
+	assert.Equal(t, DefaultConfig.ColourScheme.Black, scheme.Black)
+
+	_, err = ParseXresources([]byte("*.color1: red\n"))
+	assert.Error(t, err)
+	_, err = ParseXresources([]byte("URxvt.font: Monospace\n"))
+	assert.Error(t, err)
+}
+
+func TestParseBase16(t *testing.T) {
+	flat := `scheme: "Default Dark"
+author: "Chris Kempson (http://chriskempson.com)"
+base00: "181818" # background
+base01: "282828"
+base02: "383838"
+base03: "585858"
+base04: "b8b8b8"
+base05: "d8d8d8"
+base06: "e8e8e8"
+base07: "f8f8f8"
+base08: "ab4642"
+base09: "dc9656"
+base0A: "f7ca88"
+base0B: "a1b56c"
+base0C: "86c1b9"
+base0D: "7cafc2"
+base0E: "ba8baf"
+base0F: "a16946"
+`
+	scheme, err := ParseBase16([]byte(flat))
+	require.NoError(t, err)
+
+	assert.Equal(t, "#181818", hexOf(t, scheme.Background))
+	assert.Equal(t, "#d8d8d8", hexOf(t, scheme.Foreground))
+	assert.Equal(t, "#d8d8d8", hexOf(t, scheme.Cursor))
+	assert.Equal(t, "#383838", hexOf(t, scheme.Selection))
+	assert.Equal(t, "#ab4642", hexOf(t, scheme.Red))
+	assert.Equal(t, "#ab4642", hexOf(t, scheme.LightRed))
+	assert.Equal(t, "#585858", hexOf(t, scheme.DarkGrey))
+	assert.Equal(t, "#f8f8f8", hexOf(t, scheme.White))
+
+	nested := "system: \"base16\"\nname: \"Default Dark\"\npalette:\n"
+	for _, line := range []string{"base00: \"#181818\"", "base01: \"#282828\"", "base02: \"#383838\"", "base03: \"#585858\"",
+		"base04: \"#b8b8b8\"", "base05: \"#d8d8d8\"", "base06: \"#e8e8e8\"", "base07: \"#f8f8f8\"",
+		"base08: \"#ab4642\"", "base09: \"#dc9656\"", "base0a: \"#f7ca88\"", "base0b: \"#a1b56c\"",
+		"base0c: \"#86c1b9\"", "base0d: \"#7cafc2\"", "base0e: \"#ba8baf\"", "base0f: \"#a16946\""} {
+		nested += "  " + line + "\r\n"
+	}
+	nestedScheme, err := ParseBase16([]byte(nested))
+	require.NoError(t, err)
+	assert.Equal(t, scheme, nestedScheme)
+
+	_, err = ParseBase16([]byte("base00: \"181818\"\n"))
+	assert.Error(t, err)
+}
+
+func TestParseWindowsTerminal(t *testing.T) {
+	single := `{
+		"name": "Campbell Powershell",
+		"foreground": "#CCCCCC",
+		"background": "#012456",
+		"cursorColor": "#FFFFFF",
+		"selectionBackground": "#FFFFFF",
+		"black": "#0C0C0C",
+		"red": "#C50F1F",
+		"purple": "#881798",
+		"brightWhite": "#F2F2F2"
+	}`
+	themes, err := ParseWindowsTerminal([]byte(single))
+	require.NoError(t, err)
+	require.Len(t, themes, 1)
+	assert.Equal(t, "campbell-powershell", themes[0].Name)
+	assert.Equal(t, "#012456", hexOf(t, themes[0].Colours.Background))
+	assert.Equal(t, "#881798", hexOf(t, themes[0].Colours.Magenta))
+	assert.Equal(t, "#f2f2f2", hexOf(t, themes[0].Colours.White))
+	assert.Equal(t, DefaultConfig.ColourScheme.Green, themes[0].Colours.Green)
+
+	settings := `{"profiles": {}, "schemes": [` + single + `, {"name": "One Half Dark", "background": "#282C34"}]}`
+	themes, err = ParseWindowsTerminal([]byte(settings))
+	require.NoError(t, err)
+	require.Len(t, themes, 2)
+	assert.Equal(t, "one-half-dark", themes[1].Name)
+
+	themes, err = ParseWindowsTerminal([]byte("[" + single + "]"))
+	require.NoError(t, err)
+	require.Len(t, themes, 1)
+
+	_, err = ParseWindowsTerminal([]byte(`{"name": "Bad", "red": "#C50F"}`))
+	assert.Error(t, err)
+	_, err = ParseWindowsTerminal([]byte(`{"schemes": []}`))
+	assert.Error(t, err)
+}
+
+func TestImportThemes(t *testing.T) {
+	format, err := ImportFormatForPath("/home/user/.Xresources")
+	require.NoError(t, err)
+	assert.Equal(t, Xresources, format)
+
+	format, err = ImportFormatForPath("Solarized Dark.itermcolors")
+	require.NoError(t, err)
+	assert.Equal(t, ITermColours, format)
+
+	_, err = ImportFormatForPath("theme.txt")
+	assert.Error(t, err)
+
+	format, err = ParseImportFormat("Base16")
+	require.NoError(t, err)
+	assert.Equal(t, Base16, format)
+
+	themes, err := ImportThemes(ITermColours, "Solarized Dark (Higher Contrast)", []byte(testITermColours))
+	require.NoError(t, err)
+	require.Len(t, themes, 1)
+	assert.Equal(t, "solarized-dark-higher-contrast", themes[0].Name)
+
+	data, err := themes[0].Encode()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `red = "#ff0028"`)
+	decoded, err := ParseTheme(themes[0].Name, data)
+	require.NoError(t, err)
+	assert.Equal(t, hexOf(t, themes[0].Colours.Red), hexOf(t, decoded.Colours.Red))
+}
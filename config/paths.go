@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExpandPath expands the placeholders in a path template from the config: a leading ~ for the home
+// directory, $DATE, $TIME and $HOST
+func ExpandPath(template string, now time.Time) (string, error) {
+	path := template
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		usr, err := user.Current()
+		if err != nil {
+			return "", fmt.Errorf("Failed to find home directory: %s", err)
+		}
+		path = filepath.Join(usr.HomeDir, path[1:])
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return strings.NewReplacer(
+		"$DATE", now.Format("2006-01-02"),
+		"$TIME", now.Format("15-04-05"),
+		"$HOST", host,
+	).Replace(path), nil
+}
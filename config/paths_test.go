@@ -0,0 +1,26 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandPath(t *testing.T) {
+	now := time.Date(2019, 1, 2, 15, 4, 5, 0, time.UTC)
+	host, err := os.Hostname()
+	require.NoError(t, err)
+
+	path, err := ExpandPath("/var/log/aminal/$HOST/$DATE-$TIME.log", now)
+	require.NoError(t, err)
+	assert.Equal(t, "/var/log/aminal/"+host+"/2019-01-02-15-04-05.log", path)
+
+	path, err = ExpandPath("~/session.log", now)
+	require.NoError(t, err)
+	assert.False(t, strings.HasPrefix(path, "~"))
+	assert.True(t, strings.HasSuffix(path, "/session.log"))
+}
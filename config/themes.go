@@ -0,0 +1,193 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Theme is a named colour scheme, which can be selected with the theme setting, the --theme flag or at runtime
+type Theme struct {
+	Name    string
+	Colours ColourScheme
+}
+
+// BuiltinThemes are the themes which are always available, in the order they are cycled through
+var BuiltinThemes = []Theme{
+	{Name: "default", Colours: DefaultConfig.ColourScheme},
+	{Name: "solarized-dark", Colours: newScheme("#839496", "#002b36", "#93a1a1", "#073642",
+		"#073642", "#dc322f", "#859900", "#b58900", "#268bd2", "#d33682", "#2aa198", "#eee8d5",
+		"#002b36", "#cb4b16", "#586e75", "#657b83", "#839496", "#6c71c4", "#93a1a1", "#fdf6e3")},
+	{Name: "solarized-light", Colours: newScheme("#657b83", "#fdf6e3", "#586e75", "#eee8d5",
+		"#073642", "#dc322f", "#859900", "#b58900", "#268bd2", "#d33682", "#2aa198", "#eee8d5",
+		"#002b36", "#cb4b16", "#586e75", "#657b83", "#839496", "#6c71c4", "#93a1a1", "#fdf6e3")},
+	{Name: "gruvbox-dark", Colours: newScheme("#ebdbb2", "#282828", "#ebdbb2", "#504945",
+		"#282828", "#cc241d", "#98971a", "#d79921", "#458588", "#b16286", "#689d6a", "#a89984",
+		"#928374", "#fb4934", "#b8bb26", "#fabd2f", "#83a598", "#d3869b", "#8ec07c", "#ebdbb2")},
+	{Name: "gruvbox-light", Colours: newScheme("#3c3836", "#fbf1c7", "#3c3836", "#d5c4a1",
+		"#fbf1c7", "#cc241d", "#98971a", "#d79921", "#458588", "#b16286", "#689d6a", "#7c6f64",
+		"#928374", "#9d0006", "#79740e", "#b57614", "#076678", "#8f3f71", "#427b58", "#3c3836")},
+	{Name: "dracula", Colours: newScheme("#f8f8f2", "#282a36", "#f8f8f2", "#44475a",
+		"#21222c", "#ff5555", "#50fa7b", "#f1fa8c", "#bd93f9", "#ff79c6", "#8be9fd", "#f8f8f2",
+		"#6272a4", "#ff6e6e", "#69ff94", "#ffffa5", "#d6acff", "#ff92df", "#a4ffff", "#ffffff")},
+	{Name: "nord", Colours: newScheme("#d8dee9", "#2e3440", "#d8dee9", "#434c5e",
+		"#3b4252", "#bf616a", "#a3be8c", "#ebcb8b", "#81a1c1", "#b48ead", "#88c0d0", "#e5e9f0",
+		"#4c566a", "#bf616a", "#a3be8c", "#ebcb8b", "#81a1c1", "#b48ead", "#8fbcbb", "#eceff4")},
+	{Name: "tomorrow", Colours: newScheme("#4d4d4c", "#ffffff", "#4d4d4c", "#d6d6d6",
+		"#000000", "#c82829", "#718c00", "#eab700", "#4271ae", "#8959a8", "#3e999f", "#d6d6d6",
+		"#8e908c", "#c82829", "#718c00", "#eab700", "#4271ae", "#8959a8", "#3e999f", "#ffffff")},
+	{Name: "tomorrow-night", Colours: newScheme("#c5c8c6", "#1d1f21", "#c5c8c6", "#373b41",
+		"#1d1f21", "#cc6666", "#b5bd68", "#f0c674", "#81a2be", "#b294bb", "#8abeb7", "#c5c8c6",
+		"#969896", "#cc6666", "#b5bd68", "#f0c674", "#81a2be", "#b294bb", "#8abeb7", "#ffffff")},
+	{Name: "monokai", Colours: newScheme("#f8f8f2", "#272822", "#f8f8f0", "#49483e",
+		"#272822", "#f92672", "#a6e22e", "#f4bf75", "#66d9ef", "#ae81ff", "#a1efe4", "#f8f8f2",
+		"#75715e", "#f92672", "#a6e22e", "#f4bf75", "#66d9ef", "#ae81ff", "#a1efe4", "#f9f8f5")},
+	{Name: "one-dark", Colours: newScheme("#abb2bf", "#282c34", "#528bff", "#3e4451",
+		"#282c34", "#e06c75", "#98c379", "#e5c07b", "#61afef", "#c678dd", "#56b6c2", "#abb2bf",
+		"#5c6370", "#e06c75", "#98c379", "#e5c07b", "#61afef", "#c678dd", "#56b6c2", "#ffffff")},
+}
+
+// newScheme creates a colour scheme from hex colours, with the 16 ANSI colours in the order of their SGR codes
+func newScheme(foreground string, background string, cursor string, selection string, ansi ...string) ColourScheme {
+	scheme := ColourScheme{
+		Foreground: strToColourNoErr(foreground),
+		Background: strToColourNoErr(background),
+		Cursor:     strToColourNoErr(cursor),
+		Selection:  strToColourNoErr(selection),
+	}
+	for i, colour := range scheme.ANSIColours() {
+		*colour = strToColourNoErr(ansi[i])
+	}
+	return scheme
+}
+
+// ANSIColours returns the 16 ANSI colours of the scheme, in the order of their SGR codes
+func (scheme *ColourScheme) ANSIColours() []*Colour {
+	return []*Colour{
+		&scheme.Black, &scheme.Red, &scheme.Green, &scheme.Yellow,
+		&scheme.Blue, &scheme.Magenta, &scheme.Cyan, &scheme.LightGrey,
+		&scheme.DarkGrey, &scheme.LightRed, &scheme.LightGreen, &scheme.LightYellow,
+		&scheme.LightBlue, &scheme.LightMagenta, &scheme.LightCyan, &scheme.White,
+	}
+}
+
+// ParseTheme reads a theme file, which has the same keys as the [colours] section of the config. Colours which
+// are missing are taken from the default scheme.
+func ParseTheme(name string, data []byte) (Theme, error) {
+	theme := Theme{Name: name, Colours: DefaultConfig.ColourScheme}
+	err := toml.Unmarshal(data, &theme.Colours)
+	return theme, err
+}
+
+// Encode writes the theme in the format read by ParseTheme
+func (theme Theme) Encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(theme.Colours); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// LoadThemes returns the built-in themes along with the *.toml themes in dir, each named after its file. A user
+// theme with the same name as a built-in one replaces it. Themes which can't be read are left out, and reported in
+// the error.
+func LoadThemes(dir string) ([]Theme, error) {
+	themes := append([]Theme{}, BuiltinThemes...)
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.toml"))
+	if err != nil {
+		return themes, err
+	}
+
+	var failures []string
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		theme, err := ParseTheme(strings.TrimSuffix(filepath.Base(path), ".toml"), data)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", path, err))
+			continue
+		}
+		themes = addTheme(themes, theme)
+	}
+
+	if len(failures) > 0 {
+		return themes, fmt.Errorf("Invalid themes: %s", strings.Join(failures, "; "))
+	}
+	return themes, nil
+}
+
+func addTheme(themes []Theme, theme Theme) []Theme {
+	for i := range themes {
+		if themes[i].Name == theme.Name {
+			themes[i] = theme
+			return themes
+		}
+	}
+	return append(themes, theme)
+}
+
+// ConfigThemeName is the name of the theme made from the [colours] section of the config
+const ConfigThemeName = "config"
+
+// RegisterConfigTheme makes the current colour scheme available as the config theme, first in the cycle, so that
+// switching theme can return to it. It is selected if no other theme is.
+func (c *Config) RegisterConfigTheme() {
+	themes := []Theme{{Name: ConfigThemeName, Colours: c.ColourScheme}}
+	for _, theme := range c.AvailableThemes() {
+		if theme.Name != ConfigThemeName {
+			themes = append(themes, theme)
+		}
+	}
+	c.Themes = themes
+	if c.Theme == "" {
+		c.Theme = ConfigThemeName
+	}
+}
+
+// AvailableThemes returns the themes which can be switched to; the built-in ones if none were loaded
+func (c *Config) AvailableThemes() []Theme {
+	if c.Themes == nil {
+		return BuiltinThemes
+	}
+	return c.Themes
+}
+
+// FindTheme returns the available theme with the name
+func (c *Config) FindTheme(name string) (Theme, error) {
+	for _, theme := range c.AvailableThemes() {
+		if theme.Name == name {
+			return theme, nil
+		}
+	}
+	return Theme{}, fmt.Errorf("Unknown theme: %s", name)
+}
+
+// NextTheme returns the theme after the current one, wrapping around to the first
+func (c *Config) NextTheme() Theme {
+	themes := c.AvailableThemes()
+	for i, theme := range themes {
+		if theme.Name == c.Theme {
+			return themes[(i+1)%len(themes)]
+		}
+	}
+	return themes[0]
+}
+
+// ApplyTheme replaces the colour scheme with that of the named theme
+func (c *Config) ApplyTheme(name string) error {
+	theme, err := c.FindTheme(name)
+	if err != nil {
+		return err
+	}
+	c.Theme = theme.Name
+	c.ColourScheme = theme.Colours
+	return nil
+}
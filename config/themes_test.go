@@ -0,0 +1,114 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinThemes(t *testing.T) {
+	names := map[string]bool{}
+	for _, theme := range BuiltinThemes {
+		assert.False(t, names[theme.Name], "duplicate theme %s", theme.Name)
+		names[theme.Name] = true
+		assert.NotEqual(t, theme.Colours.Foreground, theme.Colours.Background, theme.Name)
+	}
+	assert.Equal(t, DefaultConfig.ColourScheme, BuiltinThemes[0].Colours)
+
+	dracula := BuiltinThemes[5]
+	require.Equal(t, "dracula", dracula.Name)
+	assert.Equal(t, strToColourNoErr("#ff5555"), dracula.Colours.Red)
+	assert.Equal(t, strToColourNoErr("#ffffff"), dracula.Colours.White)
+}
+
+func TestParseThemeFillsInMissingColours(t *testing.T) {
+	theme, err := ParseTheme("mine", []byte(`background = "#102030"`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "mine", theme.Name)
+	assert.Equal(t, strToColourNoErr("#102030"), theme.Colours.Background)
+	assert.Equal(t, DefaultConfig.ColourScheme.Foreground, theme.Colours.Foreground)
+}
+
+func TestThemeEncodingRoundTrips(t *testing.T) {
+	nord, err := DefaultConfig.FindTheme("nord")
+	require.NoError(t, err)
+
+	data, err := nord.Encode()
+	require.NoError(t, err)
+	decoded, err := ParseTheme("nord", data)
+	require.NoError(t, err)
+	assert.Equal(t, nord, decoded)
+}
+
+func TestLoadThemes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aminal-themes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "nord.toml"), []byte(`background = "#000000"`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "zebra.toml"), []byte(`foreground = "#ffffff"`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "broken.toml"), []byte(`foreground = "white"`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte(`not a theme`), 0644))
+
+	themes, err := LoadThemes(dir)
+	assert.Error(t, err)
+	require.Len(t, themes, len(BuiltinThemes)+1)
+
+	conf := DefaultConfig
+	conf.Themes = themes
+	nord, err := conf.FindTheme("nord")
+	require.NoError(t, err)
+	assert.Equal(t, strToColourNoErr("#000000"), nord.Colours.Background)
+	assert.Equal(t, "zebra", themes[len(themes)-1].Name)
+
+	_, err = conf.FindTheme("broken")
+	assert.Error(t, err)
+}
+
+func TestLoadThemesWithoutDirectory(t *testing.T) {
+	themes, err := LoadThemes(filepath.Join(os.TempDir(), "aminal-themes-which-do-not-exist"))
+	require.NoError(t, err)
+	assert.Equal(t, BuiltinThemes, themes)
+}
+
+func TestNextThemeWrapsAround(t *testing.T) {
+	conf := DefaultConfig
+	assert.Equal(t, "default", conf.NextTheme().Name)
+
+	conf.Theme = "default"
+	assert.Equal(t, "solarized-dark", conf.NextTheme().Name)
+
+	conf.Theme = BuiltinThemes[len(BuiltinThemes)-1].Name
+	assert.Equal(t, "default", conf.NextTheme().Name)
+}
+
+func TestNextThemeReturnsToTheConfiguredColours(t *testing.T) {
+	conf := DefaultConfig
+	conf.ColourScheme.Background = strToColourNoErr("#123456")
+	conf.RegisterConfigTheme()
+	assert.Equal(t, ConfigThemeName, conf.Theme)
+	require.Len(t, conf.AvailableThemes(), len(BuiltinThemes)+1)
+
+	for range BuiltinThemes {
+		require.NoError(t, conf.ApplyTheme(conf.NextTheme().Name))
+		assert.NotEqual(t, ConfigThemeName, conf.Theme)
+	}
+	require.NoError(t, conf.ApplyTheme(conf.NextTheme().Name))
+	assert.Equal(t, ConfigThemeName, conf.Theme)
+	assert.Equal(t, strToColourNoErr("#123456"), conf.ColourScheme.Background)
+}
+
+func TestApplyTheme(t *testing.T) {
+	conf := DefaultConfig
+	require.NoError(t, conf.ApplyTheme("gruvbox-dark"))
+	assert.Equal(t, "gruvbox-dark", conf.Theme)
+	assert.Equal(t, strToColourNoErr("#282828"), conf.ColourScheme.Background)
+
+	assert.Error(t, conf.ApplyTheme("no-such-theme"))
+	assert.Equal(t, "gruvbox-dark", conf.Theme)
+}
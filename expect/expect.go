@@ -0,0 +1,278 @@
+// Package expect scripts interactive programs running under a terminal. A command is spawned on a real pty,
+// its output is fed into a headless terminal, and the resulting screen can be waited on and asserted against.
+package expect
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/platform"
+	"github.com/liamg/aminal/terminal"
+)
+
+// Session is an interactive program running under a headless terminal. A session is not safe for concurrent
+// use: output is only processed while one of its methods is waiting for it.
+type Session struct {
+	screen  *terminal.Headless
+	pty     platform.Pty
+	process platform.Process
+	output  chan []byte
+	done    chan struct{} // closed once the program has exited
+	waitErr error
+	exited  bool // set once the output of an exited program has been drained
+}
+
+// Spawn runs a command on a new pty of the given size
+func Spawn(cols uint, rows uint, command string, args ...string) (*Session, error) {
+	pty, err := platform.NewPty(int(cols), int(rows))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to allocate pty: %s", err)
+	}
+	if err := pty.Resize(int(cols), int(rows)); err != nil {
+		pty.Close()
+		return nil, fmt.Errorf("Failed to resize pty: %s", err)
+	}
+
+	process, err := pty.CreateGuestProcess(command, args...)
+	if err != nil {
+		pty.Close()
+		return nil, fmt.Errorf("Failed to start %s: %s", command, err)
+	}
+
+	session := &Session{
+		screen:  terminal.NewHeadless(cols, rows, terminal.HeadlessOptions{}),
+		pty:     pty,
+		process: process,
+		output:  make(chan []byte, 64),
+		done:    make(chan struct{}),
+	}
+	go session.read()
+	go func() {
+		session.waitErr = process.Wait()
+		close(session.done)
+	}()
+	return session, nil
+}
+
+// read forwards output from the pty until the program exits or the pty is closed
+func (session *Session) read() {
+	defer close(session.output)
+	for {
+		data := make([]byte, 4096)
+		n, err := session.pty.Read(data)
+		if n > 0 {
+			session.output <- data[:n]
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// feed processes a chunk of output, answering any queries it contains
+func (session *Session) feed(data []byte) {
+	session.screen.Feed(data)
+	if replies := session.screen.Replies(); len(replies) > 0 {
+		session.pty.Write(replies)
+	}
+}
+
+// pump processes all output which is ready without blocking
+func (session *Session) pump() {
+	for {
+		select {
+		case data, ok := <-session.output:
+			if !ok {
+				session.exited = true
+				return
+			}
+			session.feed(data)
+		default:
+			return
+		}
+	}
+}
+
+// wait processes output until check returns true, the timeout elapses or the program exits
+func (session *Session) wait(timeout time.Duration, check func() bool) error {
+	session.pump()
+	if check() {
+		return nil
+	}
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for !session.exited {
+		select {
+		case data, ok := <-session.output:
+			if !ok {
+				session.exited = true
+				break
+			}
+			session.feed(data)
+			if check() {
+				return nil
+			}
+		case <-deadline.C:
+			return fmt.Errorf("Timed out after %s", timeout)
+		}
+	}
+	return fmt.Errorf("Program exited")
+}
+
+// Screen gives access to the terminal the program is running under
+func (session *Session) Screen() *terminal.Headless {
+	session.pump()
+	return session.screen
+}
+
+// Send writes text to the program as if it had been typed
+func (session *Session) Send(text string) error {
+	_, err := session.pty.Write([]byte(text))
+	return err
+}
+
+// SendKeys presses each of the named keys in turn, encoded the same way as the GUI would send them,
+// e.g. "ctrl+c", "alt+x", "shift+up", "enter", "f5" or a single character
+func (session *Session) SendKeys(names ...string) error {
+	session.pump()
+	for _, name := range names {
+		sequence, err := session.encodeKey(name)
+		if err != nil {
+			return err
+		}
+		if _, err := session.pty.Write(sequence); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Expect waits until the regular expression matches the text on the screen, returning the match and its submatches
+func (session *Session) Expect(pattern string, timeout time.Duration) ([]string, error) {
+	return session.expect(pattern, timeout, session.screen.Text)
+}
+
+// ExpectRegion waits until the regular expression matches the text in an area of the screen
+func (session *Session) ExpectRegion(pattern string, area buffer.Rectangle, timeout time.Duration) ([]string, error) {
+	return session.expect(pattern, timeout, func() string {
+		return session.regionText(area)
+	})
+}
+
+func (session *Session) expect(pattern string, timeout time.Duration, text func() string) ([]string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid pattern %q: %s", pattern, err)
+	}
+
+	var match []string
+	err = session.wait(timeout, func() bool {
+		match = re.FindStringSubmatch(text())
+		return match != nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s waiting for %q, screen was:\n%s", err, pattern, session.screen.Text())
+	}
+	return match, nil
+}
+
+// regionText returns the text in an area of the screen, with rows separated by newlines
+func (session *Session) regionText(area buffer.Rectangle) string {
+	rows := []string{}
+	for row := area.Top; row <= area.Bottom; row++ {
+		runes := []rune{}
+		for col := area.Left; col <= area.Right; col++ {
+			r := session.screen.Cell(col, row).Rune()
+			if r == 0 {
+				r = ' '
+			}
+			runes = append(runes, r)
+		}
+		rows = append(rows, strings.TrimRight(string(runes), " "))
+	}
+	return strings.Join(rows, "\n")
+}
+
+// AssertCursor returns an error if the cursor is not at the given zero-based screen position
+func (session *Session) AssertCursor(col uint16, row uint16) error {
+	session.pump()
+	actualCol, actualRow := session.screen.Cursor()
+	if actualCol != col || actualRow != row {
+		return fmt.Errorf("Expected cursor at %d,%d but it was at %d,%d", col, row, actualCol, actualRow)
+	}
+	return nil
+}
+
+// Style is a set of attributes to check a cell against. Colours are only checked when they are set.
+type Style struct {
+	Bold      bool
+	Dim       bool
+	Italic    bool
+	Underline bool
+	Blink     bool
+	Inverse   bool
+	Hidden    bool
+	Fg        *[3]float32
+	Bg        *[3]float32
+}
+
+// AssertStyle returns an error if the cell at the given zero-based screen position does not have the style
+func (session *Session) AssertStyle(col uint16, row uint16, style Style) error {
+	session.pump()
+	cell := session.screen.Cell(col, row)
+	attr := cell.Attr()
+	fg, bg := cell.Colours()
+	actual := Style{
+		Bold:      attr.Bold,
+		Dim:       attr.Dim,
+		Italic:    attr.Italic,
+		Underline: attr.Underline,
+		Blink:     attr.Blink,
+		Inverse:   attr.Inverse,
+		Hidden:    attr.Hidden,
+	}
+	if style.Fg != nil {
+		if *style.Fg != fg {
+			return fmt.Errorf("Expected foreground %v at %d,%d but it was %v", *style.Fg, col, row, fg)
+		}
+	}
+	if style.Bg != nil {
+		if *style.Bg != bg {
+			return fmt.Errorf("Expected background %v at %d,%d but it was %v", *style.Bg, col, row, bg)
+		}
+	}
+	actual.Fg, actual.Bg = style.Fg, style.Bg // already checked
+	if actual != style {
+		return fmt.Errorf("Expected style %+v at %d,%d but it was %+v", style, col, row, actual)
+	}
+	return nil
+}
+
+// Wait waits for the program to exit, processing the rest of its output
+func (session *Session) Wait(timeout time.Duration) error {
+	if err := session.wait(timeout, func() bool { return false }); !session.exited {
+		return err
+	}
+	<-session.done
+	return session.waitErr
+}
+
+// Close kills the program if it is still running and releases the pty
+func (session *Session) Close() error {
+	select {
+	case <-session.done:
+	default:
+		session.process.Close()
+		<-session.done
+	}
+	err := session.pty.Close()
+	for range session.output {
+	}
+	session.screen.Close()
+	return err
+}
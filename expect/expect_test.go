@@ -0,0 +1,95 @@
+package expect
+
+import (
+	"testing"
+	"time"
+
+	"github.com/liamg/aminal/buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const timeout = 5 * time.Second
+
+func TestExpectOutput(t *testing.T) {
+	session, err := Spawn(40, 10, "sh", "-c", `printf 'hello \033[1mworld\033[m\nvalue=42'`)
+	require.NoError(t, err)
+	defer session.Close()
+
+	match, err := session.Expect(`value=(\d+)`, timeout)
+	require.NoError(t, err)
+	assert.Equal(t, "42", match[1])
+
+	assert.NoError(t, session.AssertCursor(8, 1))
+	assert.Error(t, session.AssertCursor(0, 0))
+	assert.NoError(t, session.AssertStyle(6, 0, Style{Bold: true}))
+	assert.Error(t, session.AssertStyle(0, 0, Style{Bold: true}))
+
+	_, err = session.ExpectRegion(`^world$`, buffer.Rectangle{Top: 0, Left: 6, Bottom: 0, Right: 10}, timeout)
+	assert.NoError(t, err)
+
+	assert.NoError(t, session.Wait(timeout))
+}
+
+func TestExpectAfterExit(t *testing.T) {
+	session, err := Spawn(40, 10, "sh", "-c", "printf done")
+	require.NoError(t, err)
+	defer session.Close()
+
+	_, err = session.Expect("never", timeout)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Program exited")
+	assert.Contains(t, err.Error(), "done")
+}
+
+func TestExpectTimeout(t *testing.T) {
+	session, err := Spawn(40, 10, "cat")
+	require.NoError(t, err)
+	defer session.Close()
+
+	_, err = session.Expect("never", 100*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Timed out")
+}
+
+func TestSendKeys(t *testing.T) {
+	session, err := Spawn(40, 10, "cat")
+	require.NoError(t, err)
+	defer session.Close()
+
+	require.NoError(t, session.Send("abc"))
+	require.NoError(t, session.SendKeys("enter"))
+	_, err = session.Expect(`abc\nabc`, timeout)
+	require.NoError(t, err)
+
+	require.NoError(t, session.SendKeys("ctrl+d"))
+	assert.NoError(t, session.Wait(timeout))
+}
+
+func TestEncodeKey(t *testing.T) {
+	session, err := Spawn(40, 10, "cat")
+	require.NoError(t, err)
+	defer session.Close()
+
+	tests := map[string]string{
+		"ctrl+c":   "\x03",
+		"alt+x":    "\x1bx",
+		"shift+up": "\x1b[1;2A",
+		"Escape":   "\x1b",
+		"f5":       "\x1b[15~",
+		"q":        "q",
+		"+":        "+",
+	}
+	for name, expected := range tests {
+		sequence, err := session.encodeKey(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, expected, string(sequence), name)
+	}
+
+	_, err = session.encodeKey("hyper+a")
+	assert.Error(t, err)
+	_, err = session.encodeKey("nokey")
+	assert.Error(t, err)
+	_, err = session.encodeKey("ctrl+1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,85 @@
+package expect
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/liamg/aminal/terminal"
+)
+
+var keyNames = map[string]terminal.Key{
+	"f1":        terminal.KeyF1,
+	"f2":        terminal.KeyF2,
+	"f3":        terminal.KeyF3,
+	"f4":        terminal.KeyF4,
+	"f5":        terminal.KeyF5,
+	"f6":        terminal.KeyF6,
+	"f7":        terminal.KeyF7,
+	"f8":        terminal.KeyF8,
+	"f9":        terminal.KeyF9,
+	"f10":       terminal.KeyF10,
+	"f11":       terminal.KeyF11,
+	"f12":       terminal.KeyF12,
+	"insert":    terminal.KeyInsert,
+	"delete":    terminal.KeyDelete,
+	"home":      terminal.KeyHome,
+	"end":       terminal.KeyEnd,
+	"pageup":    terminal.KeyPageUp,
+	"pagedown":  terminal.KeyPageDown,
+	"esc":       terminal.KeyEscape,
+	"escape":    terminal.KeyEscape,
+	"tab":       terminal.KeyTab,
+	"enter":     terminal.KeyEnter,
+	"return":    terminal.KeyEnter,
+	"kpenter":   terminal.KeyKPEnter,
+	"backspace": terminal.KeyBackspace,
+	"up":        terminal.KeyUp,
+	"down":      terminal.KeyDown,
+	"left":      terminal.KeyLeft,
+	"right":     terminal.KeyRight,
+}
+
+var modifierNames = map[string]terminal.Modifier{
+	"shift": terminal.ModShift,
+	"ctrl":  terminal.ModControl,
+	"alt":   terminal.ModAlt,
+	"super": terminal.ModSuper,
+}
+
+// encodeKey returns the bytes for a key name such as "ctrl+c" or "shift+up", using the terminal's current modes
+func (session *Session) encodeKey(name string) ([]byte, error) {
+	parts := strings.Split(name, "+")
+	keyName := parts[len(parts)-1]
+	if keyName == "" && len(parts) > 1 {
+		// "ctrl++" presses the plus key
+		keyName = "+"
+		parts = parts[:len(parts)-1]
+	}
+
+	var mods terminal.Modifier
+	for _, modName := range parts[:len(parts)-1] {
+		mod, ok := modifierNames[strings.ToLower(modName)]
+		if !ok {
+			return nil, fmt.Errorf("Unknown modifier %q in key %q", modName, name)
+		}
+		mods |= mod
+	}
+
+	if utf8.RuneCountInString(keyName) == 1 {
+		r, _ := utf8.DecodeRuneInString(keyName)
+		if sequence := terminal.CharSequence(r, mods); sequence != nil {
+			return sequence, nil
+		}
+		if mods&^terminal.ModShift != 0 {
+			return nil, fmt.Errorf("Key %q has no encoding", name)
+		}
+		return []byte(keyName), nil
+	}
+
+	key, ok := keyNames[strings.ToLower(keyName)]
+	if !ok {
+		return nil, fmt.Errorf("Unknown key %q", name)
+	}
+	return session.screen.KeySequence(key, mods), nil
+}
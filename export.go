@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/export"
+	"github.com/liamg/aminal/terminal"
+	"go.uber.org/zap"
+)
+
+// exportOutput runs output captured from a program through a headless terminal, and exports the result
+// without opening a window
+func exportOutput(conf *config.Config, logger *zap.SugaredLogger, args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	formatName := flags.String("format", "", "Output format: html, ansi or svg (default from the output file name, or html)")
+	cols := flags.Uint("cols", 80, "Width of the terminal")
+	rows := flags.Uint("rows", 24, "Height of the terminal")
+	output := flags.String("o", "", "File to write to (default stdout)")
+	flags.Parse(args)
+
+	if flags.NArg() > 1 || *cols == 0 || *rows == 0 {
+		fmt.Println("Usage: aminal export [--format html|ansi|svg] [--cols n] [--rows n] [-o file] [input]")
+		os.Exit(1)
+	}
+
+	format := export.HTML
+	var err error
+	if *formatName != "" {
+		format, err = export.ParseFormat(*formatName)
+	} else if *output != "" {
+		format, err = export.FormatForPath(*output)
+	}
+	if err != nil {
+		logger.Fatalf("%s", err)
+	}
+
+	input := os.Stdin
+	if flags.NArg() == 1 {
+		if input, err = os.Open(flags.Arg(0)); err != nil {
+			logger.Fatalf("Failed to open input: %s", err)
+		}
+		defer input.Close()
+	}
+	data, err := ioutil.ReadAll(input)
+	if err != nil {
+		logger.Fatalf("Failed to read input: %s", err)
+	}
+
+	// captured output usually has bare line feeds, which a pty would have turned into CR LF
+	data = bytes.Replace(data, []byte("\r\n"), []byte("\n"), -1)
+	data = bytes.Replace(data, []byte("\n"), []byte("\r\n"), -1)
+
+	palette := &terminal.Palette{
+		Foreground: conf.ColourScheme.Foreground,
+		Background: conf.ColourScheme.Background,
+		Cursor:     conf.ColourScheme.Cursor,
+		Selection:  conf.ColourScheme.Selection,
+	}
+	for i, colour := range conf.ColourScheme.ANSIColours() {
+		palette.ANSI[i] = *colour
+	}
+	headless := terminal.NewHeadless(*cols, *rows, terminal.HeadlessOptions{
+		MaxLines: uint64(bytes.Count(data, []byte("\n"))) + uint64(*rows),
+		TabWidth: conf.TabWidth,
+		Palette:  palette,
+	})
+	defer headless.Close()
+	headless.Feed(data)
+	headless.Flush()
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			logger.Fatalf("Failed to create output: %s", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if err := headless.Export(w, format); err != nil {
+		logger.Fatalf("Failed to export: %s", err)
+	}
+}
@@ -0,0 +1,81 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/liamg/aminal/config"
+)
+
+func writeANSI(w io.Writer, rows []row, scheme config.ColourScheme) error {
+	out := bufio.NewWriter(w)
+	colours := palette(scheme)
+
+	for i, r := range rows {
+		if i > 0 && !r.continuation {
+			fmt.Fprint(out, "\r\n")
+		}
+		for _, run := range r.runs {
+			if run.style.isDefault(scheme) {
+				fmt.Fprint(out, run.text)
+			} else {
+				fmt.Fprintf(out, "%s%s\x1b[0m", sgrSequence(run.style, scheme, colours), run.text)
+			}
+		}
+	}
+	if len(rows) > 0 {
+		fmt.Fprint(out, "\r\n")
+	}
+
+	return out.Flush()
+}
+
+// sgrSequence returns the SGR sequences which select a style, using true colour for colours which are not in
+// the scheme. True colours are given sequences of their own, as some terminals cannot parse anything after them.
+func sgrSequence(s style, scheme config.ColourScheme, colours []paletteColour) string {
+	params := []string{}
+	extended := []string{}
+
+	flags := []struct {
+		code    string
+		enabled bool
+	}{
+		{"1", s.bold},
+		{"2", s.dim},
+		{"3", s.italic},
+		{"4", s.underline},
+		{"5", s.blink},
+		{"8", s.hidden},
+	}
+	for _, flag := range flags {
+		if flag.enabled {
+			params = append(params, flag.code)
+		}
+	}
+
+	if s.fg != scheme.Foreground {
+		if colour := lookupPalette(colours, s.fg); colour != nil && colour.fg != 0 {
+			params = append(params, strconv.Itoa(colour.fg))
+		} else {
+			r, g, b := colourBytes(s.fg)
+			extended = append(extended, fmt.Sprintf("\x1b[38;2;%d;%d;%dm", r, g, b))
+		}
+	}
+	if s.bg != scheme.Background {
+		if colour := lookupPalette(colours, s.bg); colour != nil && colour.bg != 0 {
+			params = append(params, strconv.Itoa(colour.bg))
+		} else {
+			r, g, b := colourBytes(s.bg)
+			extended = append(extended, fmt.Sprintf("\x1b[48;2;%d;%d;%dm", r, g, b))
+		}
+	}
+
+	sequence := ""
+	if len(params) > 0 {
+		sequence = "\x1b[" + strings.Join(params, ";") + "m"
+	}
+	return sequence + strings.Join(extended, "")
+}
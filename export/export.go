@@ -0,0 +1,142 @@
+// Package export serializes the lines of a terminal buffer, with their colours and attributes, as HTML, as text
+// with ANSI escape sequences, or as SVG.
+package export
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+)
+
+// Format is an output format for an export
+type Format string
+
+const (
+	HTML Format = "html" // a standalone page, with CSS classes for the colour scheme
+	ANSI Format = "ansi" // text with escape sequences which reproduce the colours in another terminal
+	SVG  Format = "svg"  // an image of the lines as they are shown on the screen
+)
+
+// ParseFormat returns the format with the given name
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(strings.ToLower(name)); format {
+	case HTML, ANSI, SVG:
+		return format, nil
+	}
+	return "", fmt.Errorf("Unknown export format %q, expected html, ansi or svg", name)
+}
+
+// FormatForPath picks the format from the extension of a file name
+func FormatForPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		return HTML, nil
+	case ".ans", ".ansi", ".txt":
+		return ANSI, nil
+	case ".svg":
+		return SVG, nil
+	}
+	return "", fmt.Errorf("Cannot tell the export format from the name %q, expected .html, .ansi or .svg", path)
+}
+
+// Write serializes the lines in the given format, using the colour scheme for the default and palette colours
+func Write(w io.Writer, format Format, lines []buffer.Line, scheme config.ColourScheme) error {
+	rows := splitRuns(lines, scheme)
+
+	switch format {
+	case HTML:
+		return writeHTML(w, rows, scheme)
+	case ANSI:
+		return writeANSI(w, rows, scheme)
+	case SVG:
+		return writeSVG(w, rows, scheme)
+	}
+	return fmt.Errorf("Unknown export format %q", format)
+}
+
+// style is the appearance of a run of cells, with inverse video already applied to the colours
+type style struct {
+	fg        config.Colour
+	bg        config.Colour
+	bold      bool
+	dim       bool
+	italic    bool
+	underline bool
+	blink     bool
+	hidden    bool
+}
+
+// run is a sequence of characters on a row which share a style
+type run struct {
+	text  string
+	cols  int
+	style style
+}
+
+// row is a line of the buffer, split into runs
+type row struct {
+	runs         []run
+	continuation bool // text wrapped onto the row from the previous one
+}
+
+// splitRuns groups the cells on each line by style. Blank cells with the default background at the end of a
+// line are dropped, unless the text wraps onto the next line, as are blank lines at the end.
+func splitRuns(lines []buffer.Line, scheme config.ColourScheme) []row {
+	rows := []row{}
+	lastContent := 0
+
+	for i := range lines {
+		cells := lines[i].Cells()
+
+		end := len(cells)
+		wrapped := i+1 < len(lines) && lines[i+1].IsContinuation()
+		for end > 0 && !wrapped {
+			last := cells[end-1]
+			if last.Rune() != 0 && last.Rune() != ' ' || config.Colour(last.Bg()) != scheme.Background {
+				break
+			}
+			end--
+		}
+
+		r := row{continuation: lines[i].IsContinuation()}
+		for _, cell := range cells[:end] {
+			attr := cell.Attr()
+			s := style{
+				fg:        cell.Fg(),
+				bg:        cell.Bg(),
+				bold:      attr.Bold,
+				dim:       attr.Dim,
+				italic:    attr.Italic,
+				underline: attr.Underline,
+				blink:     attr.Blink,
+				hidden:    attr.Hidden,
+			}
+			ch := cell.Rune()
+			if ch == 0 {
+				ch = ' '
+			}
+			if n := len(r.runs); n > 0 && r.runs[n-1].style == s {
+				r.runs[n-1].text += string(ch)
+				r.runs[n-1].cols++
+			} else {
+				r.runs = append(r.runs, run{text: string(ch), cols: 1, style: s})
+			}
+		}
+
+		rows = append(rows, r)
+		if len(r.runs) > 0 {
+			lastContent = len(rows)
+		}
+	}
+
+	return rows[:lastContent]
+}
+
+// isDefault returns true if the style needs no markup
+func (s style) isDefault(scheme config.ColourScheme) bool {
+	return s == style{fg: scheme.Foreground, bg: scheme.Background}
+}
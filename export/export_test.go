@@ -0,0 +1,121 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeLines writes "plain red", with red in bold red, and "wrapped" with the default colours
+func makeLines(scheme config.ColourScheme) []buffer.Line {
+	defaults := buffer.CellAttributes{FgColour: buffer.ColourFromFloats(scheme.Foreground), BgColour: buffer.ColourFromFloats(scheme.Background)}
+	b := buffer.NewBuffer(buffer.NewTerminalState(12, 4, defaults, 10))
+	b.Write([]rune("plain ")...)
+	b.CursorAttr().FgColour = buffer.ColourFromFloats(scheme.Red)
+	b.CursorAttr().Bold = true
+	b.Write([]rune("<red>")...)
+	*b.CursorAttr() = defaults
+	b.CarriageReturn()
+	b.NewLine()
+	b.Write([]rune("last")...)
+	return b.AllLines()
+}
+
+func export(t *testing.T, format Format) string {
+	scheme := config.DefaultConfig.ColourScheme
+	out := &bytes.Buffer{}
+	require.NoError(t, Write(out, format, makeLines(scheme), scheme))
+	return out.String()
+}
+
+func TestFormatForPath(t *testing.T) {
+	for path, expected := range map[string]Format{
+		"a.html": HTML,
+		"b.HTM":  HTML,
+		"c.ansi": ANSI,
+		"d.txt":  ANSI,
+		"e.svg":  SVG,
+	} {
+		format, err := FormatForPath(path)
+		require.NoError(t, err)
+		assert.Equal(t, expected, format, path)
+	}
+
+	_, err := FormatForPath("f.png")
+	assert.Error(t, err)
+}
+
+func TestExportHTML(t *testing.T) {
+	out := export(t, HTML)
+
+	assert.Contains(t, out, ".aminal .fg-red { color: #800000; }")
+	assert.Contains(t, out, `<pre class="aminal">plain <span class="fg-red bold">&lt;red&gt;</span>`+"\nlast</pre>")
+}
+
+func TestPaletteFollowsTheSGRCodes(t *testing.T) {
+	scheme := config.DefaultConfig.ColourScheme
+	colours := palette(scheme)
+	require.Len(t, colours, 16)
+	assert.Equal(t, paletteColour{"light-grey", scheme.LightGrey, 37, 47}, colours[7])
+	assert.Equal(t, paletteColour{"dark-grey", scheme.DarkGrey, 90, 100}, colours[8])
+	assert.Equal(t, paletteColour{"white", scheme.White, 97, 107}, colours[15])
+}
+
+func TestExportHTMLJoinsWrappedLines(t *testing.T) {
+	scheme := config.DefaultConfig.ColourScheme
+	defaults := buffer.CellAttributes{FgColour: buffer.ColourFromFloats(scheme.Foreground), BgColour: buffer.ColourFromFloats(scheme.Background)}
+	b := buffer.NewBuffer(buffer.NewTerminalState(5, 4, defaults, 10))
+	b.Write([]rune("wrapped text")...)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, Write(out, HTML, b.AllLines(), scheme))
+	assert.Contains(t, out.String(), `<pre class="aminal">wrapped text</pre>`)
+}
+
+func TestExportKeepsSpacesAtTheEndOfWrappedLines(t *testing.T) {
+	scheme := config.DefaultConfig.ColourScheme
+	defaults := buffer.CellAttributes{FgColour: buffer.ColourFromFloats(scheme.Foreground), BgColour: buffer.ColourFromFloats(scheme.Background)}
+	b := buffer.NewBuffer(buffer.NewTerminalState(5, 4, defaults, 10))
+	b.Write([]rune("aaaa bbbb")...)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, Write(out, HTML, b.AllLines(), scheme))
+	assert.Contains(t, out.String(), `<pre class="aminal">aaaa bbbb</pre>`)
+
+	out.Reset()
+	require.NoError(t, Write(out, ANSI, b.AllLines(), scheme))
+	assert.Equal(t, "aaaa bbbb\r\n", out.String())
+}
+
+func TestExportANSI(t *testing.T) {
+	assert.Equal(t, "plain \x1b[1;31m<red>\x1b[0m\r\nlast\r\n", export(t, ANSI))
+}
+
+func TestExportSVG(t *testing.T) {
+	out := export(t, SVG)
+
+	assert.True(t, strings.HasPrefix(out, `<?xml version="1.0" encoding="UTF-8"?>`))
+	assert.Contains(t, out, `width="92.4" height="34" viewBox="0 0 92.4 34"`)
+	assert.Contains(t, out, `<text x="50.4" y="13" textLength="42" fill="#800000" font-weight="bold">&lt;red&gt;</text>`)
+	assert.Contains(t, out, `<text x="0" y="30" textLength="33.6" fill="#e8dfd6">last</text>`)
+}
+
+func TestWriteHTMLFragment(t *testing.T) {
+	scheme := config.DefaultConfig.ColourScheme
+	defaults := buffer.CellAttributes{FgColour: buffer.ColourFromFloats(scheme.Foreground), BgColour: buffer.ColourFromFloats(scheme.Background)}
+	b := buffer.NewBuffer(buffer.NewTerminalState(20, 4, defaults, 10))
+	b.Write([]rune("a ")...)
+	b.CursorAttr().Italic = true
+	b.CursorAttr().BgColour = buffer.ColourFromFloats(scheme.Blue)
+	b.Write([]rune("b")...)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, WriteHTMLFragment(out, b.AllLines(), scheme))
+	assert.Equal(t, `<pre style="background: #021b21; color: #e8dfd6; font-family: monospace;">`+
+		`a <span style="background: #000080; font-style: italic">b</span></pre>`, out.String())
+}
@@ -0,0 +1,159 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+)
+
+func writeHTML(w io.Writer, rows []row, scheme config.ColourScheme) error {
+	out := bufio.NewWriter(w)
+	colours := palette(scheme)
+
+	fmt.Fprintln(out, "<!DOCTYPE html>")
+	fmt.Fprintln(out, `<html><head><meta charset="utf-8"><title>Aminal export</title><style>`)
+	fmt.Fprintf(out, "pre.aminal { background: %s; color: %s; font-family: monospace; padding: 1em; }\n",
+		cssColour(scheme.Background), cssColour(scheme.Foreground))
+	for _, colour := range colours {
+		fmt.Fprintf(out, ".aminal .fg-%s { color: %s; }\n", colour.name, cssColour(colour.colour))
+		fmt.Fprintf(out, ".aminal .bg-%s { background: %s; }\n", colour.name, cssColour(colour.colour))
+	}
+	// inverse video swaps the default colours
+	fmt.Fprintf(out, ".aminal .fg-background { color: %s; }\n", cssColour(scheme.Background))
+	fmt.Fprintf(out, ".aminal .bg-foreground { background: %s; }\n", cssColour(scheme.Foreground))
+	fmt.Fprintln(out, ".aminal .bold { font-weight: bold; }")
+	fmt.Fprintln(out, ".aminal .dim { opacity: 0.5; }")
+	fmt.Fprintln(out, ".aminal .italic { font-style: italic; }")
+	fmt.Fprintln(out, ".aminal .underline { text-decoration: underline; }")
+	fmt.Fprintln(out, ".aminal .blink { text-decoration: blink; }")
+	fmt.Fprintln(out, ".aminal .hidden { visibility: hidden; }")
+	fmt.Fprint(out, `</style></head><body><pre class="aminal">`)
+
+	for i, r := range rows {
+		if i > 0 && !r.continuation {
+			fmt.Fprint(out, "\n")
+		}
+		for _, run := range r.runs {
+			text := html.EscapeString(run.text)
+			if run.style.isDefault(scheme) {
+				fmt.Fprint(out, text)
+				continue
+			}
+			classes, inline := htmlStyle(run.style, scheme, colours)
+			fmt.Fprint(out, "<span")
+			if len(classes) > 0 {
+				fmt.Fprintf(out, ` class="%s"`, strings.Join(classes, " "))
+			}
+			if inline != "" {
+				fmt.Fprintf(out, ` style="%s"`, inline)
+			}
+			fmt.Fprintf(out, ">%s</span>", text)
+		}
+	}
+
+	fmt.Fprintln(out, "</pre></body></html>")
+	return out.Flush()
+}
+
+// htmlStyle returns the classes for a style, and inline CSS for colours which are not in the scheme
+func htmlStyle(s style, scheme config.ColourScheme, colours []paletteColour) ([]string, string) {
+	classes := []string{}
+	inline := []string{}
+
+	if s.fg != scheme.Foreground {
+		if colour := lookupPalette(colours, s.fg); colour != nil {
+			classes = append(classes, "fg-"+colour.name)
+		} else if s.fg == scheme.Background {
+			classes = append(classes, "fg-background")
+		} else {
+			inline = append(inline, "color: "+cssColour(s.fg))
+		}
+	}
+	if s.bg != scheme.Background {
+		if colour := lookupPalette(colours, s.bg); colour != nil {
+			classes = append(classes, "bg-"+colour.name)
+		} else if s.bg == scheme.Foreground {
+			classes = append(classes, "bg-foreground")
+		} else {
+			inline = append(inline, "background: "+cssColour(s.bg))
+		}
+	}
+
+	flags := []struct {
+		name    string
+		enabled bool
+	}{
+		{"bold", s.bold},
+		{"dim", s.dim},
+		{"italic", s.italic},
+		{"underline", s.underline},
+		{"blink", s.blink},
+		{"hidden", s.hidden},
+	}
+	for _, flag := range flags {
+		if flag.enabled {
+			classes = append(classes, flag.name)
+		}
+	}
+
+	return classes, strings.Join(inline, "; ")
+}
+
+// WriteHTMLFragment serializes the lines as a single pre element with inline styles, for pasting into documents
+// and chat programs which drop style sheets
+func WriteHTMLFragment(w io.Writer, lines []buffer.Line, scheme config.ColourScheme) error {
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintf(out, `<pre style="background: %s; color: %s; font-family: monospace;">`,
+		cssColour(scheme.Background), cssColour(scheme.Foreground))
+	for i, r := range splitRuns(lines, scheme) {
+		if i > 0 && !r.continuation {
+			fmt.Fprint(out, "\n")
+		}
+		for _, run := range r.runs {
+			text := html.EscapeString(run.text)
+			if run.style.isDefault(scheme) {
+				fmt.Fprint(out, text)
+			} else {
+				fmt.Fprintf(out, `<span style="%s">%s</span>`, inlineStyle(run.style, scheme), text)
+			}
+		}
+	}
+	fmt.Fprint(out, "</pre>")
+
+	return out.Flush()
+}
+
+// inlineStyle returns the CSS for a style
+func inlineStyle(s style, scheme config.ColourScheme) string {
+	rules := []string{}
+	if s.fg != scheme.Foreground {
+		rules = append(rules, "color: "+cssColour(s.fg))
+	}
+	if s.bg != scheme.Background {
+		rules = append(rules, "background: "+cssColour(s.bg))
+	}
+
+	flags := []struct {
+		rule    string
+		enabled bool
+	}{
+		{"font-weight: bold", s.bold},
+		{"opacity: 0.5", s.dim},
+		{"font-style: italic", s.italic},
+		{"text-decoration: underline", s.underline},
+		{"visibility: hidden", s.hidden},
+	}
+	for _, flag := range flags {
+		if flag.enabled {
+			rules = append(rules, flag.rule)
+		}
+	}
+
+	return strings.Join(rules, "; ")
+}
@@ -0,0 +1,56 @@
+package export
+
+import (
+	"fmt"
+
+	"github.com/liamg/aminal/config"
+)
+
+// paletteColour is a colour from the scheme, with the name used for its CSS classes and its SGR codes
+type paletteColour struct {
+	name   string
+	colour config.Colour
+	fg     int // SGR code to select it as the foreground, or 0 if there is none
+	bg     int
+}
+
+// paletteNames are the names of the 16 ANSI colours, in the order of their SGR codes
+var paletteNames = [16]string{
+	"black", "red", "green", "yellow", "blue", "magenta", "cyan", "light-grey",
+	"dark-grey", "light-red", "light-green", "light-yellow", "light-blue", "light-magenta", "light-cyan", "white",
+}
+
+// palette lists the colours of the scheme in the order they are matched, so that a colour which appears twice
+// in a scheme is given the name and code which SGR would select first
+func palette(scheme config.ColourScheme) []paletteColour {
+	colours := []paletteColour{}
+	for i, colour := range scheme.ANSIColours() {
+		fg, bg := 30+i, 40+i
+		if i >= 8 {
+			fg, bg = 90+i-8, 100+i-8
+		}
+		colours = append(colours, paletteColour{paletteNames[i], *colour, fg, bg})
+	}
+	return colours
+}
+
+// lookupPalette finds the palette entry for a colour, returning nil if it is not in the scheme
+func lookupPalette(colours []paletteColour, colour config.Colour) *paletteColour {
+	for i := range colours {
+		if colours[i].colour == colour {
+			return &colours[i]
+		}
+	}
+	return nil
+}
+
+// colourBytes converts a colour to 8-bit components
+func colourBytes(colour config.Colour) (r uint8, g uint8, b uint8) {
+	return uint8(colour[0]*255 + 0.5), uint8(colour[1]*255 + 0.5), uint8(colour[2]*255 + 0.5)
+}
+
+// cssColour formats a colour as a CSS hex colour
+func cssColour(colour config.Colour) string {
+	r, g, b := colourBytes(colour)
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
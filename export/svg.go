@@ -0,0 +1,86 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/liamg/aminal/config"
+)
+
+// the size of a cell in the image, for a 14px monospace font
+const (
+	svgFontSize   = 14
+	svgCellWidth  = 8.4
+	svgCellHeight = 17
+)
+
+func writeSVG(w io.Writer, rows []row, scheme config.ColourScheme) error {
+	out := bufio.NewWriter(w)
+
+	cols := 0
+	for _, r := range rows {
+		width := 0
+		for _, run := range r.runs {
+			width += run.cols
+		}
+		if width > cols {
+			cols = width
+		}
+	}
+	width := svgWidth(cols)
+	height := len(rows) * svgCellHeight
+
+	fmt.Fprintln(out, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintf(out, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%d" viewBox="0 0 %g %d" `+
+		`font-family="monospace" font-size="%d" xml:space="preserve">`+"\n", width, height, width, height, svgFontSize)
+	fmt.Fprintf(out, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", cssColour(scheme.Background))
+
+	for y, r := range rows {
+		col := 0
+		top := y * svgCellHeight
+		for _, run := range r.runs {
+			x := svgWidth(col)
+			if run.style.bg != scheme.Background {
+				fmt.Fprintf(out, `<rect x="%g" y="%d" width="%g" height="%d" fill="%s"/>`+"\n",
+					x, top, svgWidth(run.cols), svgCellHeight, cssColour(run.style.bg))
+			}
+			if !run.style.hidden && strings.TrimSpace(run.text) != "" {
+				// textLength keeps the text on the grid whatever the width of the font which is used
+				fmt.Fprintf(out, `<text x="%g" y="%d" textLength="%g" fill="%s"%s>%s</text>`+"\n",
+					x, top+svgCellHeight-4, svgWidth(run.cols), cssColour(run.style.fg),
+					svgAttributes(run.style), html.EscapeString(run.text))
+			}
+			col += run.cols
+		}
+	}
+
+	fmt.Fprintln(out, "</svg>")
+	return out.Flush()
+}
+
+// svgWidth returns the width of a number of cells, rounded so that it is written without float noise
+func svgWidth(cols int) float64 {
+	return math.Round(float64(cols)*svgCellWidth*10) / 10
+}
+
+// svgAttributes returns the presentation attributes for the character attributes of a style
+func svgAttributes(s style) string {
+	attrs := ""
+	if s.bold {
+		attrs += ` font-weight="bold"`
+	}
+	if s.dim {
+		attrs += ` fill-opacity="0.5"`
+	}
+	if s.italic {
+		attrs += ` font-style="italic"`
+	}
+	if s.underline {
+		attrs += ` text-decoration="underline"`
+	}
+	return attrs
+}
@@ -98,6 +98,11 @@ func (f *Font) LinePadding() float32 {
 
 //Printf draws a string to the screen, takes a list of arguments like printf
 func (f *Font) Print(x, y float32, text string) error {
+	return f.PrintScaled(x, y, 1, 1, text)
+}
+
+// PrintScaled draws the text with every glyph stretched by the given factors around the baseline
+func (f *Font) PrintScaled(x, y float32, scaleX float32, scaleY float32, text string) error {
 
 	indices := []rune(text)
 
@@ -133,10 +138,10 @@ func (f *Font) Print(x, y float32, text string) error {
 		}
 
 		//calculate position and size for current rune
-		xpos := x + float32(ch.bearingH)
-		ypos := y - float32(+ch.height-ch.bearingV)
-		w := float32(ch.width)
-		h := float32(ch.height)
+		xpos := x + float32(ch.bearingH)*scaleX
+		ypos := y - float32(+ch.height-ch.bearingV)*scaleY
+		w := float32(ch.width) * scaleX
+		h := float32(ch.height) * scaleY
 
 		//set quad positions
 		var x1 = xpos
@@ -167,7 +172,7 @@ func (f *Font) Print(x, y float32, text string) error {
 
 		gl.BindBuffer(gl.ARRAY_BUFFER, 0)
 		// Now advance cursors for next glyph (note that advance is number of 1/64 pixels)
-		x += float32((ch.advance >> 6)) // Bitshift by 6 to get value in pixels (2^6 = 64 (divide amount of 1/64th pixels by 64 to get amount of pixels))
+		x += float32((ch.advance >> 6)) * scaleX // Bitshift by 6 to get value in pixels (2^6 = 64 (divide amount of 1/64th pixels by 64 to get amount of pixels))
 
 	}
 
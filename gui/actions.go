@@ -1,30 +1,79 @@
 package gui
 
 import (
+	"bytes"
 	"fmt"
 	"net/url"
+	"os/user"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/export"
+	"github.com/liamg/aminal/platform"
 )
 
 var actionMap = map[config.UserAction]func(gui *GUI){
-	config.ActionCopy:        actionCopy,
-	config.ActionPaste:       actionPaste,
-	config.ActionToggleDebug: actionToggleDebug,
-	config.ActionSearch:      actionSearchSelection,
-	config.ActionToggleSlomo: actionToggleSlomo,
-	config.ActionReportBug:   actionReportBug,
+	config.ActionCopy:            actionCopy,
+	config.ActionCopyHTML:        actionCopyHTML,
+	config.ActionCopyANSI:        actionCopyANSI,
+	config.ActionPaste:           actionPaste,
+	config.ActionToggleDebug:     actionToggleDebug,
+	config.ActionSearch:          actionSearchSelection,
+	config.ActionToggleSlomo:     actionToggleSlomo,
+	config.ActionReportBug:       actionReportBug,
+	config.ActionClearScrollback: actionClearScrollback,
+	config.ActionToggleRecording: actionToggleRecording,
+	config.ActionToggleLogging:   actionToggleLogging,
+	config.ActionExport:          actionExport,
+	config.ActionNextTheme:       actionNextTheme,
+	config.ActionSwitchTheme:     actionSwitchTheme,
 }
 
 func actionCopy(gui *GUI) {
-	selectedText := gui.terminal.ActiveBuffer().GetSelectedText()
+	selectedText := gui.terminal.ScreenBuffer().GetSelectedText()
 
 	if selectedText != "" {
 		gui.window.SetClipboardString(selectedText)
 	}
 }
 
+func actionCopyHTML(gui *GUI) {
+	lines := gui.terminal.ScreenBuffer().GetSelectedLines()
+	if len(lines) == 0 {
+		return
+	}
+
+	var html bytes.Buffer
+	if err := export.WriteHTMLFragment(&html, lines, gui.config.ColourScheme); err != nil {
+		gui.logger.Errorf("Failed to copy selection as HTML: %s", err)
+		return
+	}
+	if err := platform.SetClipboardHTML(html.String(), gui.terminal.ScreenBuffer().GetSelectedText()); err != nil {
+		gui.logger.Warnf("Failed to copy selection as HTML, copying plain text instead: %s", err)
+		actionCopy(gui)
+	}
+}
+
+func actionCopyANSI(gui *GUI) {
+	lines := gui.terminal.ScreenBuffer().GetSelectedLines()
+	if len(lines) == 0 {
+		return
+	}
+
+	var ansi bytes.Buffer
+	if err := export.Write(&ansi, export.ANSI, lines, gui.config.ColourScheme); err != nil {
+		gui.logger.Errorf("Failed to copy selection as ANSI: %s", err)
+		return
+	}
+	// use the same line endings as a plain copy
+	text := strings.TrimSuffix(strings.Replace(ansi.String(), "\r\n", "\n", -1), "\n")
+	if text != "" {
+		gui.window.SetClipboardString(text)
+	}
+}
+
 func actionPaste(gui *GUI) {
 	if s, err := gui.window.GetClipboardString(); err == nil {
 		_ = gui.terminal.Paste([]byte(s))
@@ -37,7 +86,7 @@ func actionToggleDebug(gui *GUI) {
 }
 
 func actionSearchSelection(gui *GUI) {
-	keywords := gui.terminal.ActiveBuffer().GetSelectedText()
+	keywords := gui.terminal.ScreenBuffer().GetSelectedText()
 	if keywords != "" && gui.config.SearchURL != "" && strings.Contains(gui.config.SearchURL, "$QUERY") {
 		gui.launchTarget(fmt.Sprintf(strings.Replace(gui.config.SearchURL, "$QUERY", "%s", 1), url.QueryEscape(keywords)))
 	}
@@ -45,8 +94,120 @@ func actionSearchSelection(gui *GUI) {
 
 func actionToggleSlomo(gui *GUI) {
 	gui.config.Slomo = !gui.config.Slomo
+	gui.terminal.SetSlomo(gui.config.Slomo)
 }
 
 func actionReportBug(gui *GUI) {
 	gui.launchTarget("https://github.com/liamg/aminal/issues/new/choose")
 }
+
+func actionClearScrollback(gui *GUI) {
+	gui.terminal.ClearScrollback()
+}
+
+func actionToggleRecording(gui *GUI) {
+	defer gui.terminal.SetDirty()
+
+	if gui.terminal.IsRecording() {
+		if err := gui.terminal.StopRecording(); err != nil {
+			gui.logger.Errorf("Failed to finish recording: %s", err)
+		}
+		return
+	}
+
+	dir := gui.config.RecordingDir
+	if dir == "" {
+		if usr, err := user.Current(); err == nil {
+			dir = usr.HomeDir
+		}
+	}
+
+	path := filepath.Join(dir, time.Now().Format("aminal-20060102-150405.cast"))
+	if err := gui.terminal.StartRecording(path); err != nil {
+		gui.logger.Errorf("Failed to start recording: %s", err)
+		return
+	}
+	gui.logger.Infof("Recording to %s", path)
+}
+
+func actionToggleLogging(gui *GUI) {
+	defer gui.terminal.SetDirty()
+
+	if gui.terminal.IsSessionLogging() {
+		if err := gui.terminal.StopSessionLog(); err != nil {
+			gui.logger.Errorf("Failed to finish session log: %s", err)
+		}
+		return
+	}
+
+	if err := gui.terminal.StartSessionLog(); err != nil {
+		gui.logger.Errorf("Failed to start session log: %s", err)
+	}
+}
+
+func actionExport(gui *GUI) {
+	defaultPath, err := config.ExpandPath(gui.config.ExportPath, time.Now())
+	if err != nil {
+		gui.logger.Errorf("Failed to export scrollback: %s", err)
+		return
+	}
+
+	// the file chooser runs as a separate process, so wait for it without blocking the render loop
+	go func() {
+		path, err := platform.ChooseSaveFile(defaultPath)
+		if err == platform.ErrNoFileChooser {
+			path, err = defaultPath, nil
+		}
+		if err != nil {
+			gui.logger.Errorf("Failed to choose a file to export to: %s", err)
+			return
+		}
+		if path != "" {
+			gui.exportChan <- path
+		}
+	}()
+}
+
+// exportToFile exports the scrollback. It runs on the render loop, which is where the GUI reads the buffers.
+func (gui *GUI) exportToFile(path string) {
+	if err := gui.terminal.ExportToFile(path); err != nil {
+		gui.logger.Errorf("Failed to export scrollback: %s", err)
+		return
+	}
+	gui.logger.Infof("Exported scrollback to %s", path)
+}
+
+func actionNextTheme(gui *GUI) {
+	gui.applyTheme(gui.config.NextTheme())
+}
+
+func actionSwitchTheme(gui *GUI) {
+	themes := gui.config.AvailableThemes()
+	names := make([]string, len(themes))
+	for i, theme := range themes {
+		names[i] = theme.Name
+	}
+
+	// the chooser runs as a separate process, so wait for it without blocking the render loop
+	go func() {
+		name, err := platform.ChooseFromList("Theme", names)
+		if err == platform.ErrNoListChooser {
+			gui.themeChan <- gui.config.NextTheme()
+			return
+		}
+		if err != nil {
+			gui.logger.Errorf("Failed to choose a theme: %s", err)
+			return
+		}
+		if name == "" {
+			return
+		}
+
+		theme, err := gui.config.FindTheme(name)
+		if err != nil {
+			gui.logger.Errorf("Failed to switch theme: %s", err)
+			return
+		}
+		gui.themeChan <- theme
+	}()
+}
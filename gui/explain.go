@@ -37,7 +37,7 @@ func (a *annotation) render(gui *GUI) {
 					alpha = 1.0
 				}
 			}
-			gui.renderer.DrawCellText(string(cell.Rune()), uint(x), uint(y), alpha, colour, cell.Attr().Bold)
+			gui.renderer.DrawCellText(string(cell.Rune()), uint(x), uint(y), lines[y].Size(), alpha, colour, cell.Attr().Bold)
 		}
 	}
 
@@ -44,11 +44,13 @@ type GUI struct {
 	overlay           overlay
 	terminalAlpha     float32
 	showDebugInfo     bool
-	keyboardShortcuts map[config.UserAction]*config.KeyCombination
+	keyboardShortcuts map[config.UserAction]*KeyCombination
 	resizeLock        *sync.Mutex
 	handCursor        *glfw.Cursor
 	arrowCursor       *glfw.Cursor
 	defaultCell       *buffer.Cell
+	themeChan         chan config.Theme // themes chosen outside of the render loop, to be applied by it
+	exportChan        chan string       // files chosen outside of the render loop, to be exported to by it
 
 	prevLeftClickX                  uint16
 	prevLeftClickY                  uint16
@@ -141,7 +143,7 @@ func (g *GUI) calculateDpiScale() float32 {
 }
 
 func New(config *config.Config, terminal *terminal.Terminal, logger *zap.SugaredLogger) (*GUI, error) {
-	shortcuts, err := config.KeyMapping.GenerateActionMap()
+	shortcuts, err := generateActionMap(config.KeyMapping)
 	if err != nil {
 		return nil, err
 	}
@@ -218,6 +220,15 @@ func (gui *GUI) generateDefaultCell(reverse bool) {
 	)
 }
 
+// applyTheme changes the colour scheme of the window and the terminal, including the text already on the screen.
+// Can only be called on OS thread.
+func (gui *GUI) applyTheme(theme config.Theme) {
+	gui.config.Theme = theme.Name
+	gui.terminal.SetColourScheme(theme.Colours)
+	gui.generateDefaultCell(gui.terminal.IsScreenReversed())
+	gui.logger.Infof("Switched to the %s theme", theme.Name)
+}
+
 func (gui *GUI) getCursorBg(cell *buffer.Cell) (bg [3]float32) {
 	if gui.config.ColourScheme.Cursor != cell.Bg() {
 		bg = gui.config.ColourScheme.Cursor
@@ -326,6 +337,8 @@ func (gui *GUI) Render() error {
 	titleChan := make(chan bool, 1)
 	resizeChan := make(chan bool, 1)
 	reverseChan := make(chan bool, 1)
+	gui.themeChan = make(chan config.Theme, 1)
+	gui.exportChan = make(chan string, 1)
 
 	gui.renderer = NewOpenGLRenderer(gui.config, gui.fontMap, 0, 0, gui.width, gui.height, gui.colourAttr, program)
 
@@ -413,6 +426,10 @@ func (gui *GUI) Render() error {
 		case reverse := <-reverseChan:
 			gui.generateDefaultCell(reverse)
 			forceRedraw = true
+		case theme := <-gui.themeChan:
+			gui.applyTheme(theme)
+		case path := <-gui.exportChan:
+			gui.exportToFile(path)
 		default:
 			// this is more efficient than glfw.PollEvents()
 			glfw.WaitEventsTimeout(0.02) // up to 50fps on no input, otherwise higher
@@ -429,17 +446,30 @@ Buffer Size: %d lines
 `,
 					gui.terminal.GetLogicalCursorX(),
 					gui.terminal.GetLogicalCursorY(),
-					gui.terminal.ActiveBuffer().ViewWidth(),
-					gui.terminal.ActiveBuffer().ViewHeight(),
-					gui.terminal.ActiveBuffer().Height(),
+					gui.terminal.ScreenBuffer().ViewWidth(),
+					gui.terminal.ScreenBuffer().ViewHeight(),
+					gui.terminal.ScreenBuffer().Height(),
 				),
 					[3]float32{1, 1, 1},
 					[3]float32{0.8, 0, 0},
 				)
 			}
 
+			indicators := []string{}
+			if gui.terminal.IsSessionLogging() {
+				indicators = append(indicators, "LOG")
+			}
+			if gui.terminal.IsRecording() {
+				indicators = append(indicators, "REC")
+			}
+			if len(indicators) > 0 {
+				w, _ := gui.terminal.GetSize()
+				text := strings.Join(indicators, " ")
+				gui.textbox(uint16(w-len(text)-3), 0, text, [3]float32{1, 1, 1}, [3]float32{0.8, 0, 0})
+			}
+
 			if showMessage {
-				if latestVersion != "" && time.Since(startTime) < time.Second*10 && gui.terminal.ActiveBuffer().RawLine() == 0 {
+				if latestVersion != "" && time.Since(startTime) < time.Second*10 && gui.terminal.ScreenBuffer().RawLine() == 0 {
 					time.AfterFunc(time.Second, gui.terminal.SetDirty)
 					_, h := gui.terminal.GetSize()
 					var msg string
@@ -473,22 +503,23 @@ Buffer Size: %d lines
 func (gui *GUI) redraw() {
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT | gl.STENCIL_BUFFER_BIT)
 	lines := gui.terminal.GetVisibleLines()
-	lineCount := int(gui.terminal.ActiveBuffer().ViewHeight())
-	colCount := int(gui.terminal.ActiveBuffer().ViewWidth())
+	lineCount := int(gui.terminal.ScreenBuffer().ViewHeight())
+	colCount := int(gui.terminal.ScreenBuffer().ViewWidth())
 	cx := uint(gui.terminal.GetLogicalCursorX())
 	cy := uint(gui.terminal.GetLogicalCursorY()) + uint(gui.terminal.GetScrollOffset())
 	var colour *config.Colour
 	for y := 0; y < lineCount; y++ {
 		if y < len(lines) {
 			cells := lines[y].Cells()
-			for x := 0; x < colCount; x++ {
+			scale := lineScale(lines[y])
+			for x := 0; x < colCount/scale; x++ {
 
 				cursor := false
 				if gui.terminal.Modes().ShowCursor {
 					cursor = cx == uint(x) && cy == uint(y)
 				}
 
-				if gui.terminal.ActiveBuffer().InSelection(uint16(x), uint16(y)) {
+				if gui.terminal.ScreenBuffer().InSelection(uint16(x), uint16(y)) {
 					colour = &gui.config.ColourScheme.Selection
 				} else {
 					colour = nil
@@ -500,7 +531,7 @@ func (gui *GUI) redraw() {
 					if x < len(cells) {
 						cell = &cells[x]
 						if cell.Image() != nil {
-							gui.renderer.DrawCellImage(*cell, uint(x), uint(y))
+							gui.renderer.DrawCellImage(*cell, uint(x*scale), uint(y))
 							continue
 						}
 					}
@@ -510,7 +541,9 @@ func (gui *GUI) redraw() {
 						colour = &bgColour
 					}
 
-					gui.renderer.DrawCellBg(*cell, uint(x), uint(y), colour, false)
+					for i := 0; i < scale; i++ {
+						gui.renderer.DrawCellBg(*cell, uint(x*scale+i), uint(y), colour, false)
+					}
 				}
 
 			}
@@ -526,8 +559,9 @@ func (gui *GUI) redraw() {
 			col := 0
 			colour := [3]float32{0, 0, 0}
 			cells := lines[y].Cells()
+			size := lines[y].Size()
 
-			for x := 0; x < colCount; x++ {
+			for x := 0; x < colCount/lineScale(lines[y]); x++ {
 				if x < len(cells) {
 					cell := cells[x]
 
@@ -543,12 +577,26 @@ func (gui *GUI) redraw() {
 						newFg = cell.Fg()
 					}
 
+					if glyph := gui.terminal.SoftGlyph(cell.Rune()); glyph != nil {
+						if builder.Len() > 0 {
+							var alpha float32 = 1.0
+							if dim {
+								alpha = 0.5
+							}
+							gui.renderer.DrawCellText(builder.String(), uint(col), uint(y), size, alpha, colour, bold)
+							builder.Reset()
+						}
+						gui.renderer.DrawSoftGlyph(glyph, uint(x), uint(y), size, newFg)
+						col = x + 1
+						continue
+					}
+
 					if builder.Len() > 0 && (cell.Attr().Dim != dim || cell.Attr().Bold != bold || colour != newFg) {
 						var alpha float32 = 1.0
 						if dim {
 							alpha = 0.5
 						}
-						gui.renderer.DrawCellText(builder.String(), uint(col), uint(y), alpha, colour, bold)
+						gui.renderer.DrawCellText(builder.String(), uint(col), uint(y), size, alpha, colour, bold)
 						col = x
 						builder.Reset()
 					}
@@ -567,7 +615,7 @@ func (gui *GUI) redraw() {
 				if dim {
 					alpha = 0.5
 				}
-				gui.renderer.DrawCellText(builder.String(), uint(col), uint(y), alpha, colour, bold)
+				gui.renderer.DrawCellText(builder.String(), uint(col), uint(y), size, alpha, colour, bold)
 			}
 		}
 
@@ -580,13 +628,14 @@ func (gui *GUI) redraw() {
 			span := 0
 			colour := [3]float32{0, 0, 0}
 			cells := lines[y].Cells()
+			scale := lineScale(lines[y])
 
 			var x int
 
 			for x = 0; x < colCount && x < len(cells); x++ {
 				cell := cells[x]
 				if span > 0 && (!cell.Attr().Underline || colour != cell.Fg()) {
-					gui.renderer.DrawUnderline(span, uint(x-span), uint(y), colour)
+					gui.renderer.DrawUnderline(span*scale, uint((x-span)*scale), uint(y), colour)
 					span = 0
 				}
 
@@ -596,14 +645,23 @@ func (gui *GUI) redraw() {
 				}
 			}
 			if span > 0 {
-				gui.renderer.DrawUnderline(span, uint(x-span), uint(y), colour)
+				gui.renderer.DrawUnderline(span*scale, uint((x-span)*scale), uint(y), colour)
 			}
 		}
 
 	}
+	gui.renderStatusLine(uint(lineCount))
 	gui.renderOverlay()
 }
 
+// lineScale returns the number of columns taken up by each character on the line
+func lineScale(line buffer.Line) int {
+	if line.IsDoubleWidth() {
+		return 2
+	}
+	return 1
+}
+
 func (gui *GUI) createWindow() (*glfw.Window, error) {
 	if err := glfw.Init(); err != nil {
 		return nil, fmt.Errorf("failed to initialise GLFW: %s", err)
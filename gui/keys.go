@@ -1,10 +1,11 @@
-package config
+package gui
 
 import (
 	"fmt"
 	"strings"
 
 	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/liamg/aminal/config"
 )
 
 type KeyCombination struct {
@@ -47,6 +48,10 @@ func parseKeyCombination(keyStr string) (*KeyCombination, error) {
 			return nil, fmt.Errorf("Multiple non-modifier keys specified in keyboard shortcut")
 		}
 
+		if k == "" {
+			return nil, fmt.Errorf("Empty key specified in keyboard shortcut")
+		}
+
 		key = rune(k[0])
 	}
 
@@ -68,14 +73,15 @@ func (combi KeyCombination) Match(pressedMods glfw.ModifierKey, pressedChar rune
 	return pressedChar == combi.char && pressedMods == combi.mods
 }
 
-func (keyMapConfig KeyMappingConfig) GenerateActionMap() (map[UserAction]*KeyCombination, error) {
-	m := map[UserAction]*KeyCombination{}
+// generateActionMap parses the keyboard shortcuts of the key mapping config
+func generateActionMap(keyMapConfig config.KeyMappingConfig) (map[config.UserAction]*KeyCombination, error) {
+	m := map[config.UserAction]*KeyCombination{}
 	for actionStr, keyStr := range keyMapConfig {
 		combi, err := parseKeyCombination(keyStr)
 		if err != nil {
 			return nil, err
 		}
-		m[UserAction(actionStr)] = combi
+		m[config.UserAction(actionStr)] = combi
 	}
 
 	return m, nil
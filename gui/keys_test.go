@@ -1,4 +1,4 @@
-package config
+package gui
 
 import (
 	"testing"
@@ -25,3 +25,11 @@ func TestKeyCombinations(t *testing.T) {
 	assert.False(t, combi.Match(glfw.ModControl^glfw.ModAlt^glfw.ModShift, 'f'))
 
 }
+
+func TestInvalidKeyCombinations(t *testing.T) {
+	for _, keyStr := range []string{"", "ctrl", "ctrl + ", "ctrl + a + b", "a"} {
+		combi, err := parseKeyCombination(keyStr)
+		assert.Error(t, err, "Key combination %q should be rejected", keyStr)
+		assert.Nil(t, combi)
+	}
+}
@@ -46,11 +46,11 @@ func (gui *GUI) mouseMoveCallback(w *glfw.Window, px float64, py float64) {
 			ty := int(y) + 1
 			gui.emitButtonEventToTerminal(tx, ty, glfw.MouseButtonLeft, nil, gui.mouseDownModifier)
 		} else {
-			gui.terminal.ActiveBuffer().ExtendSelection(x, y, false)
+			gui.terminal.ScreenBuffer().ExtendSelection(x, y, false)
 		}
 	} else {
 
-		hint := gui.terminal.ActiveBuffer().GetHintAtPosition(x, y)
+		hint := gui.terminal.ScreenBuffer().GetHintAtPosition(x, y)
 		if hint != nil {
 			gui.setOverlay(newAnnotation(hint))
 		} else {
@@ -58,7 +58,7 @@ func (gui *GUI) mouseMoveCallback(w *glfw.Window, px float64, py float64) {
 		}
 	}
 
-	if url := gui.terminal.ActiveBuffer().GetURLAtPosition(x, y); url != "" {
+	if url := gui.terminal.ScreenBuffer().GetURLAtPosition(x, y); url != "" {
 		w.SetCursor(gui.getHandCursor())
 	} else {
 		w.SetCursor(gui.getArrowCursor())
@@ -157,7 +157,7 @@ func (gui *GUI) mouseButtonCallback(w *glfw.Window, button glfw.MouseButton, act
 		if gui.config.CopyAndPasteWithMouse && action == glfw.Press && gui.terminal.GetMouseMode() == terminal.MouseModeNone {
 			str, err := gui.window.GetClipboardString()
 			if err == nil {
-				activeBuffer := gui.terminal.ActiveBuffer()
+				activeBuffer := gui.terminal.ScreenBuffer()
 				activeBuffer.ClearSelection()
 				_ = gui.terminal.Paste([]byte(str))
 			}
@@ -256,7 +256,7 @@ func (gui *GUI) mouseButtonCallback(w *glfw.Window, button glfw.MouseButton, act
 }
 
 func (gui *GUI) handleSelectionButtonPress(x uint16, y uint16) {
-	activeBuffer := gui.terminal.ActiveBuffer()
+	activeBuffer := gui.terminal.ScreenBuffer()
 	clickCount := gui.updateLeftClickCount(x, y)
 	switch clickCount {
 	case 1:
@@ -270,7 +270,7 @@ func (gui *GUI) handleSelectionButtonPress(x uint16, y uint16) {
 }
 
 func (gui *GUI) handleSelectionButtonRelease(x uint16, y uint16) {
-	activeBuffer := gui.terminal.ActiveBuffer()
+	activeBuffer := gui.terminal.ScreenBuffer()
 	if x != gui.prevLeftClickX || y != gui.prevLeftClickY {
 		gui.mouseMovedAfterSelectionStarted = true
 	}
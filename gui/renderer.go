@@ -8,6 +8,7 @@ import (
 	"github.com/liamg/aminal/buffer"
 	"github.com/liamg/aminal/config"
 	"github.com/liamg/aminal/glfont"
+	"github.com/liamg/aminal/terminal"
 )
 
 type OpenGLRenderer struct {
@@ -241,7 +242,8 @@ func (r *OpenGLRenderer) DrawUnderline(span int, col uint, row uint, colour [3]f
 	rect.Free()
 }
 
-func (r *OpenGLRenderer) DrawCellText(text string, col uint, row uint, alpha float32, colour [3]float32, bold bool) {
+// DrawCellText draws text starting at the given column of a line, where columns are twice as wide on double size lines
+func (r *OpenGLRenderer) DrawCellText(text string, col uint, row uint, size buffer.LineSize, alpha float32, colour [3]float32, bold bool) {
 
 	var f *glfont.Font
 	if bold {
@@ -255,7 +257,79 @@ func (r *OpenGLRenderer) DrawCellText(text string, col uint, row uint, alpha flo
 	x := float32(r.areaX) + float32(col)*r.cellWidth
 	y := float32(r.areaY) + (float32(row+1) * r.cellHeight) + f.MinY()
 
-	f.Print(x, y, text)
+	switch size {
+	case buffer.LineSizeDoubleWidth:
+		x = float32(r.areaX) + float32(col)*r.cellWidth*2
+		f.PrintScaled(x, y, 2, 1, text)
+	case buffer.LineSizeDoubleHeightTop, buffer.LineSizeDoubleHeightBottom:
+		// the glyphs are drawn twice the height of the row, and clipped to the half that belongs on it
+		x = float32(r.areaX) + float32(col)*r.cellWidth*2
+		baselineRow := row + 1
+		if size == buffer.LineSizeDoubleHeightTop {
+			baselineRow++
+		}
+		y = float32(r.areaY) + (float32(baselineRow) * r.cellHeight) + f.MinY()*2
+		r.clipToRow(row)
+		f.PrintScaled(x, y, 2, 2, text)
+		gl.Disable(gl.SCISSOR_TEST)
+	default:
+		f.Print(x, y, text)
+	}
+}
+
+// clipToRow restricts drawing to a single row until the scissor test is disabled again
+func (r *OpenGLRenderer) clipToRow(row uint) {
+	gl.Enable(gl.SCISSOR_TEST)
+	gl.Scissor(
+		int32(r.areaX),
+		int32(float32(r.areaHeight)-(float32(row+1)*r.cellHeight)),
+		int32(r.areaWidth),
+		int32(r.cellHeight),
+	)
+}
+
+// DrawSoftGlyph draws a DECDLD soft font character, scaling its bitmap to fill the cell
+func (r *OpenGLRenderer) DrawSoftGlyph(glyph *terminal.SoftGlyph, col uint, row uint, size buffer.LineSize, colour [3]float32) {
+	cellWidth := r.cellWidth
+	if size != buffer.LineSizeSingle {
+		cellWidth *= 2
+	}
+	pixelWidth := cellWidth / float32(glyph.Width)
+	pixelHeight := r.cellHeight / float32(glyph.Height)
+
+	left := float32(col) * cellWidth
+	top := float32(row) * r.cellHeight
+
+	// double height lines show the top or bottom half of the glyph, stretched over the row
+	startY, rows := 0, glyph.Height
+	switch size {
+	case buffer.LineSizeDoubleHeightTop:
+		rows = (glyph.Height + 1) / 2
+	case buffer.LineSizeDoubleHeightBottom:
+		startY = glyph.Height / 2
+		rows = glyph.Height - startY
+	}
+	if rows != glyph.Height {
+		pixelHeight = r.cellHeight / float32(rows)
+	}
+
+	// draw each run of set pixels on a row as a single rectangle
+	for y := 0; y < rows; y++ {
+		for x := 0; x < glyph.Width; x++ {
+			if !glyph.At(x, startY+y) {
+				continue
+			}
+			span := 1
+			for glyph.At(x+span, startY+y) {
+				span++
+			}
+			rect := r.newRectangleEx(left+float32(x)*pixelWidth, top+float32(y+1)*pixelHeight, float32(span)*pixelWidth, pixelHeight, r.colourAttr)
+			rect.setColour(colour)
+			rect.Draw()
+			rect.Free()
+			x += span
+		}
+	}
 }
 
 func (r *OpenGLRenderer) DrawCellImage(cell buffer.Cell, col uint, row uint) {
@@ -0,0 +1,51 @@
+package gui
+
+import (
+	"fmt"
+
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/terminal"
+)
+
+// renderStatusLine draws the status line selected by DECSSDT on the given row, below the screen
+func (gui *GUI) renderStatusLine(row uint) {
+	colCount := int(gui.terminal.ScreenBuffer().ViewWidth())
+
+	switch gui.terminal.StatusLineType() {
+	case terminal.StatusLineIndicator:
+		// shown in reverse video, so it stands out from the screen above
+		fg := gui.config.ColourScheme.Background
+		bg := gui.config.ColourScheme.Foreground
+		cell := buffer.NewBackgroundCell(bg)
+		for x := 0; x < colCount; x++ {
+			gui.renderer.DrawCellBg(cell, uint(x), row, nil, true)
+		}
+		text := fmt.Sprintf(" Ln %d, Col %d", gui.terminal.GetLogicalCursorY()+1, gui.terminal.GetLogicalCursorX()+1)
+		if len(text) > colCount {
+			text = text[:colCount]
+		}
+		gui.renderer.DrawCellText(text, 0, row, buffer.LineSizeSingle, 1.0, fg, false)
+	case terminal.StatusLineHostWritable:
+		lines := gui.terminal.StatusLine().GetVisibleLines()
+		if len(lines) == 0 {
+			return
+		}
+		cells := lines[0].Cells()
+		for x := 0; x < colCount && x < len(cells); x++ {
+			cell := cells[x]
+			gui.renderer.DrawCellBg(cell, uint(x), row, nil, false)
+			r := cell.Rune()
+			if r == 0 {
+				continue
+			}
+			alpha := float32(1.0)
+			if cell.Attr().Dim {
+				alpha = 0.5
+			}
+			gui.renderer.DrawCellText(string(r), uint(x), row, buffer.LineSizeSingle, alpha, cell.Fg(), cell.Attr().Bold)
+			if cell.Attr().Underline {
+				gui.renderer.DrawUnderline(1, uint(x), row, cell.Fg())
+			}
+		}
+	}
+}
@@ -12,8 +12,8 @@ func (gui *GUI) textbox(col uint16, row uint16, text string, fg [3]float32, bg [
 	line := ""
 	word := ""
 
-	maxWidth := int(gui.terminal.ActiveBuffer().ViewWidth()) - 4
-	maxHeight := (int(gui.terminal.ActiveBuffer().ViewHeight()) / 2) - 2
+	maxWidth := int(gui.terminal.ScreenBuffer().ViewWidth()) - 4
+	maxHeight := (int(gui.terminal.ScreenBuffer().ViewHeight()) / 2) - 2
 
 	if maxHeight < 1 {
 		return
@@ -41,7 +41,8 @@ func hintColours(word string, context string, wordX uint16, wordY uint16) *Hint
 	return nil
 }
 
+var colourRegex = regexp.MustCompile("^#[0-9A-Fa-f]{6}$")
+
 func isColour(s string) bool {
-	re := regexp.MustCompile("#[0-9A-Fa-f]{6}")
-	return re.MatchString(s)
+	return colourRegex.MatchString(s)
 }
@@ -0,0 +1,35 @@
+package hints
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColourHint(t *testing.T) {
+	hint := Get("#ff0000", "colour: #ff0000", 8, 0)
+	require.NotNil(t, hint)
+	assert.Equal(t, [3]float32{1, 0, 0}, hint.BackgroundColour)
+}
+
+func TestHintsIgnoreMalformedWords(t *testing.T) {
+	words := []string{
+		"",
+		"#",
+		"#12345",
+		"x#123456",
+		"#1234567",
+		"-rwx",
+		"x-rwxrwxrwx",
+	}
+	for _, word := range words {
+		assert.Nil(t, Get(word, word, 0, 0), "Word %q should not produce a hint", word)
+	}
+}
+
+func TestPermissionsHint(t *testing.T) {
+	hint := Get("drwxr-x---", "drwxr-x--- 2 root root", 0, 0)
+	require.NotNil(t, hint)
+	assert.Contains(t, hint.Description, "Numeric: 750")
+}
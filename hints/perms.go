@@ -155,7 +155,8 @@ func hintPerms(word string, context string, wordX uint16, wordY uint16) *Hint {
 	return nil
 }
 
+var permStringRegex = regexp.MustCompile("^[cdl\\-sS]{1}[sStTrwx\\-]{9}$")
+
 func isPermString(s string) bool {
-	re := regexp.MustCompile("[cdl\\-sS]{1}[sStTrwx\\-]{9}")
-	return re.MatchString(s)
+	return permStringRegex.MatchString(s)
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/liamg/aminal/config"
+	"go.uber.org/zap"
+)
+
+// importTheme converts colour schemes from other programs into themes, written to the themes directory
+func importTheme(logger *zap.SugaredLogger, args []string) {
+	flags := flag.NewFlagSet("import-theme", flag.ExitOnError)
+	formatName := flags.String("format", "", "Input format: iterm, xresources, base16 or windows-terminal (default from the file name)")
+	name := flags.String("name", "", "Name of the theme (default from the scheme or the file name)")
+	output := flags.String("o", "", "File to write the theme to, or - for stdout (default the themes directory)")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: aminal import-theme [--format iterm|xresources|base16|windows-terminal] [--name name] [-o file] file")
+		os.Exit(1)
+	}
+	path := flags.Arg(0)
+
+	var format config.ImportFormat
+	var err error
+	if *formatName != "" {
+		format, err = config.ParseImportFormat(*formatName)
+	} else {
+		format, err = config.ImportFormatForPath(path)
+	}
+	if err != nil {
+		logger.Fatalf("%s", err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		logger.Fatalf("Failed to read theme: %s", err)
+	}
+	themes, err := config.ImportThemes(format, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), data)
+	if err != nil {
+		logger.Fatalf("Failed to import %s: %s", path, err)
+	}
+
+	if (*name != "" || *output != "") && len(themes) > 1 {
+		logger.Fatalf("%s has %d schemes, so they can only be written to the themes directory under their own names", path, len(themes))
+	}
+	if *name != "" {
+		themes[0].Name = config.ThemeName(*name)
+	}
+
+	if *output != "" {
+		data, err := themes[0].Encode()
+		if err != nil {
+			logger.Fatalf("Failed to encode theme: %s", err)
+		}
+		if *output == "-" {
+			os.Stdout.Write(data)
+		} else if err := ioutil.WriteFile(*output, data, 0644); err != nil {
+			logger.Fatalf("Failed to write theme: %s", err)
+		}
+		return
+	}
+
+	dir := themesDir()
+	if dir == "" {
+		logger.Fatalf("Cannot find the themes directory, use -o to choose where to write the theme")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logger.Fatalf("Failed to create themes directory: %s", err)
+	}
+	for _, theme := range themes {
+		themePath := filepath.Join(dir, theme.Name+".toml")
+		if _, err := os.Stat(themePath); err == nil {
+			logger.Fatalf("%s already exists, remove it or choose another --name", themePath)
+		}
+		data, err := theme.Encode()
+		if err != nil {
+			logger.Fatalf("Failed to encode theme: %s", err)
+		}
+		if err := ioutil.WriteFile(themePath, data, 0644); err != nil {
+			logger.Fatalf("Failed to write theme: %s", err)
+		}
+		fmt.Printf("Imported %s to %s, use it with --theme %s\n", theme.Name, themePath, theme.Name)
+	}
+}
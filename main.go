@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/liamg/aminal/gui"
 	"github.com/liamg/aminal/platform"
@@ -29,6 +30,19 @@ func initialize(unitTestfunc callback) {
 	}
 	defer logger.Sync()
 
+	if flag.Arg(0) == "play" {
+		play(conf, logger, flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == "export" {
+		exportOutput(conf, logger, flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == "import-theme" {
+		importTheme(logger, flag.Args()[1:])
+		return
+	}
+
 	logger.Infof("Allocating pty...")
 
 	pty, err := platform.NewPty(80, 25)
@@ -56,7 +70,22 @@ func initialize(unitTestfunc callback) {
 	defer guestProcess.Close()
 
 	logger.Infof("Creating terminal...")
-	terminal := terminal.New(pty, logger, conf)
+	terminal := terminal.New(pty, logger, terminalSettings(conf))
+
+	if recordFile != "" {
+		if err := terminal.StartRecording(recordFile); err != nil {
+			logger.Fatalf("Cannot record: %s", err)
+		}
+		defer terminal.StopRecording()
+	}
+
+	if conf.SessionLogAutoStart {
+		if err := terminal.StartSessionLog(); err != nil {
+			logger.Fatalf("Cannot log session: %s", err)
+		}
+	}
+	defer terminal.StopSessionLog()
+	defer terminal.CloseScrollback()
 
 	g, err := gui.New(conf, terminal, logger)
 	if err != nil {
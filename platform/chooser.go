@@ -0,0 +1,9 @@
+package platform
+
+import "errors"
+
+// ErrNoFileChooser is returned by ChooseSaveFile when there is no file chooser available to ask with
+var ErrNoFileChooser = errors.New("No file chooser available")
+
+// ErrNoListChooser is returned by ChooseFromList when there is no dialog available to ask with
+var ErrNoListChooser = errors.New("No list chooser available")
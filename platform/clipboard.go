@@ -0,0 +1,6 @@
+package platform
+
+import "errors"
+
+// ErrNoHTMLClipboard is returned by SetClipboardHTML when there is no way to put HTML on the clipboard
+var ErrNoHTMLClipboard = errors.New("No way to copy HTML to the clipboard")
@@ -22,7 +22,6 @@ func (p *cmdProc) Close() error {
 	if p == nil || p.cmd == nil || p.cmd.Process == nil {
 		return nil
 	}
-	ret := p.cmd.Process.Kill()
-	p.cmd = nil
-	return ret
+	// cmd is kept so that a concurrent Wait can still reap the process
+	return p.cmd.Process.Kill()
 }
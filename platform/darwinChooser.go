@@ -0,0 +1,50 @@
+// +build darwin
+
+package platform
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ChooseSaveFile asks for a file to save to with a Finder dialog, starting from defaultPath. It returns an
+// empty path if the user cancelled.
+func ChooseSaveFile(defaultPath string) (string, error) {
+	script := fmt.Sprintf(
+		"POSIX path of (choose file name default name %s default location POSIX file %s)",
+		strconv.Quote(filepath.Base(defaultPath)),
+		strconv.Quote(filepath.Dir(defaultPath)),
+	)
+	output, err := exec.Command("osascript", "-e", script).Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", nil // cancelled
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
+
+// ChooseFromList asks for one of the items with a list dialog. It returns an empty string if the user cancelled.
+func ChooseFromList(title string, items []string) (string, error) {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = strconv.Quote(item)
+	}
+	script := fmt.Sprintf("choose from list {%s} with prompt %s", strings.Join(quoted, ", "), strconv.Quote(title))
+	output, err := exec.Command("osascript", "-e", script).Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", nil // cancelled
+		}
+		return "", err
+	}
+	choice := strings.TrimRight(string(output), "\r\n")
+	if choice == "false" {
+		return "", nil // cancelled
+	}
+	return choice, nil
+}
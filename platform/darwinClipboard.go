@@ -0,0 +1,21 @@
+// +build darwin
+
+package platform
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// SetClipboardHTML puts HTML on the pasteboard with osascript, as GLFW can only offer plain text. The plain text
+// goes alongside it for programs which can't paste HTML. The script goes through stdin, as it can be too large for
+// the command line.
+func SetClipboardHTML(html string, text string) error {
+	script := fmt.Sprintf("set the clipboard to {«class HTML»:«data HTML%s», «class utf8»:«data utf8%s»}",
+		hex.EncodeToString([]byte(html)), hex.EncodeToString([]byte(text)))
+	cmd := exec.Command("osascript")
+	cmd.Stdin = strings.NewReader(script)
+	return cmd.Run()
+}
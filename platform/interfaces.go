@@ -17,6 +17,6 @@ type Pty interface {
 	io.ReadWriteCloser
 
 	Resize(x int, y int) error
-	CreateGuestProcess(imagePath string) (Process, error)
+	CreateGuestProcess(imagePath string, args ...string) (Process, error)
 	GetPlatformDependentSettings() PlatformDependentSettings
 }
@@ -0,0 +1,56 @@
+// +build linux freebsd netbsd openbsd
+
+package platform
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// ChooseSaveFile asks for a file to save to with zenity or kdialog, starting from defaultPath. It returns an
+// empty path if the user cancelled.
+func ChooseSaveFile(defaultPath string) (string, error) {
+	var cmd *exec.Cmd
+	if _, err := exec.LookPath("zenity"); err == nil {
+		cmd = exec.Command("zenity", "--file-selection", "--save", "--confirm-overwrite", "--filename="+defaultPath)
+	} else if _, err := exec.LookPath("kdialog"); err == nil {
+		cmd = exec.Command("kdialog", "--getsavefilename", defaultPath)
+	} else {
+		return "", ErrNoFileChooser
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", nil // cancelled
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
+
+// ChooseFromList asks for one of the items with zenity or kdialog. It returns an empty string if the user cancelled.
+func ChooseFromList(title string, items []string) (string, error) {
+	var cmd *exec.Cmd
+	if _, err := exec.LookPath("zenity"); err == nil {
+		args := []string{"--list", "--title=" + title, "--column=" + title, "--hide-header"}
+		cmd = exec.Command("zenity", append(args, items...)...)
+	} else if _, err := exec.LookPath("kdialog"); err == nil {
+		args := []string{"--menu", title}
+		for _, item := range items {
+			args = append(args, item, item)
+		}
+		cmd = exec.Command("kdialog", args...)
+	} else {
+		return "", ErrNoListChooser
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", nil // cancelled
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
@@ -0,0 +1,37 @@
+// +build linux freebsd netbsd openbsd
+
+package platform
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// copyqScript reads the HTML and the plain text, separated by a NUL, from stdin and offers both
+const copyqScript = `var data = str(input()); var i = data.indexOf("\0"); copy("text/html", data.substr(0, i), "text/plain", data.substr(i + 1))`
+
+// SetClipboardHTML puts HTML on the clipboard as text/html. GLFW can only offer plain text, so this uses copyq when
+// it is installed, which offers the plain text as text/plain alongside it for programs which can't paste HTML.
+// Otherwise only the HTML is copied, with wl-copy on Wayland or xclip on X. The data goes through stdin, as it can
+// be too large for the command line.
+func SetClipboardHTML(html string, text string) error {
+	if _, err := exec.LookPath("copyq"); err == nil {
+		return runWithInput(html+"\x00"+text, "copyq", "eval", copyqScript)
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		if _, err := exec.LookPath("wl-copy"); err == nil {
+			return runWithInput(html, "wl-copy", "--type", "text/html")
+		}
+	}
+	if _, err := exec.LookPath("xclip"); err == nil {
+		return runWithInput(html, "xclip", "-selection", "clipboard", "-t", "text/html")
+	}
+	return ErrNoHTMLClipboard
+}
+
+func runWithInput(input string, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(input)
+	return cmd.Run()
+}
@@ -0,0 +1,74 @@
+package platform
+
+import (
+	"fmt"
+	"io"
+)
+
+// pipePty is an in-memory stand-in for a pty, connecting the terminal to a host through a pair of pipes
+type pipePty struct {
+	fromHost *io.PipeReader
+	toHost   *io.PipeWriter
+}
+
+// pipeHost is the host side of a pipePty, where a real pty would have the shell
+type pipeHost struct {
+	toTerminal *io.PipeWriter
+	fromTerm   *io.PipeReader
+}
+
+// NewPipePty creates a pty which isn't backed by the OS. Anything written to the returned host is read by the
+// terminal, and replies from the terminal are read from the host. Like any pipe, writes block until they are read.
+func NewPipePty() (Pty, io.ReadWriteCloser) {
+	hostReader, ptyWriter := io.Pipe()
+	ptyReader, hostWriter := io.Pipe()
+	return &pipePty{
+		fromHost: ptyReader,
+		toHost:   ptyWriter,
+	}, &pipeHost{
+		toTerminal: hostWriter,
+		fromTerm:   hostReader,
+	}
+}
+
+func (pty *pipePty) Read(b []byte) (int, error) {
+	return pty.fromHost.Read(b)
+}
+
+func (pty *pipePty) Write(b []byte) (int, error) {
+	return pty.toHost.Write(b)
+}
+
+func (pty *pipePty) Close() error {
+	pty.fromHost.Close()
+	return pty.toHost.Close()
+}
+
+// Resize does nothing, as there is no process to tell about the new size
+func (pty *pipePty) Resize(x int, y int) error {
+	return nil
+}
+
+func (pty *pipePty) CreateGuestProcess(imagePath string, args ...string) (Process, error) {
+	return nil, fmt.Errorf("Cannot run a process on a pipe pty")
+}
+
+func (pty *pipePty) GetPlatformDependentSettings() PlatformDependentSettings {
+	return PlatformDependentSettings{
+		OSCTerminators: map[rune]struct{}{0x07: {}, 0x5c: {}},
+	}
+}
+
+func (host *pipeHost) Read(b []byte) (int, error) {
+	return host.fromTerm.Read(b)
+}
+
+func (host *pipeHost) Write(b []byte) (int, error) {
+	return host.toTerminal.Write(b)
+}
+
+// Close ends the session, so the terminal reads EOF
+func (host *pipeHost) Close() error {
+	host.fromTerm.Close()
+	return host.toTerminal.Close()
+}
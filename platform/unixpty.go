@@ -4,8 +4,10 @@ package platform
 
 import (
 	"errors"
+	"io"
 	"os"
 	"os/exec"
+	"sync"
 	"syscall"
 	"unsafe"
 
@@ -14,7 +16,9 @@ import (
 
 type unixPty struct {
 	pty                       *os.File
-	tty                       *os.File
+	tty                       *os.File // the parent's copy, closed once a process has been started on it
+	lock                      sync.Mutex
+	closed                    bool
 	platformDependentSettings PlatformDependentSettings
 }
 
@@ -25,11 +29,16 @@ type winsize struct {
 	y      uint16 //ignored, but necessary for ioctl calls
 }
 
+// Read returns io.EOF once every process using the tty has exited, which linux reports as EIO
 func (p *unixPty) Read(b []byte) (int, error) {
 	if p == nil || p.pty == nil {
 		return 0, errors.New("Attempted to read from a deallocated pty")
 	}
-	return p.pty.Read(b)
+	n, err := p.pty.Read(b)
+	if pathErr, ok := err.(*os.PathError); ok && pathErr.Err == syscall.EIO {
+		err = io.EOF
+	}
+	return n, err
 }
 
 func (p *unixPty) Write(b []byte) (int, error) {
@@ -39,14 +48,23 @@ func (p *unixPty) Write(b []byte) (int, error) {
 	return p.pty.Write(b)
 }
 
+// Close releases the pty. A Read blocked on another goroutine returns an error, so callers should close the pty
+// and then wait for their reader to exit.
 func (p *unixPty) Close() error {
-	if p == nil || p.pty == nil {
+	if p == nil {
 		return nil
 	}
-	ret := p.pty.Close()
-	p.pty = nil
-	p.tty = nil
-	return ret
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	if p.tty != nil {
+		p.tty.Close()
+		p.tty = nil
+	}
+	return p.pty.Close()
 }
 
 func (p *unixPty) Resize(x, y int) error {
@@ -66,11 +84,19 @@ func (p *unixPty) Resize(x, y int) error {
 	return nil
 }
 
-func (p *unixPty) CreateGuestProcess(imagePath string) (Process, error) {
-	if p == nil || p.tty == nil {
+func (p *unixPty) CreateGuestProcess(imagePath string, args ...string) (Process, error) {
+	if p == nil {
 		return nil, errors.New("Attempted to create a process on a deallocated pty")
 	}
-	shell := newCmdProc(exec.Command(imagePath))
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.closed {
+		return nil, errors.New("Attempted to create a process on a deallocated pty")
+	}
+	if p.tty == nil {
+		return nil, errors.New("A process has already been started on the pty")
+	}
+	shell := newCmdProc(exec.Command(imagePath, args...))
 	shell.cmd.Stdout = p.tty
 	shell.cmd.Stdin = p.tty
 	shell.cmd.Stderr = p.tty
@@ -79,6 +105,10 @@ func (p *unixPty) CreateGuestProcess(imagePath string) (Process, error) {
 		return nil, err
 	}
 
+	// the child has its own copy; keeping ours open would stop reads from ending when it exits
+	p.tty.Close()
+	p.tty = nil
+
 	return shell, nil
 }
 
@@ -0,0 +1,13 @@
+// +build windows
+
+package platform
+
+// ChooseSaveFile is not supported on Windows yet, so the path from the config is always used
+func ChooseSaveFile(defaultPath string) (string, error) {
+	return "", ErrNoFileChooser
+}
+
+// ChooseFromList is not supported on Windows yet
+func ChooseFromList(title string, items []string) (string, error) {
+	return "", ErrNoListChooser
+}
@@ -0,0 +1,8 @@
+// +build windows
+
+package platform
+
+// SetClipboardHTML is not supported on Windows yet, so only plain text is copied
+func SetClipboardHTML(html string, text string) error {
+	return ErrNoHTMLClipboard
+}
@@ -133,8 +133,13 @@ func (pty *winConPty) Close() error {
 	return nil
 }
 
-func (pty *winConPty) CreateGuestProcess(imagePath string) (Process, error) {
-	process, err := createPtyChildProcess(imagePath, pty.hcon)
+func (pty *winConPty) CreateGuestProcess(imagePath string, args ...string) (Process, error) {
+	// the image path is used as is, since it may already hold a command line from the shell setting
+	commandLine := imagePath
+	for _, arg := range args {
+		commandLine += " " + syscall.EscapeArg(arg)
+	}
+	process, err := createPtyChildProcess(commandLine, pty.hcon)
 
 	if err == nil {
 		setupChildConsole(C.DWORD(process.processID), C.STD_OUTPUT_HANDLE, C.ENABLE_PROCESSED_OUTPUT|C.ENABLE_WRAP_AT_EOL_OUTPUT)
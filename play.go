@@ -0,0 +1,179 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/liamg/aminal/asciicast"
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/gui"
+	"github.com/liamg/aminal/platform"
+	"github.com/liamg/aminal/terminal"
+	"go.uber.org/zap"
+)
+
+// play replays an asciicast recording in the terminal window, in place of a shell
+func play(conf *config.Config, logger *zap.SugaredLogger, args []string) {
+	flags := flag.NewFlagSet("play", flag.ExitOnError)
+	speed := flags.Float64("speed", 1, "Playback speed, e.g. 2 to play twice as fast")
+	idleLimit := flags.Duration("idle-limit", 0, "Longest pause between events, e.g. 1s")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: aminal play [--speed n] [--idle-limit duration] file.cast")
+		os.Exit(1)
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		logger.Fatalf("Failed to open recording: %s", err)
+	}
+	defer file.Close()
+
+	reader, err := asciicast.NewReader(file)
+	if err != nil {
+		logger.Fatalf("Failed to read recording: %s", err)
+	}
+	player := asciicast.NewPlayer(reader, asciicast.PlayerOptions{Speed: *speed, IdleLimit: *idleLimit})
+
+	pty, host := platform.NewPipePty()
+	term := terminal.New(pty, logger, terminalSettings(conf))
+
+	g, err := gui.New(conf, term, logger)
+	if err != nil {
+		logger.Fatalf("Cannot start: %s", err)
+	}
+
+	go handlePlaybackKeys(host, player, g)
+
+	go func() {
+		// the window sizes the terminal when it opens, so wait for that before resizing it to fit the recording
+		for cols, _ := term.GetSize(); cols == 0; cols, _ = term.GetSize() {
+			time.Sleep(10 * time.Millisecond)
+		}
+		term.SetSize(uint(reader.Header.Width), uint(reader.Header.Height))
+
+		err := player.Play(host, func(cols int, rows int) {
+			term.SetSize(uint(cols), uint(rows))
+		})
+		if err != nil {
+			logger.Errorf("Playback failed: %s", err)
+		}
+	}()
+
+	if err := g.Render(); err != nil {
+		logger.Fatalf("Render error: %s", err)
+	}
+}
+
+// handlePlaybackKeys reads what is typed into the window during playback: space pauses and q quits. Anything
+// else sent by the terminal, such as replies to queries, is discarded.
+func handlePlaybackKeys(host io.Reader, player *asciicast.Player, g *gui.GUI) {
+	filter := &replyFilter{}
+	data := make([]byte, 1024)
+	for {
+		n, err := host.Read(data)
+		for _, b := range data[:n] {
+			if !filter.typed(b) {
+				continue
+			}
+			switch b {
+			case ' ':
+				player.TogglePause()
+			case 'q':
+				g.Close()
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// replyFilter picks out the keys typed into the window from what the terminal sends, skipping escape sequences
+// such as replies to queries, which may contain spaces and qs. Its state carries over between reads.
+type replyFilter struct {
+	state         int
+	continuations int // bytes left in a UTF-8 character, which could otherwise be taken for C1 controls
+}
+
+const (
+	replyGround       = iota
+	replyEscape       // after ESC
+	replyControl      // in a control sequence, until its final byte
+	replyString       // in a control string, until ST or BEL
+	replyStringEscape // after ESC in a control string
+	replySingleShift  // after SS2 or SS3, before the character it applies to
+)
+
+// typed returns true if the byte was typed, rather than part of an escape sequence
+func (filter *replyFilter) typed(b byte) bool {
+	switch filter.state {
+	case replyEscape:
+		switch b {
+		case '[':
+			filter.state = replyControl
+		case 'P', ']', 'X', '^', '_':
+			filter.state = replyString
+		case 'N', 'O':
+			filter.state = replySingleShift
+		default:
+			filter.state = replyGround
+		}
+		return false
+	case replyControl:
+		if b >= 0x40 && b <= 0x7e {
+			filter.state = replyGround
+		}
+		return false
+	case replyString:
+		switch b {
+		case 0x1b:
+			filter.state = replyStringEscape
+		case 0x07, 0x9c:
+			filter.state = replyGround
+		}
+		return false
+	case replyStringEscape:
+		filter.state = replyString
+		if b == '\\' {
+			filter.state = replyGround
+		}
+		return false
+	case replySingleShift:
+		filter.state = replyGround
+		return false
+	}
+
+	if filter.continuations > 0 && b&0xc0 == 0x80 {
+		filter.continuations--
+		return true
+	}
+	filter.continuations = 0
+
+	switch {
+	case b >= 0xf0:
+		filter.continuations = 3
+	case b >= 0xe0:
+		filter.continuations = 2
+	case b >= 0xc0:
+		filter.continuations = 1
+	}
+
+	switch b {
+	case 0x1b:
+		filter.state = replyEscape
+	case 0x9b:
+		filter.state = replyControl
+	case 0x90, 0x9d, 0x98, 0x9e, 0x9f:
+		filter.state = replyString
+	case 0x8e, 0x8f:
+		filter.state = replySingleShift
+	default:
+		return true
+	}
+	return false
+}
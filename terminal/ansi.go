@@ -14,21 +14,34 @@ var ansiSequenceMap = map[rune]escapeSequenceHandler{
 	'E': nextLineHandler, // NEL
 	'H': tabSetHandler,   // HTS
 	'M': reverseIndexHandler,
-	'P': sixelHandler,
+	'P': dcsHandler,
 	'c': risHandler, //RIS
 	'#': screenStateHandler,
-	'(': scs0Handler,       // select character set into G0
-	')': scs1Handler,       // select character set into G1
-	'*': swallowHandler(1), // character set bullshit
-	'+': swallowHandler(1), // character set bullshit
-	'>': swallowHandler(0), // numeric char selection  //@todo
-	'=': swallowHandler(0), // alt char selection  //@todo
+	' ': announceCodeHandler,           // S7C1T, S8C1T
+	'(': scsHandler(0),                 // select character set into G0
+	')': scsHandler(1),                 // select character set into G1
+	'*': scsHandler(2),                 // select character set into G2
+	'+': scsHandler(3),                 // select character set into G3
+	'-': scs96Handler(1),               // select 96 character set into G1
+	'.': scs96Handler(2),               // select 96 character set into G2
+	'/': scs96Handler(3),               // select 96 character set into G3
+	'N': singleShiftHandler(2),         // SS2
+	'O': singleShiftHandler(3),         // SS3
+	'n': lockingShiftHandler(2, false), // LS2
+	'o': lockingShiftHandler(3, false), // LS3
+	'~': lockingShiftHandler(1, true),  // LS1R
+	'}': lockingShiftHandler(2, true),  // LS2R
+	'|': lockingShiftHandler(3, true),  // LS3R
+	'>': swallowHandler(0),             // numeric char selection  //@todo
+	'=': swallowHandler(0),             // alt char selection  //@todo
 }
 
 func swallowHandler(n int) func(pty chan rune, terminal *Terminal) error {
 	return func(pty chan rune, terminal *Terminal) error {
 		for i := 0; i < n; i++ {
-			<-pty
+			if _, ok := terminal.nextRune(pty); !ok {
+				return errTruncatedSequence
+			}
 		}
 		return nil
 	}
@@ -61,9 +74,21 @@ func restoreCursorHandler(pty chan rune, terminal *Terminal) error {
 
 func ansiHandler(pty chan rune, terminal *Terminal) error {
 	// if the byte is an escape character, read the next byte to determine which one
-	b := <-pty
+	b, ok := terminal.nextRune(pty)
+	if !ok {
+		return errTruncatedSequence
+	}
+	return handleEscapeSequence(b, pty, terminal)
+}
+
+// handleEscapeSequence handles the rest of an escape sequence, given the byte which follows ESC
+func handleEscapeSequence(b rune, pty chan rune, terminal *Terminal) error {
+	sequenceMap := ansiSequenceMap
+	if terminal.modes.VT52 {
+		sequenceMap = vt52SequenceMap
+	}
 
-	handler, ok := ansiSequenceMap[b]
+	handler, ok := sequenceMap[b]
 	if ok {
 		//terminal.logger.Debugf("Handling ansi sequence %c", b)
 		return handler(pty, terminal)
@@ -78,6 +103,6 @@ func nextLineHandler(pty chan rune, terminal *Terminal) error {
 }
 
 func tabSetHandler(pty chan rune, terminal *Terminal) error {
-	terminal.terminalState.TabSetAtCursor()
+	terminal.ActiveBuffer().TabSetAtCursor()
 	return nil
 }
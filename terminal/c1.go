@@ -0,0 +1,89 @@
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/liamg/aminal/config"
+)
+
+// 8-bit C1 control characters, each equivalent to ESC followed by (c1 - 0x40)
+const (
+	c1DCS rune = 0x90
+	c1CSI rune = 0x9b
+	c1ST  rune = 0x9c
+	c1OSC rune = 0x9d
+)
+
+func isC1Control(r rune) bool {
+	return r >= 0x80 && r <= 0x9f
+}
+
+// readRune reads the next rune from the pty. Bytes which are not valid UTF-8 are offset by rawByteBase, so that
+// they can be told apart from decoded characters, to be translated through GR or passed on to a printer as they were.
+func readRune(reader *bufio.Reader) (rune, error) {
+	r, size, err := reader.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+
+	if r == utf8.RuneError && size == 1 {
+		// not valid UTF-8, so recover the raw byte - it may well be an 8-bit control
+		if err := reader.UnreadRune(); err != nil {
+			return 0, err
+		}
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		return rawByteBase + rune(b), nil
+	}
+
+	return r, nil
+}
+
+// translateC1 translates an 8-bit C1 control read from the pty into its 7-bit equivalent (ESC Fe), as permitted by
+// the c1_controls setting, returning the character which follows ESC as next. Raw C1 bytes which are not
+// interpreted become U+FFFD.
+func (terminal *Terminal) translateC1(r rune) (translated rune, next rune) {
+	mode := terminal.settings.C1Controls
+
+	if r >= rawByteBase+0x80 && r <= rawByteBase+0x9f {
+		if mode == config.C1Always || mode == config.C1NonUTF8 {
+			return 0x1b, r - rawByteBase - 0x40
+		}
+		return utf8.RuneError, 0
+	}
+
+	if mode == config.C1Always && isC1Control(r) {
+		return 0x1b, r - 0x40
+	}
+
+	return r, 0
+}
+
+// c1 returns the given C1 control in the form selected by S7C1T/S8C1T, for use in replies to the host
+func (terminal *Terminal) c1(control rune) string {
+	if terminal.modes.EightBitControls {
+		return string([]byte{byte(control)})
+	}
+	return string([]rune{0x1b, control - 0x40})
+}
+
+// ESC SP Ps
+func announceCodeHandler(pty chan rune, terminal *Terminal) error {
+	b, ok := terminal.nextRune(pty)
+	if !ok {
+		return errTruncatedSequence
+	}
+	switch b {
+	case 'F': // S7C1T
+		terminal.modes.EightBitControls = false
+	case 'G': // S8C1T
+		terminal.modes.EightBitControls = true
+	default:
+		return fmt.Errorf("Unsupported code announcement: ESC SP %c", b)
+	}
+	return nil
+}
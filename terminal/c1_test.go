@@ -0,0 +1,64 @@
+package terminal
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/liamg/aminal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// readAllRunes decodes the input as it would be read from the pty, and returns the runes seen by the parser
+func readAllRunes(t *testing.T, mode config.C1Mode, input []byte) []rune {
+	terminal := &Terminal{settings: Settings{C1Controls: mode}}
+	reader := bufio.NewReader(bytes.NewReader(input))
+	pty := make(chan rune, len(input))
+	for {
+		r, err := readRune(reader)
+		if err != nil {
+			break
+		}
+		pty <- r
+	}
+	close(pty)
+
+	result := []rune{}
+	for {
+		r, ok := terminal.nextRune(pty)
+		if !ok {
+			break
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+func TestC1ControlTranslation(t *testing.T) {
+	raw := []byte{0x9b, '2', 'J'}
+	encoded := []byte("\u009b2J")
+
+	assert.Equal(t, []rune{0xfffd, '2', 'J'}, readAllRunes(t, config.C1Never, raw))
+	assert.Equal(t, []rune{0x9b, '2', 'J'}, readAllRunes(t, config.C1Never, encoded))
+
+	assert.Equal(t, []rune{0x1b, '[', '2', 'J'}, readAllRunes(t, config.C1NonUTF8, raw))
+	assert.Equal(t, []rune{0x9b, '2', 'J'}, readAllRunes(t, config.C1NonUTF8, encoded))
+
+	assert.Equal(t, []rune{0x1b, '[', '2', 'J'}, readAllRunes(t, config.C1Always, raw))
+	assert.Equal(t, []rune{0x1b, '[', '2', 'J'}, readAllRunes(t, config.C1Always, encoded))
+
+	// other invalid UTF-8 is kept apart from decoded characters
+	assert.Equal(t, []rune{rawByteBase + 0xff, 'a'}, readAllRunes(t, config.C1Always, []byte{0xff, 'a'}))
+	assert.Equal(t, []rune{0xfffd, 'a'}, readAllRunes(t, config.C1Never, []byte{0x85, 'a'}))
+	assert.Equal(t, []rune("é"), readAllRunes(t, config.C1Always, []byte("é")))
+}
+
+func TestC1Replies(t *testing.T) {
+	terminal := &Terminal{}
+	assert.Equal(t, "\x1b[", terminal.c1(c1CSI))
+	assert.Equal(t, "\x1b\\", terminal.c1(c1ST))
+
+	terminal.modes.EightBitControls = true
+	assert.Equal(t, "\x9b", terminal.c1(c1CSI))
+	assert.Equal(t, "\x90", terminal.c1(c1DCS))
+}
@@ -2,10 +2,40 @@ package terminal
 
 import "fmt"
 
-var charSets = map[rune]*map[rune]rune{
-	'0': &decSpecGraphics,
-	'B': nil, // ASCII
-	// @todo 1,2,A
+// https://vt100.net/docs/vt510-rm/SCS.html
+
+// charSets are the 94 character sets, keyed by their final character(s) in SCS
+var charSets = map[string]*map[rune]rune{
+	"B":  nil, // ASCII
+	"0":  &decSpecGraphics,
+	"1":  nil, // alternate character ROM standard characters
+	"2":  &decSpecGraphics,
+	"<":  &decSupplemental, // user-preferred supplemental
+	"%5": &decSupplemental,
+	">":  &decTechnical,
+	"A":  &nrcsUK,
+	"4":  &nrcsDutch,
+	"C":  &nrcsFinnish,
+	"5":  &nrcsFinnish,
+	"R":  &nrcsFrench,
+	"f":  &nrcsFrench,
+	"Q":  &nrcsFrenchCanadian,
+	"9":  &nrcsFrenchCanadian,
+	"K":  &nrcsGerman,
+	"Y":  &nrcsItalian,
+	"E":  &nrcsNorwegianDanish,
+	"6":  &nrcsNorwegianDanish,
+	"`":  &nrcsNorwegianDanish,
+	"%6": &nrcsPortuguese,
+	"Z":  &nrcsSpanish,
+	"H":  &nrcsSwedish,
+	"7":  &nrcsSwedish,
+	"=":  &nrcsSwiss,
+}
+
+// charSets96 are the 96 character sets, which can only be designated into G1 to G3
+var charSets96 = map[string]*map[rune]rune{
+	"A": &isoLatin1Supplemental,
 }
 
 var decSpecGraphics = map[rune]rune{
@@ -43,23 +73,256 @@ var decSpecGraphics = map[rune]rune{
 	0x7e: 0x00B7, // MIDDLE DOT
 }
 
-func scs0Handler(pty chan rune, terminal *Terminal) error {
-	return scsHandler(pty, terminal, 0)
+// DEC Supplemental Graphic matches the right half of ISO Latin-1, apart from a few positions
+var decSupplemental = func() map[rune]rune {
+	m := map[rune]rune{}
+	for r := rune(0x21); r <= 0x7e; r++ {
+		m[r] = r + 0x80
+	}
+	m[0x28] = 0x00A4 // CURRENCY SIGN
+	m[0x57] = 0x0152 // LATIN CAPITAL LIGATURE OE
+	m[0x5d] = 0x0178 // LATIN CAPITAL LETTER Y WITH DIAERESIS
+	m[0x77] = 0x0153 // LATIN SMALL LIGATURE OE
+	m[0x7d] = 0x00FF // LATIN SMALL LETTER Y WITH DIAERESIS
+	return m
+}()
+
+var isoLatin1Supplemental = func() map[rune]rune {
+	m := map[rune]rune{}
+	for r := rune(0x20); r <= 0x7f; r++ {
+		m[r] = r + 0x80
+	}
+	return m
+}()
+
+var decTechnical = map[rune]rune{
+	0x21: 0x23B7, // RADICAL SYMBOL BOTTOM
+	0x22: 0x250C, // BOX DRAWINGS LIGHT DOWN AND RIGHT
+	0x23: 0x2500, // BOX DRAWINGS LIGHT HORIZONTAL
+	0x24: 0x2320, // TOP HALF INTEGRAL
+	0x25: 0x2321, // BOTTOM HALF INTEGRAL
+	0x26: 0x2502, // BOX DRAWINGS LIGHT VERTICAL
+	0x27: 0x23A1, // LEFT SQUARE BRACKET UPPER CORNER
+	0x28: 0x23A3, // LEFT SQUARE BRACKET LOWER CORNER
+	0x29: 0x23A4, // RIGHT SQUARE BRACKET UPPER CORNER
+	0x2a: 0x23A6, // RIGHT SQUARE BRACKET LOWER CORNER
+	0x2b: 0x239B, // LEFT PARENTHESIS UPPER HOOK
+	0x2c: 0x239D, // LEFT PARENTHESIS LOWER HOOK
+	0x2d: 0x239E, // RIGHT PARENTHESIS UPPER HOOK
+	0x2e: 0x23A0, // RIGHT PARENTHESIS LOWER HOOK
+	0x2f: 0x23A8, // LEFT CURLY BRACKET MIDDLE PIECE
+	0x30: 0x23AC, // RIGHT CURLY BRACKET MIDDLE PIECE
+	0x31: 0x23B2, // SUMMATION TOP
+	0x32: 0x23B3, // SUMMATION BOTTOM
+	0x33: 0x2572, // BOX DRAWINGS LIGHT DIAGONAL UPPER LEFT TO LOWER RIGHT
+	0x34: 0x2571, // BOX DRAWINGS LIGHT DIAGONAL UPPER RIGHT TO LOWER LEFT
+	0x35: 0x231D, // TOP RIGHT CORNER
+	0x36: 0x231F, // BOTTOM RIGHT CORNER
+	0x37: 0x27E9, // MATHEMATICAL RIGHT ANGLE BRACKET
+	0x3c: 0x2264, // LESS-THAN OR EQUAL TO
+	0x3d: 0x2260, // NOT EQUAL TO
+	0x3e: 0x2265, // GREATER-THAN OR EQUAL TO
+	0x3f: 0x222B, // INTEGRAL
+	0x40: 0x2234, // THEREFORE
+	0x41: 0x221D, // PROPORTIONAL TO
+	0x42: 0x221E, // INFINITY
+	0x43: 0x00F7, // DIVISION SIGN
+	0x44: 0x0394, // GREEK CAPITAL DELTA
+	0x45: 0x2207, // NABLA
+	0x46: 0x03A6, // GREEK CAPITAL LETTER PHI
+	0x47: 0x0393, // GREEK CAPITAL LETTER GAMMA
+	0x48: 0x223C, // TILDE OPERATOR
+	0x49: 0x2243, // ASYMPTOTICALLY EQUAL TO
+	0x4a: 0x0398, // GREEK CAPITAL LETTER THETA
+	0x4b: 0x00D7, // MULTIPLICATION SIGN
+	0x4c: 0x039B, // GREEK CAPITAL LETTER LAMDA
+	0x4d: 0x21D4, // LEFT RIGHT DOUBLE ARROW
+	0x4e: 0x21D2, // RIGHTWARDS DOUBLE ARROW
+	0x4f: 0x2261, // IDENTICAL TO
+	0x50: 0x03A0, // GREEK CAPITAL LETTER PI
+	0x51: 0x03A8, // GREEK CAPITAL LETTER PSI
+	0x53: 0x03A3, // GREEK CAPITAL LETTER SIGMA
+	0x56: 0x221A, // SQUARE ROOT
+	0x57: 0x03A9, // GREEK CAPITAL LETTER OMEGA
+	0x58: 0x039E, // GREEK CAPITAL LETTER XI
+	0x59: 0x03A5, // GREEK CAPITAL LETTER UPSILON
+	0x5a: 0x2282, // SUBSET OF
+	0x5b: 0x2283, // SUPERSET OF
+	0x5c: 0x2229, // INTERSECTION
+	0x5d: 0x222A, // UNION
+	0x5e: 0x2227, // LOGICAL AND
+	0x5f: 0x2228, // LOGICAL OR
+	0x60: 0x00AC, // NOT SIGN
+	0x61: 0x03B1, // GREEK SMALL LETTER ALPHA
+	0x62: 0x03B2, // GREEK SMALL LETTER BETA
+	0x63: 0x03C7, // GREEK SMALL LETTER CHI
+	0x64: 0x03B4, // GREEK SMALL LETTER DELTA
+	0x65: 0x03B5, // GREEK SMALL LETTER EPSILON
+	0x66: 0x03C6, // GREEK SMALL LETTER PHI
+	0x67: 0x03B3, // GREEK SMALL LETTER GAMMA
+	0x68: 0x03B7, // GREEK SMALL LETTER ETA
+	0x69: 0x03B9, // GREEK SMALL LETTER IOTA
+	0x6a: 0x03B8, // GREEK SMALL LETTER THETA
+	0x6b: 0x03BA, // GREEK SMALL LETTER KAPPA
+	0x6c: 0x03BB, // GREEK SMALL LETTER LAMDA
+	0x6e: 0x03BD, // GREEK SMALL LETTER NU
+	0x6f: 0x2202, // PARTIAL DIFFERENTIAL
+	0x70: 0x03C0, // GREEK SMALL LETTER PI
+	0x71: 0x03C8, // GREEK SMALL LETTER PSI
+	0x72: 0x03C1, // GREEK SMALL LETTER RHO
+	0x73: 0x03C3, // GREEK SMALL LETTER SIGMA
+	0x74: 0x03C4, // GREEK SMALL LETTER TAU
+	0x76: 0x0192, // LATIN SMALL LETTER F WITH HOOK
+	0x77: 0x03C9, // GREEK SMALL LETTER OMEGA
+	0x78: 0x03BE, // GREEK SMALL LETTER XI
+	0x79: 0x03C5, // GREEK SMALL LETTER UPSILON
+	0x7a: 0x03B6, // GREEK SMALL LETTER ZETA
+	0x7b: 0x2190, // LEFTWARDS ARROW
+	0x7c: 0x2191, // UPWARDS ARROW
+	0x7d: 0x2192, // RIGHTWARDS ARROW
+	0x7e: 0x2193, // DOWNWARDS ARROW
+}
+
+// National Replacement Character Sets replace a handful of ASCII positions with local characters
+
+var nrcsUK = map[rune]rune{
+	'#': '£',
+}
+
+var nrcsDutch = map[rune]rune{
+	'#': '£', '@': '¾', '[': 'ĳ', '\\': '½', ']': '|', '{': '¨', '|': 'ƒ', '}': '¼', '~': '´',
+}
+
+var nrcsFinnish = map[rune]rune{
+	'[': 'Ä', '\\': 'Ö', ']': 'Å', '^': 'Ü', '`': 'é', '{': 'ä', '|': 'ö', '}': 'å', '~': 'ü',
+}
+
+var nrcsFrench = map[rune]rune{
+	'#': '£', '@': 'à', '[': '°', '\\': 'ç', ']': '§', '{': 'é', '|': 'ù', '}': 'è', '~': '¨',
+}
+
+var nrcsFrenchCanadian = map[rune]rune{
+	'@': 'à', '[': 'â', '\\': 'ç', ']': 'ê', '^': 'î', '`': 'ô', '{': 'é', '|': 'ù', '}': 'è', '~': 'û',
+}
+
+var nrcsGerman = map[rune]rune{
+	'@': '§', '[': 'Ä', '\\': 'Ö', ']': 'Ü', '{': 'ä', '|': 'ö', '}': 'ü', '~': 'ß',
+}
+
+var nrcsItalian = map[rune]rune{
+	'#': '£', '@': '§', '[': '°', '\\': 'ç', ']': 'é', '`': 'ù', '{': 'à', '|': 'ò', '}': 'è', '~': 'ì',
+}
+
+var nrcsNorwegianDanish = map[rune]rune{
+	'@': 'Ä', '[': 'Æ', '\\': 'Ø', ']': 'Å', '^': 'Ü', '`': 'ä', '{': 'æ', '|': 'ø', '}': 'å', '~': 'ü',
+}
+
+var nrcsPortuguese = map[rune]rune{
+	'[': 'Ã', '\\': 'Ç', ']': 'Õ', '{': 'ã', '|': 'ç', '}': 'õ',
+}
+
+var nrcsSpanish = map[rune]rune{
+	'#': '£', '@': '§', '[': '¡', '\\': 'Ñ', ']': '¿', '{': '°', '|': 'ñ', '}': 'ç',
+}
+
+var nrcsSwedish = map[rune]rune{
+	'@': 'É', '[': 'Ä', '\\': 'Ö', ']': 'Å', '^': 'Ü', '`': 'é', '{': 'ä', '|': 'ö', '}': 'å', '~': 'ü',
+}
+
+var nrcsSwiss = map[rune]rune{
+	'#': 'ù', '@': 'à', '[': 'é', '\\': 'ç', ']': 'ê', '^': 'î', '_': 'è', '`': 'ô', '{': 'ä', '|': 'ö', '}': 'ü', '~': 'û',
+}
+
+// ESC ( C, ESC ) C, ESC * C, ESC + C
+func scsHandler(which int) escapeSequenceHandler {
+	return func(pty chan rune, terminal *Terminal) error {
+		return designateCharset(pty, terminal, which, charSets, false)
+	}
 }
 
-func scs1Handler(pty chan rune, terminal *Terminal) error {
-	return scsHandler(pty, terminal, 1)
+// ESC - C, ESC . C, ESC / C
+func scs96Handler(which int) escapeSequenceHandler {
+	return func(pty chan rune, terminal *Terminal) error {
+		return designateCharset(pty, terminal, which, charSets96, true)
+	}
 }
 
-func scsHandler(pty chan rune, terminal *Terminal, which int) error {
-	b := <-pty
+func designateCharset(pty chan rune, terminal *Terminal, which int, sets map[string]*map[rune]rune, is96 bool) error {
+	code := ""
+	for {
+		b, ok := terminal.nextRune(pty)
+		if !ok {
+			return errTruncatedSequence
+		}
+		code += string(b)
+		// intermediates such as % or SP precede the final character of longer designators
+		if b < 0x20 || b > 0x2f || len(code) > 2 {
+			break
+		}
+	}
 
-	cs, ok := charSets[b]
+	if cs := terminal.lookupSoftFont(code, is96); cs != nil {
+		terminal.logger.Debugf("Selected soft font %q into G%v", code, which)
+		terminal.terminalState.Charsets[which] = cs
+		return nil
+	}
+
+	cs, ok := sets[code]
 	if ok {
-		terminal.logger.Debugf("Selected charset %v into G%v", string(b), which)
+		terminal.logger.Debugf("Selected charset %v into G%v", code, which)
 		terminal.terminalState.Charsets[which] = cs
 		return nil
 	}
 	terminal.terminalState.Charsets[which] = nil
-	return fmt.Errorf("Unknown SCS charset code: 0x%02X [%v]", b, string(b))
+	return fmt.Errorf("Unknown SCS charset code: %q", code)
+}
+
+// lockingShiftHandler invokes the given G set into GL (LS2, LS3) or GR (LS1R, LS2R, LS3R)
+func lockingShiftHandler(which int, right bool) escapeSequenceHandler {
+	return func(pty chan rune, terminal *Terminal) error {
+		if right {
+			terminal.terminalState.CurrentCharsetGR = which
+		} else {
+			terminal.terminalState.CurrentCharset = which
+		}
+		return nil
+	}
+}
+
+// singleShiftHandler invokes the given G set for the next graphic character only (SS2, SS3)
+func singleShiftHandler(which int) escapeSequenceHandler {
+	return func(pty chan rune, terminal *Terminal) error {
+		terminal.terminalState.SingleShift = which
+		return nil
+	}
+}
+
+// rawByteBase offsets 8-bit characters which were not valid UTF-8, so that they can be told apart from decoded
+// characters. Only these are translated through GR; the lone surrogates they become can't come from decoding UTF-8.
+const rawByteBase = 0xdc00
+
+// translateRune maps a character through the character sets currently invoked into GL and GR
+func (terminal *Terminal) translateRune(b rune) rune {
+	state := terminal.terminalState
+	raw := b >= rawByteBase+0xa0 && b <= rawByteBase+0xff
+	gl, gr := state.CurrentCharset, state.CurrentCharsetGR
+	if state.SingleShift != 0 && ((b > 0x20 && b < 0x7f) || (raw && b > rawByteBase+0xa0 && b < rawByteBase+0xff)) {
+		gl, gr = state.SingleShift, state.SingleShift
+		state.SingleShift = 0
+	}
+
+	table, index := state.Charsets[gl], b
+	if raw {
+		// without a mapping, an 8-bit character is taken to be Latin-1
+		b -= rawByteBase
+		table, index = state.Charsets[gr], b-0x80
+	}
+	if table == nil {
+		return b
+	}
+	chr, ok := (*table)[index]
+	if ok {
+		return chr
+	}
+	return b
 }
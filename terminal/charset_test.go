@@ -0,0 +1,74 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func firstLine(terminal *Terminal) string {
+	return terminal.ActiveBuffer().GetVisibleLines()[0].String()
+}
+
+func TestNationalReplacementCharsets(t *testing.T) {
+	terminal := newTestTerminal(40, 4)
+	process(terminal, "\x1b(K{|}~\x1b(A#\x1b(B#")
+	assert.Equal(t, "äöüß£#", firstLine(terminal))
+}
+
+func TestTwoCharacterDesignators(t *testing.T) {
+	terminal := newTestTerminal(40, 4)
+	process(terminal, "\x1b(%6[\x1b(%5W")
+	assert.Equal(t, "ÃŒ", firstLine(terminal))
+}
+
+func TestLockingShifts(t *testing.T) {
+	terminal := newTestTerminal(40, 4)
+	process(terminal, "\x1b*0\x1b+>\x1bnq\x1bod\x0fq")
+	assert.Equal(t, "─δq", firstLine(terminal))
+
+	// GR is translated through G2 by default, then G1 once LS1R is used
+	headless := NewHeadless(40, 4, HeadlessOptions{})
+	defer headless.Close()
+	headless.Feed([]byte("\x1b*0\x1b)>\xf1\x1b~\xe1."))
+	assert.Equal(t, "─α.", headless.Lines()[0])
+}
+
+func TestGROnlyTranslatesRawBytes(t *testing.T) {
+	headless := NewHeadless(40, 4, HeadlessOptions{})
+	defer headless.Close()
+
+	// characters decoded from UTF-8 are left alone, and raw bytes without a mapping are Latin-1
+	headless.Feed([]byte("\x1b*0ñ\xf1\x1b*B\xe9é"))
+	assert.Equal(t, "ñ─éé", headless.Lines()[0])
+}
+
+func TestFillAreaTranslatesThroughGR(t *testing.T) {
+	terminal := newTestTerminal(40, 4)
+	process(terminal, "\x1b*0\x1b[241;1;1;1;2$x\x1b*B\x1b[233;1;3;1;3$x")
+	assert.Equal(t, "──é", firstLine(terminal))
+}
+
+func TestSingleShifts(t *testing.T) {
+	terminal := newTestTerminal(40, 4)
+	process(terminal, "\x1b*0\x1b+K\x1bNqq\x1bO~~")
+	assert.Equal(t, "─qß~", firstLine(terminal))
+}
+
+func TestSaveCursorRestoresCharsets(t *testing.T) {
+	terminal := newTestTerminal(40, 4)
+	process(terminal, "\x1b*0\x1bn\x1b7\x1b*B\x0fq")
+	assert.Equal(t, "q", firstLine(terminal))
+	assert.Equal(t, 0, terminal.terminalState.CurrentCharset)
+
+	// the cursor returns to the start of the line, so the character is overwritten
+	process(terminal, "\x1b8q")
+	assert.Equal(t, "─", firstLine(terminal))
+	assert.Equal(t, 2, terminal.terminalState.CurrentCharset)
+}
+
+func TestUnknownCharsetFallsBackToASCII(t *testing.T) {
+	terminal := newTestTerminal(40, 4)
+	process(terminal, "\x1b(0\x1b(Xq")
+	assert.Equal(t, "q", firstLine(terminal))
+}
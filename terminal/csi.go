@@ -10,6 +10,7 @@ type csiSequenceHandler func(params []string, terminal *Terminal) error
 
 type csiMapping struct {
 	id             rune
+	intermediate   string // intermediate bytes (0x20-0x2F) that must precede the final byte
 	handler        csiSequenceHandler
 	description    string
 	expectedParams *expectedParams
@@ -27,10 +28,23 @@ var csiSequences = []csiMapping{
 	{id: 'g', handler: csiTabClearHandler, description: "Tab Clear (TBC)"},
 	{id: 'h', handler: csiSetModeHandler, expectedParams: &expectedParams{min: 1, max: ^uint8(0)}, description: "Set Mode (SM)"},
 	{id: 'l', handler: csiResetModeHandler, expectedParams: &expectedParams{min: 1, max: ^uint8(0)}, description: "Reset Mode (RM)"},
+	{id: 'i', handler: csiMediaCopyHandler, description: "Media Copy (MC)"},
 	{id: 'm', handler: sgrSequenceHandler, description: "Character Attributes (SGR)"},
 	{id: 'n', handler: csiDeviceStatusReportHandler, description: "Device Status Report (DSR)"},
+	{id: 'q', intermediate: "\"", handler: csiSelectCharacterProtectionHandler, description: "Select Character Protection Attribute (DECSCA), VT220"},
 	{id: 'r', handler: csiSetMarginsHandler, expectedParams: &expectedParams{min: 0, max: 2}, description: "Set Scrolling Region [top;bottom] (default = full size of window) (DECSTBM), VT100"},
 	{id: 't', handler: csiWindowManipulation, description: "Window manipulation"},
+	{id: 'r', intermediate: "$", handler: csiChangeAreaAttributesHandler, description: "Change Attributes in Rectangular Area (DECCARA), VT400"},
+	{id: 't', intermediate: "$", handler: csiReverseAreaAttributesHandler, description: "Reverse Attributes in Rectangular Area (DECRARA), VT400"},
+	{id: 'w', intermediate: "$", handler: csiRequestPresentationStateReportHandler, description: "Request Presentation State Report (DECRQPSR), VT320"},
+	{id: 'v', intermediate: "$", handler: csiCopyAreaHandler, description: "Copy Rectangular Area (DECCRA), VT400"},
+	{id: 'x', intermediate: "$", handler: csiFillAreaHandler, description: "Fill Rectangular Area (DECFRA), VT420"},
+	{id: 'x', intermediate: "*", handler: csiSelectAttributeChangeExtentHandler, description: "Select Attribute Change Extent (DECSACE), VT420"},
+	{id: 'y', intermediate: "*", handler: csiRequestAreaChecksumHandler, description: "Request Checksum of Rectangular Area (DECRQCRA), VT420"},
+	{id: 'z', intermediate: "$", handler: csiEraseAreaHandler, description: "Erase Rectangular Area (DECERA), VT400"},
+	{id: '{', intermediate: "$", handler: csiSelectiveEraseAreaHandler, description: "Selective Erase Rectangular Area (DECSERA), VT400"},
+	{id: '~', intermediate: "$", handler: csiSelectStatusLineTypeHandler, description: "Select Status Line Type (DECSSDT), VT320"},
+	{id: '}', intermediate: "$", handler: csiSelectActiveStatusDisplayHandler, description: "Select Active Status Display (DECSASD), VT320"},
 	{id: 'A', handler: csiCursorUpHandler, description: "Cursor Up Ps Times (default = 1) (CUU)"},
 	{id: 'B', handler: csiCursorDownHandler, description: "Cursor Down Ps Times (default = 1) (CUD)"},
 	{id: 'C', handler: csiCursorForwardHandler, description: "Cursor Forward Ps Times (default = 1) (CUF)"},
@@ -46,6 +60,7 @@ var csiSequences = []csiMapping{
 	{id: 'P', handler: csiDeleteHandler, description: " Delete Ps Character(s) (default = 1) (DCH)"},
 	{id: 'S', handler: csiScrollUpHandler, description: "Scroll up Ps lines (default = 1) (SU), VT420, ECMA-48"},
 	{id: 'T', handler: csiScrollDownHandler, description: "Scroll down Ps lines (default = 1) (SD), VT420"},
+	{id: 'W', handler: csiCursorTabulationControlHandler, description: "Cursor Tabulation Control (CTC), Set Tab at every 8 columns (DECST8C)"},
 	{id: 'X', handler: csiEraseCharactersHandler, description: "Erase Ps Character(s) (default = 1) (ECH"},
 	{id: '@', handler: csiInsertBlankCharactersHandler, description: "Insert Ps (Blank) Character(s) (default = 1) (ICH)"},
 }
@@ -57,13 +72,17 @@ type runeRange struct {
 
 var csiTerminators = runeRange{0x40, 0x7e}
 
-func loadCSI(pty chan rune) (final rune, param string, intermediate []rune) {
+func loadCSI(pty chan rune, terminal *Terminal) (final rune, param string, intermediate []rune, err error) {
 	var b rune
+	var ok bool
 	param = ""
 	intermediate = []rune{}
 CSI:
 	for {
-		b = <-pty
+		b, ok = terminal.nextRune(pty)
+		if !ok {
+			return final, param, intermediate, errTruncatedSequence
+		}
 		switch true {
 		case b >= 0x30 && b <= 0x3F:
 			param = param + string(b)
@@ -75,7 +94,7 @@ CSI:
 		}
 	}
 
-	return final, param, intermediate
+	return final, param, intermediate, nil
 }
 
 func splitParams(paramString string) []string {
@@ -87,17 +106,25 @@ func splitParams(paramString string) []string {
 }
 
 func csiHandler(pty chan rune, terminal *Terminal) error {
-	final, param, intermediate := loadCSI(pty)
+	final, param, intermediate, err := loadCSI(pty, terminal)
+	if err != nil {
+		return err
+	}
 
-	// process intermediate control codes before the CSI
+	// process control codes embedded in the sequence before the CSI, keeping genuine intermediate bytes
+	intermediateChars := ""
 	for _, b := range intermediate {
-		terminal.processRune(b)
+		if b < 0x20 {
+			terminal.processRune(b)
+		} else {
+			intermediateChars += string(b)
+		}
 	}
 
 	params := splitParams(param)
 
 	for _, sequence := range csiSequences {
-		if sequence.id == final {
+		if sequence.id == final && sequence.intermediate == intermediateChars {
 			if sequence.expectedParams != nil && (uint8(len(params)) < sequence.expectedParams.min || uint8(len(params)) > sequence.expectedParams.max) {
 				continue
 			}
@@ -108,7 +135,7 @@ func csiHandler(pty chan rune, terminal *Terminal) error {
 		}
 	}
 
-	return fmt.Errorf("Unknown CSI control sequence: 0x%02X (ESC[%s%s)", final, param, string(final))
+	return fmt.Errorf("Unknown CSI control sequence: 0x%02X (ESC[%s%s%s)", final, param, intermediateChars, string(final))
 }
 
 func csiSendDeviceAttributesHandler(params []string, terminal *Terminal) error {
@@ -123,7 +150,7 @@ func csiSendDeviceAttributesHandler(params []string, terminal *Terminal) error {
 		response = ">0;0;0"
 	}
 
-	_ = terminal.Write([]byte("\x1b[" + response + "c"))
+	_ = terminal.Write([]byte(terminal.c1(c1CSI) + response + "c"))
 
 	return nil
 }
@@ -136,10 +163,11 @@ func csiDeviceStatusReportHandler(params []string, terminal *Terminal) error {
 
 	switch params[0] {
 	case "5":
-		_ = terminal.Write([]byte("\x1b[0n")) // everything is cool
+		_ = terminal.Write([]byte(terminal.c1(c1CSI) + "0n")) // everything is cool
 	case "6": // report cursor position
 		_ = terminal.Write([]byte(fmt.Sprintf(
-			"\x1b[%d;%dR",
+			"%s%d;%dR",
+			terminal.c1(c1CSI),
 			terminal.ActiveBuffer().CursorLine()+1,
 			terminal.ActiveBuffer().CursorColumn()+1,
 		)))
@@ -463,7 +491,7 @@ func csiTabClearHandler(params []string, terminal *Terminal) error {
 	switch n {
 
 	case "0", "":
-		terminal.terminalState.TabClearAtCursor()
+		terminal.ActiveBuffer().TabClearAtCursor()
 	case "3":
 		terminal.terminalState.TabZonk()
 	default:
@@ -473,6 +501,50 @@ func csiTabClearHandler(params []string, terminal *Terminal) error {
 	return nil
 }
 
+// CSI Ps W
+func csiCursorTabulationControlHandler(params []string, terminal *Terminal) error {
+	n := "0"
+	if len(params) > 0 {
+		n = params[0]
+	}
+
+	switch n {
+	case "0", "":
+		terminal.ActiveBuffer().TabSetAtCursor()
+	case "2":
+		terminal.ActiveBuffer().TabClearAtCursor()
+	case "5":
+		terminal.terminalState.TabZonk()
+	case "?5": // DECST8C
+		terminal.terminalState.TabSetEvery(8)
+	default:
+		return fmt.Errorf("Unsupported CTC: CSI %s W", n)
+	}
+
+	return nil
+}
+
+// CSI Ps $ w
+func csiRequestPresentationStateReportHandler(params []string, terminal *Terminal) error {
+	n := "0"
+	if len(params) > 0 {
+		n = params[0]
+	}
+
+	switch n {
+	case "2": // DECTABSR
+		stops := []string{}
+		for _, stop := range terminal.terminalState.TabStops() {
+			stops = append(stops, strconv.Itoa(int(stop)+1))
+		}
+		_ = terminal.Write([]byte(fmt.Sprintf("%s2$u%s%s", terminal.c1(c1DCS), strings.Join(stops, "/"), terminal.c1(c1ST))))
+	default:
+		return fmt.Errorf("Unsupported DECRQPSR: CSI %s $ w", n)
+	}
+
+	return nil
+}
+
 // CSI Ps J
 func csiEraseInDisplayHandler(params []string, terminal *Terminal) error {
 	n := "0"
@@ -480,14 +552,20 @@ func csiEraseInDisplayHandler(params []string, terminal *Terminal) error {
 		n = params[0]
 	}
 
+	if strings.HasPrefix(n, "?") {
+		return csiSelectiveEraseInDisplayHandler(n[1:], terminal)
+	}
+
 	switch n {
 
 	case "0", "":
 		terminal.ActiveBuffer().EraseDisplayFromCursor()
 	case "1":
 		terminal.ActiveBuffer().EraseDisplayToCursor()
-	case "2", "3":
+	case "2":
 		terminal.ActiveBuffer().EraseDisplay()
+	case "3":
+		terminal.ActiveBuffer().ClearScrollback()
 	default:
 		return fmt.Errorf("Unsupported ED: CSI %s J", n)
 	}
@@ -495,6 +573,22 @@ func csiEraseInDisplayHandler(params []string, terminal *Terminal) error {
 	return nil
 }
 
+// CSI ? Ps J
+func csiSelectiveEraseInDisplayHandler(n string, terminal *Terminal) error {
+	switch n {
+	case "0", "":
+		terminal.ActiveBuffer().SelectiveEraseDisplayFromCursor()
+	case "1":
+		terminal.ActiveBuffer().SelectiveEraseDisplayToCursor()
+	case "2":
+		terminal.ActiveBuffer().SelectiveEraseDisplay()
+	default:
+		return fmt.Errorf("Unsupported DECSED: CSI ?%s J", n)
+	}
+
+	return nil
+}
+
 // CSI Ps K
 func csiEraseInLineHandler(params []string, terminal *Terminal) error {
 
@@ -503,6 +597,10 @@ func csiEraseInLineHandler(params []string, terminal *Terminal) error {
 		n = params[0]
 	}
 
+	if strings.HasPrefix(n, "?") {
+		return csiSelectiveEraseInLineHandler(n[1:], terminal)
+	}
+
 	switch n {
 	case "0", "": //erase adter cursor
 		terminal.ActiveBuffer().EraseLineFromCursor()
@@ -515,3 +613,36 @@ func csiEraseInLineHandler(params []string, terminal *Terminal) error {
 	}
 	return nil
 }
+
+// CSI ? Ps K
+func csiSelectiveEraseInLineHandler(n string, terminal *Terminal) error {
+	switch n {
+	case "0", "":
+		terminal.ActiveBuffer().SelectiveEraseLineFromCursor()
+	case "1":
+		terminal.ActiveBuffer().SelectiveEraseLineToCursor()
+	case "2":
+		terminal.ActiveBuffer().SelectiveEraseLine()
+	default:
+		return fmt.Errorf("Unsupported DECSEL: CSI ?%s K", n)
+	}
+	return nil
+}
+
+// CSI Ps " q
+func csiSelectCharacterProtectionHandler(params []string, terminal *Terminal) error {
+	n := "0"
+	if len(params) > 0 {
+		n = params[0]
+	}
+
+	switch n {
+	case "0", "", "2":
+		terminal.ActiveBuffer().CursorAttr().Protected = false
+	case "1":
+		terminal.ActiveBuffer().CursorAttr().Protected = true
+	default:
+		return fmt.Errorf("Unsupported DECSCA: CSI %s \" q", n)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEraseInDisplayScrollback(t *testing.T) {
+	terminal := newTestTerminal(20, 3)
+	process(terminal, "1\r\n2\r\n3\r\n4\r\n5")
+	assert.Equal(t, 5, terminal.ActiveBuffer().Height())
+
+	// ED 2 clears the screen, but leaves the history alone
+	process(terminal, "\x1b[2J")
+	assert.Equal(t, 5, terminal.ActiveBuffer().Height())
+	assert.Equal(t, []string{"", "", ""}, screenText(terminal))
+
+	// ED 3 only clears the history
+	process(terminal, "\x1b[Hvisible\x1b[3J")
+	assert.Equal(t, 3, terminal.ActiveBuffer().Height())
+	assert.Equal(t, "visible", screenText(terminal)[0])
+}
@@ -0,0 +1,65 @@
+package terminal
+
+import "fmt"
+
+// https://vt100.net/docs/vt510-rm/chapter4.html#S4.3.4
+
+// dcsHandler reads the parameters and intermediates of a device control string, then passes it on
+// according to its final character. Anything not recognised is treated as sixel data.
+func dcsHandler(pty chan rune, terminal *Terminal) error {
+	prefix := []rune{}
+	for {
+		b, ok := terminal.nextRune(pty)
+		if !ok {
+			return errTruncatedSequence
+		}
+		prefix = append(prefix, b)
+		if b < 0x20 || b > 0x3f {
+			break
+		}
+	}
+
+	final := prefix[len(prefix)-1]
+	params := string(prefix[:len(prefix)-1])
+
+	switch final {
+	case '{':
+		return softFontHandler(params, pty, terminal)
+	}
+
+	return sixelHandler(prefix, pty, terminal)
+}
+
+// interruptedStringError is returned when a device control string is ended by an escape sequence other than ST.
+// It holds the byte which followed ESC, so that the sequence can still be handled.
+type interruptedStringError rune
+
+func (err interruptedStringError) Error() string {
+	return fmt.Sprintf("Device control string interrupted by ESC %c", rune(err))
+}
+
+// readStringTerminatedData reads the data of a device control string up to the string terminator (ST)
+func readStringTerminatedData(pty chan rune, terminal *Terminal) ([]rune, error) {
+	data := []rune{}
+	for {
+		b, ok := terminal.nextRune(pty)
+		if !ok {
+			return nil, errTruncatedSequence
+		}
+		if b == 0x1b {
+			next, ok := terminal.nextRune(pty)
+			if !ok {
+				return nil, errTruncatedSequence
+			}
+			if next == '\\' { // ST
+				return data, nil
+			}
+			// any other escape sequence abandons the string, and is carried out as usual
+			return nil, interruptedStringError(next)
+		}
+		if b == 0x18 || b == 0x1a { // CAN, SUB
+			return nil, fmt.Errorf("Device control string cancelled")
+		}
+		data = append(data, b)
+	}
+}
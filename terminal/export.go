@@ -0,0 +1,37 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/liamg/aminal/export"
+)
+
+// Export writes the scrollback and the screen in the given format
+func (terminal *Terminal) Export(w io.Writer, format export.Format) error {
+	return export.Write(w, format, terminal.ScreenBuffer().AllLines(), terminal.settings.ColourScheme)
+}
+
+// ExportToFile exports the scrollback and the screen to a file, choosing the format from its extension
+func (terminal *Terminal) ExportToFile(path string) error {
+	format, err := export.FormatForPath(path)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("Failed to create export directory: %s", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Failed to create export: %s", err)
+	}
+	defer file.Close()
+
+	if err := terminal.Export(file, format); err != nil {
+		return fmt.Errorf("Failed to write export: %s", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,64 @@
+package terminal
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/liamg/aminal/export"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportANSIReproducesScreen(t *testing.T) {
+	original := NewHeadless(20, 5, HeadlessOptions{})
+	defer original.Close()
+	original.FeedString("\x1b[1;31mbold red\x1b[0m plain\r\n\x1b[44;97m white on blue \x1b[0m\r\n" +
+		"\x1b[38;2;10;20;30;4mtrue colour\x1b[7m inverse\x1b[0m\r\n\x1b[3;33mitalic\x1b[23m upright\r\n")
+
+	out := &bytes.Buffer{}
+	require.NoError(t, original.Export(out, export.ANSI))
+
+	copied := NewHeadless(20, 5, HeadlessOptions{})
+	defer copied.Close()
+	copied.Feed(out.Bytes())
+
+	assert.Equal(t, original.Text(), copied.Text())
+	assert.True(t, original.Cell(0, 3).Attr().Italic)
+	assert.False(t, original.Cell(7, 3).Attr().Italic)
+	for row := uint16(0); row < 4; row++ {
+		for col := uint16(0); col < 20; col++ {
+			expected, actual := original.Cell(col, row), copied.Cell(col, row)
+			assert.Equal(t, expected.Rune(), actual.Rune(), "cell %d,%d", col, row)
+			if expected.Rune() != 0 {
+				// blank cells keep the foreground colour they were erased with, which is not visible
+				assert.Equal(t, expected.Fg(), actual.Fg(), "cell %d,%d", col, row)
+			}
+			assert.Equal(t, expected.Bg(), actual.Bg(), "cell %d,%d", col, row)
+			assert.Equal(t, expected.Attr().Bold, actual.Attr().Bold, "cell %d,%d", col, row)
+			assert.Equal(t, expected.Attr().Italic, actual.Attr().Italic, "cell %d,%d", col, row)
+			assert.Equal(t, expected.Attr().Underline, actual.Attr().Underline, "cell %d,%d", col, row)
+		}
+	}
+}
+
+func TestExportToFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aminal-export")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	headless := NewHeadless(20, 5, HeadlessOptions{})
+	defer headless.Close()
+	headless.FeedString("hello")
+
+	path := filepath.Join(dir, "exports", "screen.html")
+	require.NoError(t, headless.ExportToFile(path))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `<pre class="aminal">hello</pre>`)
+
+	assert.Error(t, headless.ExportToFile(filepath.Join(dir, "screen.png")))
+}
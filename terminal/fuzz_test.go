@@ -0,0 +1,16 @@
+//go:build go1.18
+// +build go1.18
+
+package terminal
+
+import "testing"
+
+func FuzzProcessInput(f *testing.F) {
+	for _, input := range parserRegressionInputs {
+		f.Add([]byte(input))
+	}
+	f.Add([]byte("hello\r\n\x1b[1;31mworld\x1b[0m\x1b[5;5H\x1b[K\x1b[3@\x1b[2P\tx"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		feedAndCheck(t, data)
+	})
+}
@@ -0,0 +1,145 @@
+package terminal
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/liamg/aminal/asciicast"
+	"github.com/liamg/aminal/platform"
+)
+
+// HeadlessOptions configures a headless terminal. The zero value gives the same defaults as the GUI.
+type HeadlessOptions Options
+
+// Headless is a terminal emulator which runs without a GUI or a real pty. Output from the host is fed in
+// synchronously, and the resulting screen can be queried directly.
+type Headless struct {
+	*Terminal
+	host      io.ReadWriteCloser
+	input     chan rune // read by the parser on its own goroutine, so it keeps its place in a sequence between feeds
+	fed       int       // runes sent to the parser
+	partial   []byte    // the start of a UTF-8 sequence which has not been completely fed yet
+	inputLock sync.Mutex
+	inputCond *sync.Cond
+	caughtUp  int // runes the parser had read when it last ran out of input
+	replyLock sync.Mutex
+	replyCond *sync.Cond
+	replies   []byte
+	written   int // bytes the terminal has written to the host
+	received  int // bytes collected from the host side of the pipe
+}
+
+// headlessPty counts the replies written by the terminal, so the headless terminal knows when it has collected them all
+type headlessPty struct {
+	platform.Pty
+	headless *Headless
+}
+
+func (pty *headlessPty) Write(b []byte) (int, error) {
+	n, err := pty.Pty.Write(b)
+	pty.headless.replyLock.Lock()
+	pty.headless.written += n
+	pty.headless.replyLock.Unlock()
+	return n, err
+}
+
+// NewHeadless creates a terminal of the given size using an in-memory pty
+func NewHeadless(cols uint, rows uint, opts HeadlessOptions) *Headless {
+	pty, host := platform.NewPipePty()
+	headless := &Headless{host: host, input: make(chan rune, 4096)}
+	headless.replyCond = sync.NewCond(&headless.replyLock)
+	headless.inputCond = sync.NewCond(&headless.inputLock)
+	headless.Terminal = NewWithOptions(&headlessPty{Pty: pty, headless: headless}, nil, Options(opts))
+	headless.inputWaiting = headless.parserWaiting
+	headless.SetCharSize(8, 16)
+	headless.SetSize(cols, rows)
+
+	go headless.collectReplies()
+	go headless.processInput(headless.input)
+	return headless
+}
+
+// collectReplies reads everything the terminal sends to the host, so that queries never block feeding
+func (headless *Headless) collectReplies() {
+	data := make([]byte, 4096)
+	for {
+		n, err := headless.host.Read(data)
+		headless.replyLock.Lock()
+		headless.replies = append(headless.replies, data[:n]...)
+		headless.received += n
+		headless.replyCond.Broadcast()
+		headless.replyLock.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Feed processes output from the host, returning once the screen has been updated. Escape sequences and
+// UTF-8 characters may be split across calls. The start of a UTF-8 character at the end is held back until the
+// rest of it arrives; call Flush once all of the output has been fed, in case it never does.
+func (headless *Headless) Feed(data []byte) {
+	// hold back an incomplete UTF-8 sequence at the end until the rest of it arrives
+	data, headless.partial = asciicast.SplitIncompleteUTF8(append(headless.partial, data...))
+	headless.process(data)
+}
+
+// Flush processes the start of a UTF-8 character held back by Feed as raw bytes, as it will not be completed
+func (headless *Headless) Flush() {
+	data := headless.partial
+	headless.partial = nil
+	headless.process(data)
+}
+
+func (headless *Headless) process(data []byte) {
+	reader := bufio.NewReader(bytes.NewReader(data))
+	for {
+		r, err := readRune(reader)
+		if err != nil {
+			break
+		}
+		headless.input <- r
+		headless.fed++
+	}
+
+	headless.inputLock.Lock()
+	for headless.caughtUp != headless.fed {
+		headless.inputCond.Wait()
+	}
+	headless.inputLock.Unlock()
+}
+
+// parserWaiting is called by the parser when it has read everything fed to it
+func (headless *Headless) parserWaiting(read int) {
+	headless.inputLock.Lock()
+	headless.caughtUp = read
+	headless.inputCond.Broadcast()
+	headless.inputLock.Unlock()
+}
+
+// FeedString processes output from the host given as a string
+func (headless *Headless) FeedString(data string) {
+	headless.Feed([]byte(data))
+}
+
+// Replies returns everything the terminal has sent back to the host since the last call, such as query responses
+func (headless *Headless) Replies() []byte {
+	headless.replyLock.Lock()
+	defer headless.replyLock.Unlock()
+
+	for headless.received < headless.written {
+		headless.replyCond.Wait()
+	}
+	replies := headless.replies
+	headless.replies = nil
+	return replies
+}
+
+// Close stops the parser and shuts down the in-memory pty
+func (headless *Headless) Close() error {
+	close(headless.input)
+	headless.CloseScrollback()
+	return headless.host.Close()
+}
@@ -0,0 +1,108 @@
+package terminal
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/liamg/aminal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeadlessFeedAndQuery(t *testing.T) {
+	headless := NewHeadless(20, 3, HeadlessOptions{})
+	defer headless.Close()
+
+	headless.FeedString("hello\r\n\x1b[1;31mworld\x1b[0m")
+	assert.Equal(t, []string{"hello", "world", ""}, headless.Lines())
+	assert.Equal(t, "hello\nworld\n", headless.Text())
+
+	cell := headless.Cell(0, 1)
+	assert.Equal(t, 'w', cell.Rune())
+	assert.True(t, cell.Attr().Bold)
+	assert.False(t, headless.Cell(0, 0).Attr().Bold)
+
+	col, row := headless.Cursor()
+	assert.Equal(t, uint16(5), col)
+	assert.Equal(t, uint16(1), row)
+	assert.True(t, headless.CursorVisible())
+
+	headless.FeedString("\x1b[?25l")
+	assert.False(t, headless.CursorVisible())
+}
+
+func TestHeadlessFeedSplitSequences(t *testing.T) {
+	headless := NewHeadless(20, 3, HeadlessOptions{})
+	defer headless.Close()
+
+	// an escape sequence and a UTF-8 character, each split across feeds
+	headless.FeedString("a\x1b[")
+	headless.FeedString("3")
+	headless.FeedString("Cb")
+	headless.Feed([]byte{0xc3})
+	headless.Feed([]byte{0xa9})
+
+	assert.Equal(t, "a   bé", headless.Lines()[0])
+}
+
+func TestHeadlessFlushesBytesWhichNeverFormACharacter(t *testing.T) {
+	headless := NewHeadless(20, 3, HeadlessOptions{})
+	defer headless.Close()
+
+	// 0xe9 starts a three byte sequence, but the byte after it doesn't continue one, so it is shown as Latin-1
+	headless.Feed([]byte{'a', 0xe9})
+	assert.Equal(t, "a", headless.Lines()[0])
+	headless.Feed([]byte{'b'})
+	assert.Equal(t, "aéb", headless.Lines()[0])
+
+	headless.Feed([]byte{0xe9})
+	headless.Flush()
+	assert.Equal(t, "aébé", headless.Lines()[0])
+}
+
+func TestHeadlessReplies(t *testing.T) {
+	headless := NewHeadless(20, 3, HeadlessOptions{})
+	defer headless.Close()
+
+	headless.FeedString("\x1b[2;3H\x1b[6n")
+	assert.Equal(t, "\x1b[2;3R", string(headless.Replies()))
+	assert.Empty(t, headless.Replies())
+}
+
+func TestHeadlessOptions(t *testing.T) {
+	headless := NewHeadless(20, 3, HeadlessOptions{TabWidth: 2})
+	defer headless.Close()
+
+	headless.FeedString("\tx")
+	col, _ := headless.Cursor()
+	assert.Equal(t, uint16(3), col)
+}
+
+func TestHeadlessPalette(t *testing.T) {
+	palette := &Palette{Background: color.RGBA{0x10, 0x20, 0x30, 0xff}}
+	palette.ANSI[1] = config.Colour{1, 0.5, 0}
+	headless := NewHeadless(20, 3, HeadlessOptions{Palette: palette})
+	defer headless.Close()
+
+	headless.FeedString("\x1b[31mx")
+	assert.Equal(t, config.Colour{1, 0.5, 0}, config.Colour(headless.Cell(0, 0).Fg()))
+	background, _ := config.Colour(headless.Cell(0, 0).Bg()).MarshalText()
+	assert.Equal(t, "#102030", string(background))
+	assert.Equal(t, config.DefaultColourScheme.Foreground, headless.settings.ColourScheme.Foreground)
+}
+
+func TestHeadlessFeedKeepsItsPlaceInASequence(t *testing.T) {
+	headless := NewHeadless(20, 3, HeadlessOptions{})
+	defer headless.Close()
+
+	// the title is not read again from the start for each chunk
+	title := strings.Repeat("a", 1<<14)
+	headless.FeedString("\x1b]2;")
+	for i := 0; i < len(title); i += 16 {
+		headless.FeedString(title[i : i+16])
+		assert.Equal(t, "", headless.GetTitle())
+	}
+	headless.FeedString("\x07x")
+	assert.Equal(t, title, headless.GetTitle())
+	assert.Equal(t, "x", headless.Lines()[0])
+}
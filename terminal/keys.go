@@ -0,0 +1,179 @@
+package terminal
+
+import "fmt"
+
+// Key is a non-character key on the keyboard
+type Key int
+
+const (
+	KeyUnknown Key = iota
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+	KeyInsert
+	KeyDelete
+	KeyHome
+	KeyEnd
+	KeyPageUp
+	KeyPageDown
+	KeyEscape
+	KeyTab
+	KeyEnter
+	KeyKPEnter
+	KeyBackspace
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+)
+
+// Modifier is a set of modifier keys held down while a key is pressed
+type Modifier uint8
+
+const (
+	ModShift Modifier = 1 << iota
+	ModControl
+	ModAlt
+	ModSuper
+)
+
+// functionKeySequences are the sequences sent by keys which are not affected by any modes or modifiers
+var functionKeySequences = map[Key]string{
+	KeyF1:     "\x1bOP",
+	KeyF2:     "\x1bOQ",
+	KeyF3:     "\x1bOR",
+	KeyF4:     "\x1bOS",
+	KeyF5:     "\x1b[15~",
+	KeyF6:     "\x1b[17~",
+	KeyF7:     "\x1b[18~",
+	KeyF8:     "\x1b[19~",
+	KeyF9:     "\x1b[20~",
+	KeyF10:    "\x1b[21~",
+	KeyF11:    "\x1b[23~",
+	KeyF12:    "\x1b[24~",
+	KeyInsert: "\x1b[2~",
+	KeyDelete: "\x1b[3~",
+	KeyEscape: "\x1b",
+	KeyTab:    "\x09",
+}
+
+var cursorKeyFinals = map[Key]byte{
+	KeyUp:    'A',
+	KeyDown:  'B',
+	KeyRight: 'C',
+	KeyLeft:  'D',
+}
+
+// modifierParam returns the xterm style parameter which encodes the modifiers, or "" if there are none
+func modifierParam(mods Modifier) string {
+	switch mods {
+	case ModControl | ModShift | ModAlt:
+		return "8"
+	case ModControl | ModAlt:
+		return "7"
+	case ModControl | ModShift:
+		return "6"
+	case ModControl:
+		return "5"
+	case ModAlt | ModShift:
+		return "4"
+	case ModAlt:
+		return "3"
+	case ModShift:
+		return "2"
+	}
+	return ""
+}
+
+// returnSequence returns what the return key sends, which depends on the new line mode (LNM)
+func (terminal *Terminal) returnSequence() []byte {
+	if terminal.terminalState.IsNewLineMode() {
+		return []byte{0x0d, 0x0a}
+	}
+	return []byte{0x0d}
+}
+
+// KeySequence returns the bytes sent to the host when the key is pressed, taking the current modes into account
+func (terminal *Terminal) KeySequence(key Key, mods Modifier) []byte {
+	if sequence, ok := functionKeySequences[key]; ok {
+		return []byte(sequence)
+	}
+
+	modStr := modifierParam(mods)
+
+	switch key {
+	case KeyHome:
+		if !terminal.modes.ApplicationCursorKeys {
+			return []byte("\x1b[H")
+		}
+		if modStr == "" {
+			return []byte("\x1b[1~")
+		}
+		return []byte(fmt.Sprintf("\x1b[1;%s~", modStr))
+	case KeyEnd, KeyPageUp, KeyPageDown:
+		code := map[Key]int{KeyEnd: 4, KeyPageUp: 5, KeyPageDown: 6}[key]
+		if modStr == "" {
+			return []byte(fmt.Sprintf("\x1b[%d~", code))
+		}
+		return []byte(fmt.Sprintf("\x1b[%d;%s~", code, modStr))
+	case KeyEnter:
+		return terminal.returnSequence()
+	case KeyKPEnter:
+		if terminal.modes.ApplicationCursorKeys {
+			return []byte("\x1bOM")
+		}
+		return terminal.returnSequence()
+	case KeyBackspace:
+		if mods == ModAlt {
+			return []byte{0x17} // ctrl-w/delete word
+		}
+		return []byte{0x7f} //0x7f is DEL
+	case KeyUp, KeyDown, KeyLeft, KeyRight:
+		final := cursorKeyFinals[key]
+		switch {
+		case modStr != "":
+			return []byte(fmt.Sprintf("\x1b[1;%s%c", modStr, final))
+		case terminal.modes.VT52:
+			return []byte{0x1b, final}
+		case terminal.modes.ApplicationCursorKeys:
+			return []byte{0x1b, 'O', final}
+		default:
+			return []byte{0x1b, '[', final}
+		}
+	}
+
+	return nil
+}
+
+// CharSequence returns the bytes sent for a letter typed while holding Control or Alt,
+// or nil if the combination has no special encoding
+func CharSequence(r rune, mods Modifier) []byte {
+	isLower := r >= 'a' && r <= 'z'
+	isUpper := r >= 'A' && r <= 'Z'
+
+	switch mods {
+	case ModControl:
+		// standard ctrl codes e.g. ^C
+		if isLower {
+			return []byte{byte(r) - 96}
+		} else if isUpper {
+			return []byte{byte(r) - 64}
+		}
+	case ModAlt:
+		// pass through alt codes
+		if isLower || isUpper {
+			return []byte{0x1b, byte(r)}
+		}
+	}
+
+	return nil
+}
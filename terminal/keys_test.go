@@ -0,0 +1,46 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorKeySequences(t *testing.T) {
+	terminal := newTestTerminal(80, 24)
+
+	assert.Equal(t, "\x1b[A", string(terminal.KeySequence(KeyUp, 0)))
+	assert.Equal(t, "\x1b[1;2A", string(terminal.KeySequence(KeyUp, ModShift)))
+	assert.Equal(t, "\x1b[1;5D", string(terminal.KeySequence(KeyLeft, ModControl)))
+
+	process(terminal, "\x1b[?1h")
+	assert.Equal(t, "\x1bOB", string(terminal.KeySequence(KeyDown, 0)))
+	assert.Equal(t, "\x1b[1;3B", string(terminal.KeySequence(KeyDown, ModAlt)))
+
+	process(terminal, "\x1b[?2l")
+	assert.Equal(t, "\x1bC", string(terminal.KeySequence(KeyRight, 0)))
+}
+
+func TestReturnKeySequence(t *testing.T) {
+	terminal := newTestTerminal(80, 24)
+	assert.Equal(t, "\r", string(terminal.KeySequence(KeyEnter, 0)))
+
+	process(terminal, "\x1b[20h")
+	assert.Equal(t, "\r\n", string(terminal.KeySequence(KeyEnter, 0)))
+}
+
+func TestFunctionKeySequences(t *testing.T) {
+	terminal := newTestTerminal(80, 24)
+	assert.Equal(t, "\x1bOP", string(terminal.KeySequence(KeyF1, 0)))
+	assert.Equal(t, "\x1b[24~", string(terminal.KeySequence(KeyF12, 0)))
+	assert.Equal(t, "\x1b[5;5~", string(terminal.KeySequence(KeyPageUp, ModControl)))
+	assert.Equal(t, "\x1b[H", string(terminal.KeySequence(KeyHome, 0)))
+}
+
+func TestCharSequence(t *testing.T) {
+	assert.Equal(t, []byte{0x03}, CharSequence('c', ModControl))
+	assert.Equal(t, []byte{0x03}, CharSequence('C', ModControl))
+	assert.Equal(t, []byte{0x1b, 'x'}, CharSequence('x', ModAlt))
+	assert.Nil(t, CharSequence('x', 0))
+	assert.Nil(t, CharSequence('1', ModControl))
+}
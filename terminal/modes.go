@@ -41,7 +41,7 @@ func csiSetModes(modes []string, enabled bool, terminal *Terminal) error {
 	}
 
 	if len(errorStrings) > 0 {
-		return fmt.Errorf(strings.Join(errorStrings, "\n"))
+		return errors.New(strings.Join(errorStrings, "\n"))
 	}
 
 	return nil
@@ -82,6 +82,11 @@ func csiSetMode(modeStr string, enabled bool, terminal *Terminal) error {
 		}
 	case "?1":
 		terminal.modes.ApplicationCursorKeys = enabled
+	case "?2":
+		// DECANM - setting has no effect, since VT52 mode is left with ESC <
+		if !enabled {
+			terminal.modes.VT52 = true
+		}
 	case "?3":
 		_, lines := terminal.GetSize()
 		if enabled {
@@ -118,12 +123,22 @@ func csiSetMode(modeStr string, enabled bool, terminal *Terminal) error {
 		terminal.modes.BlinkingCursor = enabled
 	case "?25":
 		terminal.modes.ShowCursor = enabled
-	case "?47", "?1047":
+	case "?47":
 		if enabled {
 			terminal.UseAltBuffer()
 		} else {
 			terminal.UseMainBuffer()
 		}
+	case "?1047":
+		if enabled {
+			terminal.UseAltBuffer()
+		} else {
+			// the alternate screen is cleared on the way out, if we are actually on it
+			if terminal.activeBuffer == terminal.buffers[AltBuffer] {
+				terminal.ActiveBuffer().EraseDisplay()
+			}
+			terminal.UseMainBuffer()
+		}
 	case "?1000", "?10061000": // ?10061000 seen from htop
 		// enable mouse tracking
 		// 1000 refers to ext mode for extended mouse click area - otherwise only x <= 255-31
@@ -180,9 +195,10 @@ func csiSetMode(modeStr string, enabled bool, terminal *Terminal) error {
 		}
 	case "?1049":
 		if enabled {
-			terminal.UseAltBuffer()
+			terminal.enterAltScreen()
 		} else {
 			terminal.UseMainBuffer()
+			terminal.ActiveBuffer().RestoreCursor()
 		}
 	case "?2004":
 		terminal.SetBracketedPasteMode(enabled)
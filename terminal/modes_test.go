@@ -0,0 +1,123 @@
+package terminal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func cursorPosition(terminal *Terminal) (uint16, uint16) {
+	return terminal.ActiveBuffer().CursorColumn(), terminal.ActiveBuffer().CursorLineAbsolute()
+}
+
+func screenText(terminal *Terminal) []string {
+	text := []string{}
+	for _, line := range terminal.ActiveBuffer().GetVisibleLines() {
+		text = append(text, line.String())
+	}
+	return text
+}
+
+func TestAltScreen47KeepsContentAndCursors(t *testing.T) {
+	terminal := newTestTerminal(20, 5)
+	process(terminal, "main\r\n$ ")
+
+	process(terminal, "\x1b[?47h")
+	assert.False(t, terminal.UsingMainBuffer())
+	process(terminal, "\x1b[4;10Halt")
+
+	process(terminal, "\x1b[?47l")
+	assert.True(t, terminal.UsingMainBuffer())
+	assert.Equal(t, "main", screenText(terminal)[0])
+	col, line := cursorPosition(terminal)
+	assert.Equal(t, uint16(2), col)
+	assert.Equal(t, uint16(1), line)
+
+	// without 1047 or 1049 the alternate screen is not cleared
+	process(terminal, "\x1b[?47h")
+	assert.Equal(t, "\x00\x00\x00\x00\x00\x00\x00\x00\x00alt", screenText(terminal)[3])
+	col, line = cursorPosition(terminal)
+	assert.Equal(t, uint16(12), col)
+	assert.Equal(t, uint16(3), line)
+}
+
+func TestAltScreen1047ClearsOnExit(t *testing.T) {
+	terminal := newTestTerminal(20, 5)
+	process(terminal, "\x1b[?1047hfull screen app\x1b[?1047l")
+	assert.True(t, terminal.UsingMainBuffer())
+
+	process(terminal, "\x1b[?1047h")
+	for _, line := range screenText(terminal) {
+		assert.Equal(t, "", line)
+	}
+}
+
+func TestAltScreen1049SavesAndRestoresCursor(t *testing.T) {
+	terminal := newTestTerminal(20, 5)
+	process(terminal, "\x1b[?47hstale\x1b[?47l")
+	process(terminal, "one\r\ntwo\r\n$ ")
+
+	process(terminal, "\x1b[?1049h")
+	assert.False(t, terminal.UsingMainBuffer())
+	for _, line := range screenText(terminal) {
+		assert.Equal(t, "", line)
+	}
+	// the cursor starts where it was on the main screen
+	col, line := cursorPosition(terminal)
+	assert.Equal(t, uint16(2), col)
+	assert.Equal(t, uint16(2), line)
+
+	process(terminal, "\x1b[H\x1b[2J\x1b[5;1H~\x1b[?1049l")
+	assert.True(t, terminal.UsingMainBuffer())
+	assert.Equal(t, []string{"one", "two", "$"}, screenText(terminal))
+	col, line = cursorPosition(terminal)
+	assert.Equal(t, uint16(2), col)
+	assert.Equal(t, uint16(2), line)
+}
+
+func TestAltScreenHasNoScrollback(t *testing.T) {
+	terminal := newTestTerminal(20, 5)
+	process(terminal, "\x1b[?1049h")
+	for i := 0; i < 20; i++ {
+		process(terminal, "line\r\n")
+	}
+	assert.Equal(t, 5, terminal.ActiveBuffer().Height())
+	process(terminal, "\x1b[?1049l")
+}
+
+func TestAltScreenKeepsAltMaxLines(t *testing.T) {
+	settings := DefaultSettings()
+	settings.AltMaxLines = 10
+	terminal := New(&nullPty{}, nil, settings)
+	terminal.SetCharSize(8, 16)
+	terminal.SetSize(20, 5)
+
+	process(terminal, "\x1b[?1049h")
+	for i := 0; i < 30; i++ {
+		process(terminal, "alt\r\n")
+	}
+	assert.Equal(t, 10, terminal.ActiveBuffer().Height())
+
+	// by default the alternate screen has no scrollback
+	terminal = newTestTerminal(20, 5)
+	process(terminal, "\x1b[?1049h")
+	for i := 0; i < 30; i++ {
+		process(terminal, "alt\r\n")
+	}
+	assert.Equal(t, 5, terminal.ActiveBuffer().Height())
+}
+
+func TestMaxScrollbackBytesLimitsTheMainScreen(t *testing.T) {
+	settings := DefaultSettings()
+	settings.MaxScrollbackBytes = 1
+	terminal := New(&nullPty{}, nil, settings)
+	terminal.SetCharSize(8, 16)
+	terminal.SetSize(20, 5)
+
+	for i := 0; i < 30; i++ {
+		process(terminal, fmt.Sprintf("line %d\r\n", i))
+	}
+	assert.Equal(t, 5, terminal.ActiveBuffer().Height())
+	assert.Equal(t, "line 26", screenText(terminal)[0])
+}
@@ -0,0 +1,63 @@
+package terminal
+
+import (
+	"image/color"
+
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/platform"
+)
+
+// Options configures a terminal which is created without a full configuration. The zero value gives the same
+// defaults as the GUI.
+type Options struct {
+	MaxLines uint64 // lines of scrollback, 0 for the default
+	TabWidth uint16 // columns between tab stops, 0 for the default
+
+	Palette *Palette // colours for the defaults and the palette, nil for the default scheme
+}
+
+// Palette is the colours of a terminal. Colours which are nil are taken from the default scheme.
+type Palette struct {
+	Foreground color.Color
+	Background color.Color
+	Cursor     color.Color
+	Selection  color.Color
+	ANSI       [16]color.Color // in the order of their SGR codes
+}
+
+// colourScheme fills in a copy of the scheme with the colours of the palette
+func (palette *Palette) colourScheme(scheme config.ColourScheme) config.ColourScheme {
+	set := func(target *config.Colour, colour color.Color) {
+		switch colour := colour.(type) {
+		case nil:
+		case config.Colour:
+			*target = colour
+		default:
+			r, g, b, _ := colour.RGBA()
+			*target = config.Colour{float32(r) / 0xffff, float32(g) / 0xffff, float32(b) / 0xffff}
+		}
+	}
+	set(&scheme.Foreground, palette.Foreground)
+	set(&scheme.Background, palette.Background)
+	set(&scheme.Cursor, palette.Cursor)
+	set(&scheme.Selection, palette.Selection)
+	for i, target := range scheme.ANSIColours() {
+		set(target, palette.ANSI[i])
+	}
+	return scheme
+}
+
+// NewWithOptions creates a terminal with the default settings, changed by the options. The logger may be nil.
+func NewWithOptions(pty platform.Pty, logger Logger, opts Options) *Terminal {
+	settings := DefaultSettings()
+	if opts.MaxLines > 0 {
+		settings.MaxLines = opts.MaxLines
+	}
+	if opts.TabWidth > 0 {
+		settings.TabWidth = opts.TabWidth
+	}
+	if opts.Palette != nil {
+		settings.ColourScheme = opts.Palette.colourScheme(settings.ColourScheme)
+	}
+	return New(pty, logger, settings)
+}
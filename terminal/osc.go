@@ -11,7 +11,10 @@ func oscHandler(pty chan rune, terminal *Terminal) error {
 	param := ""
 
 	for {
-		b := <-pty
+		b, ok := terminal.nextRune(pty)
+		if !ok {
+			return errTruncatedSequence
+		}
 		if terminal.IsOSCTerminator(b) {
 			params = append(params, param)
 			break
@@ -39,16 +42,20 @@ func oscHandler(pty chan rune, terminal *Terminal) error {
 	switch pS[0] {
 	case "0", "2":
 		terminal.SetTitle(pT)
+	case "4": // get/set palette colours
+		return terminal.handlePaletteOSC(params[1:])
+	case "104": // reset palette colours
+		return terminal.handlePaletteResetOSC(params[1:])
 	case "10": // get/set foreground colour
 		if len(pS) > 1 {
 			if pS[1] == "?" {
-				terminal.Write([]byte("\x1b]10;15"))
+				terminal.Write([]byte(terminal.c1(c1OSC) + "10;15"))
 			}
 		}
 	case "11": // get/set background colour
 		if len(pS) > 1 {
 			if pS[1] == "?" {
-				terminal.Write([]byte("\x1b]10;0"))
+				terminal.Write([]byte(terminal.c1(c1OSC) + "10;0"))
 			}
 		}
 	default:
@@ -1,6 +1,7 @@
 package terminal
 
 import (
+	"errors"
 	"time"
 )
 
@@ -13,6 +14,9 @@ type runeHandler func(terminal *Terminal) error
 
 type escapeSequenceHandler func(pty chan rune, terminal *Terminal) error
 
+// errTruncatedSequence is returned by handlers when the input ends in the middle of a sequence
+var errTruncatedSequence = errors.New("Input ended in the middle of an escape sequence")
+
 var runeMap = map[rune]runeHandler{
 	0x05: enqHandler,
 	0x07: bellHandler,
@@ -27,6 +31,7 @@ var runeMap = map[rune]runeHandler{
 }
 
 func newLineHandler(terminal *Terminal) error {
+	terminal.autoPrintLine()
 	terminal.ActiveBuffer().NewLine()
 	terminal.isDirty = true
 	return nil
@@ -81,22 +86,14 @@ func (terminal *Terminal) processRune(b rune) {
 		return
 	}
 	//terminal.logger.Debugf("Received character 0x%X: %q", b, string(b))
+	if terminal.modes.AutoPrint && terminal.terminalState.AutoWrap && terminal.ActiveBuffer().CursorColumn() >= terminal.ActiveBuffer().CursorLineWidth() {
+		// the character is about to wrap onto the next line
+		terminal.autoPrintLine()
+	}
 	terminal.ActiveBuffer().Write(terminal.translateRune(b))
 	terminal.isDirty = true
 }
 
-func (terminal *Terminal) translateRune(b rune) rune {
-	table := terminal.terminalState.Charsets[terminal.terminalState.CurrentCharset]
-	if table == nil {
-		return b
-	}
-	chr, ok := (*table)[b]
-	if ok {
-		return chr
-	}
-	return b
-}
-
 func (terminal *Terminal) processInput(pty chan rune) {
 
 	// https://en.wikipedia.org/wiki/ANSI_escape_code
@@ -105,21 +102,67 @@ func (terminal *Terminal) processInput(pty chan rune) {
 
 	for {
 
-		if terminal.config.Slomo {
+		if terminal.settings.Slomo {
 			time.Sleep(time.Millisecond * 100)
 		}
 
-		b = <-pty
+		var ok bool
+		b, ok = terminal.nextRune(pty)
+		if !ok {
+			return
+		}
+
+		if err := terminal.handleInput(b, pty); err != nil {
+			terminal.logger.Errorf("Error handling escape sequence: %s", err)
+		}
+	}
+}
 
-		if b == 0x1b {
-			//terminal.logger.Debugf("Handling escape sequence: 0x%x", b)
-			if err := ansiHandler(pty, terminal); err != nil {
-				terminal.logger.Errorf("Error handling escape sequence: %s", err)
+// handleInput processes a rune read from the pty, along with the rest of the escape sequence if it starts one
+func (terminal *Terminal) handleInput(b rune, pty chan rune) error {
+	if terminal.printerController != nil {
+		terminal.printerControllerRune(b)
+		return nil
+	}
+
+	if b == 0x1b {
+		//terminal.logger.Debugf("Handling escape sequence: 0x%x", b)
+		err := ansiHandler(pty, terminal)
+		// a device control string cut short by another escape sequence is dropped, and that sequence handled instead
+		for {
+			interrupted, ok := err.(interruptedStringError)
+			if !ok {
+				break
 			}
-			terminal.isDirty = true
-			continue
+			terminal.logger.Debugf("%s", interrupted)
+			err = handleEscapeSequence(rune(interrupted), pty, terminal)
 		}
+		terminal.isDirty = true
+		return err
+	}
+
+	terminal.processRune(b)
+	return nil
+}
 
-		terminal.processRune(b)
+// nextRune reads a rune from the pty for the parser, translating 8-bit C1 controls unless the output is going
+// straight to the printer. If inputWaiting is set, it is told first when there is nothing left to read.
+func (terminal *Terminal) nextRune(pty chan rune) (rune, bool) {
+	if terminal.pendingRune != 0 {
+		b := terminal.pendingRune
+		terminal.pendingRune = 0
+		return b, true
+	}
+	if terminal.inputWaiting != nil && len(pty) == 0 {
+		terminal.inputWaiting(terminal.inputRead)
+	}
+	b, ok := <-pty
+	if !ok {
+		return b, ok
+	}
+	terminal.inputRead++
+	if terminal.printerController == nil {
+		b, terminal.pendingRune = terminal.translateC1(b)
 	}
+	return b, ok
 }
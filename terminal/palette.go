@@ -0,0 +1,96 @@
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+)
+
+// palette returns the colours of the colour scheme, as the colours of cells resolve to them
+func (terminal *Terminal) palette() buffer.Palette {
+	palette := buffer.Palette{
+		DefaultFg: terminal.settings.ColourScheme.Foreground,
+		DefaultBg: terminal.settings.ColourScheme.Background,
+	}
+	for i := range palette.Colours {
+		palette.Colours[i] = terminal.get8BitSGRColour(uint8(i))
+	}
+	return palette
+}
+
+// applyPalette changes the actual colours of the screen and the status line, including those of the text which
+// is already there
+func (terminal *Terminal) applyPalette(palette buffer.Palette) {
+	terminal.terminalState.SetPalette(palette)
+	terminal.statusLineState.SetPalette(palette)
+	terminal.SetDirty()
+}
+
+// SetColourScheme changes the colour scheme, recolouring the text on the screen and in the scrollback.
+// Colours changed by OSC 4 are replaced by those of the scheme.
+func (terminal *Terminal) SetColourScheme(scheme config.ColourScheme) {
+	terminal.settings.ColourScheme = scheme
+	terminal.applyPalette(terminal.palette())
+}
+
+// handlePaletteOSC sets or queries the colours given as pairs of a palette index and a colour specification (OSC 4)
+func (terminal *Terminal) handlePaletteOSC(params []string) error {
+	if len(params)%2 != 0 {
+		return fmt.Errorf("Invalid palette colour change: %s", strings.Join(params, ";"))
+	}
+
+	palette := *terminal.terminalState.Palette()
+	for i := 0; i < len(params); i += 2 {
+		index, err := strconv.Atoi(params[i])
+		if err != nil || index < 0 || index > 255 {
+			return fmt.Errorf("Invalid palette index: %s", params[i])
+		}
+		if params[i+1] == "?" {
+			terminal.Write([]byte(fmt.Sprintf("%s4;%d;%s%s", terminal.c1(c1OSC), index, colourSpec(palette.Colours[index]), terminal.c1(c1ST))))
+			continue
+		}
+		colour, err := config.ParseColourSpec(params[i+1])
+		if err != nil {
+			return err
+		}
+		palette.Colours[index] = colour
+	}
+	terminal.applyPalette(palette)
+	return nil
+}
+
+// handlePaletteResetOSC restores the given palette indexes, or the whole palette if there are none, to the colours
+// of the scheme (OSC 104)
+func (terminal *Terminal) handlePaletteResetOSC(params []string) error {
+	original := terminal.palette()
+	palette := *terminal.terminalState.Palette()
+	reset := false
+	for _, param := range params {
+		if param == "" {
+			continue
+		}
+		index, err := strconv.Atoi(param)
+		if err != nil || index < 0 || index > 255 {
+			return fmt.Errorf("Invalid palette index: %s", param)
+		}
+		palette.Colours[index] = original.Colours[index]
+		reset = true
+	}
+	if !reset {
+		palette.Colours = original.Colours
+	}
+	terminal.applyPalette(palette)
+	return nil
+}
+
+// colourSpec formats a colour as xterm reports it
+func colourSpec(colour [3]float32) string {
+	var components [3]uint16
+	for i, c := range colour {
+		components[i] = uint16(c*0xffff + 0.5)
+	}
+	return fmt.Sprintf("rgb:%04x/%04x/%04x", components[0], components[1], components[2])
+}
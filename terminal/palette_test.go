@@ -0,0 +1,88 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/liamg/aminal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOSC4RecoloursExistingText(t *testing.T) {
+	terminal := newTestTerminal(20, 5)
+	process(terminal, "\x1b[31mred\x1b[38;5;200mpink\x1b[38;2;1;2;3mrgb")
+
+	process(terminal, "\x1b]4;1;rgb:00/00/ff;200;#00ff00\x07")
+	assert.Equal(t, [3]float32{0, 0, 1}, terminal.Cell(0, 0).Fg())
+	assert.Equal(t, [3]float32{0, 1, 0}, terminal.Cell(3, 0).Fg())
+	assert.Equal(t, [3]float32{float32(1) / 0xff, float32(2) / 0xff, float32(3) / 0xff}, terminal.Cell(7, 0).Fg())
+
+	process(terminal, "\x1b]104;1\x07")
+	assert.Equal(t, [3]float32(terminal.settings.ColourScheme.Red), terminal.Cell(0, 0).Fg())
+	assert.Equal(t, [3]float32{0, 1, 0}, terminal.Cell(3, 0).Fg())
+
+	process(terminal, "\x1b]104\x07")
+	assert.Equal(t, terminal.get8BitSGRColour(200), terminal.Cell(3, 0).Fg())
+}
+
+func TestOSC4Query(t *testing.T) {
+	pty := &replyPty{}
+	terminal := newTestTerminal(20, 5)
+	terminal.pty = pty
+
+	process(terminal, "\x1b]4;2;rgb:12/34/56\x07\x1b]4;2;?\x07")
+	assert.Equal(t, "\x1b]4;2;rgb:1212/3434/5656\x1b\\", string(pty.replies))
+}
+
+func TestSetColourSchemeRecoloursExistingText(t *testing.T) {
+	terminal := newTestTerminal(20, 5)
+	process(terminal, "plain\x1b[32mgreen\r\n")
+	for i := 0; i < 10; i++ {
+		process(terminal, "\r\n")
+	}
+
+	scheme := config.DefaultColourScheme
+	scheme.Foreground = config.Colour{0.1, 0.2, 0.3}
+	scheme.Background = config.Colour{0.9, 0.9, 0.9}
+	scheme.Green = config.Colour{0, 0.5, 0}
+	terminal.SetColourScheme(scheme)
+
+	// the text has scrolled back out of the view
+	lines := terminal.ScreenBuffer().AllLines()
+	cells := lines[0].Cells()
+	assert.Equal(t, [3]float32{0.1, 0.2, 0.3}, cells[0].Fg())
+	assert.Equal(t, [3]float32{0.9, 0.9, 0.9}, cells[0].Bg())
+	assert.Equal(t, [3]float32{0, 0.5, 0}, cells[5].Fg())
+}
+
+func TestReverseScreenModeSwapsColoursAsTheyAreRead(t *testing.T) {
+	terminal := newTestTerminal(20, 5)
+	scheme := terminal.settings.ColourScheme
+	process(terminal, "before\x1b[?5hafter")
+
+	for _, col := range []uint16{0, 6} {
+		cell := terminal.Cell(col, 0)
+		assert.Equal(t, [3]float32(scheme.Background), cell.Fg())
+		assert.Equal(t, [3]float32(scheme.Foreground), cell.Bg())
+	}
+
+	process(terminal, "\x1b[?5l")
+	cell := terminal.Cell(6, 0)
+	assert.Equal(t, [3]float32(scheme.Foreground), cell.Fg())
+	assert.Equal(t, [3]float32(scheme.Background), cell.Bg())
+}
+
+func TestSGRWhitesUseTheirOwnSchemeEntries(t *testing.T) {
+	terminal := newTestTerminal(20, 5)
+	theme, err := (&config.Config{}).FindTheme("solarized-dark")
+	assert.NoError(t, err)
+	terminal.SetColourScheme(theme.Colours)
+
+	process(terminal, "\x1b[37ma\x1b[97;47mb\x1b[38;5;7m\x1b[48;5;15mc")
+	lightGrey, _ := config.Colour(terminal.Cell(0, 0).Fg()).MarshalText()
+	assert.Equal(t, "#eee8d5", string(lightGrey))
+	white, _ := config.Colour(terminal.Cell(1, 0).Fg()).MarshalText()
+	assert.Equal(t, "#fdf6e3", string(white))
+	assert.Equal(t, terminal.Cell(0, 0).Fg(), terminal.Cell(1, 0).Bg())
+	assert.Equal(t, terminal.Cell(0, 0).Fg(), terminal.Cell(2, 0).Fg())
+	assert.Equal(t, terminal.Cell(1, 0).Fg(), terminal.Cell(2, 0).Bg())
+}
@@ -0,0 +1,101 @@
+package terminal
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/liamg/aminal/platform"
+)
+
+// nullPty discards everything written to it, so replies from the terminal go nowhere
+type nullPty struct{}
+
+func (pty *nullPty) Read(p []byte) (int, error)  { select {} }
+func (pty *nullPty) Write(p []byte) (int, error) { return len(p), nil }
+func (pty *nullPty) Close() error                { return nil }
+func (pty *nullPty) Resize(x int, y int) error   { return nil }
+func (pty *nullPty) CreateGuestProcess(imagePath string, args ...string) (platform.Process, error) {
+	return nil, fmt.Errorf("Not supported")
+}
+func (pty *nullPty) GetPlatformDependentSettings() platform.PlatformDependentSettings {
+	return platform.PlatformDependentSettings{
+		OSCTerminators: map[rune]struct{}{0x07: {}, 0x5c: {}},
+	}
+}
+
+func newTestTerminal(cols uint, rows uint) *Terminal {
+	settings := DefaultSettings()
+	terminal := New(&nullPty{}, nil, settings)
+	terminal.SetCharSize(8, 16)
+	terminal.SetSize(cols, rows)
+	return terminal
+}
+
+// process runs the input through the parser, returning once all of it has been handled
+func process(terminal *Terminal, input string) {
+	pty := make(chan rune, len(input))
+	for _, r := range input {
+		pty <- r
+	}
+	close(pty)
+	terminal.processInput(pty)
+}
+
+// feedAndCheck runs the input through a fresh terminal and verifies that the parser terminates
+// and the buffer invariants still hold afterwards. The input is processed on the test goroutine, so that a panic
+// in a handler fails the test rather than a goroutine it can't see.
+func feedAndCheck(t *testing.T, data []byte) {
+	terminal := newTestTerminal(20, 8)
+
+	watchdog := time.AfterFunc(5*time.Second, func() {
+		panic(fmt.Sprintf("Parser did not terminate for input %q", data))
+	})
+	process(terminal, string(data))
+	watchdog.Stop()
+
+	buffer := terminal.ActiveBuffer()
+	if buffer.CursorColumn() > buffer.ViewWidth() {
+		t.Fatalf("Cursor column %d is outside of view width %d for input %q", buffer.CursorColumn(), buffer.ViewWidth(), data)
+	}
+	if buffer.CursorLineAbsolute() >= buffer.ViewHeight() {
+		t.Fatalf("Cursor line %d is outside of view height %d for input %q", buffer.CursorLineAbsolute(), buffer.ViewHeight(), data)
+	}
+	for i, line := range buffer.GetVisibleLines() {
+		if len(line.Cells()) > int(buffer.ViewWidth()) {
+			t.Fatalf("Line %d has %d cells, wider than view width %d for input %q", i, len(line.Cells()), buffer.ViewWidth(), data)
+		}
+	}
+}
+
+var parserRegressionInputs = []string{
+	"\x1b",             // truncated escape
+	"\x1b[",            // truncated CSI
+	"\x1b[12;",         // truncated CSI parameters
+	"\x1b]0;title",     // unterminated OSC
+	"\x1bP",            // truncated DCS
+	"\x1bPq#0;2;0;0;0", // unterminated sixel
+	"\x1b(",            // truncated SCS
+	"\x1b*",            // truncated G2 designation
+	"\x1b(%",           // truncated two character designator
+	"\x1b#",            // truncated screen state
+	"\x1b ",            // truncated code announcement
+	"\x1b[?1049h\x1b[2J\x1b[?1049l",
+	"\x1b[?1049h1\r\n2\r\n3\r\n4\r\n5\r\n6\r\n7\r\n8\x1b[H\x1b[L", // insert line on a full screen without scrollback
+	"abcdefghijklmnopqrstuvwxyz\x1b#6\x1b[99C\x1b[3@xy",
+	"\x1b[?2l\x1bY",             // truncated VT52 cursor address
+	"\x1b[?2l\x1bY~~",           // VT52 cursor address outside the screen
+	"\x1bP1;1{",                 // truncated soft font
+	"\x1bP0;1;0;0;0;0;0;0{ @~~", // unterminated soft font
+	"\x1bP0;1;0;999;0;0;999;0{ @~~~~~~~~~~~~/~\x1b\\\x1b( @!", // oversized soft font matrix
+	"\x1b[2M",                          // delete lines on a fresh screen
+	"\x1b[5;1H\x1b[9M",                 // delete more lines than there are below the cursor
+	"\x1b[999999999L",                  // insert a huge number of lines
+	"\x1b[3;6r\x1b[4H\x1b[99L\x1b[99M", // insert and delete lines in a scrolling region
+}
+
+func TestParserRegressions(t *testing.T) {
+	for _, input := range parserRegressionInputs {
+		feedAndCheck(t, []byte(input))
+	}
+}
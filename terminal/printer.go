@@ -0,0 +1,229 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/liamg/aminal/buffer"
+)
+
+// https://vt100.net/docs/vt510-rm/MC.html
+
+// maxPrinterControllerSequence is the longest control sequence held back while watching for the end of printer
+// controller mode; anything longer can't be CSI 4 i, however many leading zeros it has
+const maxPrinterControllerSequence = 16
+
+// openPrintJob starts a print job using the print_command setting, or appending to print_file if there is no command
+func (terminal *Terminal) openPrintJob() (io.WriteCloser, error) {
+	if args := strings.Fields(terminal.settings.PrintCommand); len(args) > 0 {
+		cmd := exec.Command(args[0], args[1:]...)
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, fmt.Errorf("Failed to run print command: %s", err)
+		}
+
+		// the command is left to finish in its own time, so a slow printer doesn't hold up the terminal
+		terminal.printJobs.Add(1)
+		go func() {
+			defer terminal.printJobs.Done()
+			if err := cmd.Wait(); err != nil {
+				terminal.logger.Errorf("Print command failed: %s", err)
+			}
+		}()
+
+		return stdin, nil
+	}
+
+	if terminal.settings.PrintFile != "" {
+		return os.OpenFile(terminal.settings.PrintFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	}
+
+	return nil, fmt.Errorf("Nothing to print to: set print_command or print_file in the config")
+}
+
+// print sends the text to the printer as a job of its own
+func (terminal *Terminal) print(text string) error {
+	job, err := terminal.openPrintJob()
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(job, text); err != nil {
+		job.Close()
+		return err
+	}
+	return job.Close()
+}
+
+// printableLine returns the text of a line as it should be printed
+func printableLine(line buffer.Line) string {
+	return lineText(line) + "\n"
+}
+
+// autoPrintLine prints the cursor line if auto print mode is on. It is called as the cursor leaves the line.
+func (terminal *Terminal) autoPrintLine() {
+	if !terminal.modes.AutoPrint {
+		return
+	}
+
+	if terminal.autoPrintJob == nil {
+		job, err := terminal.openPrintJob()
+		if err != nil {
+			terminal.logger.Errorf("Failed to start auto print: %s", err)
+			terminal.modes.AutoPrint = false
+			return
+		}
+		terminal.autoPrintJob = job
+	}
+
+	activeBuffer := terminal.ActiveBuffer()
+	line := activeBuffer.GetVisibleLines()[activeBuffer.CursorLine()]
+	if _, err := io.WriteString(terminal.autoPrintJob, printableLine(line)); err != nil {
+		terminal.logger.Errorf("Failed to auto print line: %s", err)
+	}
+}
+
+// setAutoPrint switches auto print mode, finishing the current job when it is turned off
+func (terminal *Terminal) setAutoPrint(enabled bool) error {
+	terminal.modes.AutoPrint = enabled
+	if enabled || terminal.autoPrintJob == nil {
+		return nil
+	}
+	job := terminal.autoPrintJob
+	terminal.autoPrintJob = nil
+	return job.Close()
+}
+
+// startPrinterController sends all further output from the host to the printer, until printer controller mode is turned off
+func (terminal *Terminal) startPrinterController() error {
+	if terminal.printerController != nil {
+		return nil
+	}
+	job, err := terminal.openPrintJob()
+	if err != nil {
+		return err
+	}
+	terminal.printerController = job
+	terminal.printerControllerPending = []rune{}
+	return nil
+}
+
+// printerControllerRune passes a rune from the host straight to the printer, watching for the CSI 4 i which ends
+// printer controller mode. A control sequence is held back until it is clear whether it is that one.
+func (terminal *Terminal) printerControllerRune(r rune) {
+	pending := append(terminal.printerControllerPending, r)
+
+	if _, off := terminal.printerControllerSequence(pending); off {
+		terminal.printerControllerPending = nil
+		job := terminal.printerController
+		terminal.printerController = nil
+		if err := job.Close(); err != nil {
+			terminal.logger.Errorf("Failed to finish printer controller job: %s", err)
+		}
+		return
+	}
+
+	// anything which can no longer be the start of the terminating sequence goes to the printer
+	for len(pending) > 0 {
+		if partial, _ := terminal.printerControllerSequence(pending); partial {
+			break
+		}
+		if _, err := terminal.printerController.Write(printerBytes(pending[0])); err != nil {
+			terminal.logger.Errorf("Failed to pass data to printer: %s", err)
+		}
+		pending = pending[1:]
+	}
+	terminal.printerControllerPending = pending
+}
+
+// printerBytes returns the bytes which a rune from the host was read from, so that they reach the printer unchanged
+func printerBytes(r rune) []byte {
+	if r >= rawByteBase+0x80 && r <= rawByteBase+0xff {
+		return []byte{byte(r - rawByteBase)}
+	}
+	return []byte(string(r))
+}
+
+// printerControllerSequence reports whether the runes are an incomplete control sequence which could still turn out to
+// be CSI 4 i, in either its 7-bit or 8-bit form, and whether they are a complete CSI 4 i. The runes have not been
+// through translateC1, so 8-bit controls are recognised here as the c1_controls setting allows.
+func (terminal *Terminal) printerControllerSequence(pending []rune) (partial bool, off bool) {
+	var body []rune
+	switch first, next := terminal.translateC1(pending[0]); {
+	case first == 0x1b && next == '[':
+		body = pending[1:]
+	case pending[0] == 0x1b && len(pending) == 1:
+		return true, false
+	case pending[0] == 0x1b && pending[1] == '[':
+		body = pending[2:]
+	default:
+		return false, false
+	}
+
+	if len(body) > maxPrinterControllerSequence {
+		return false, false
+	}
+
+	param := ""
+	for i, b := range body {
+		switch {
+		case b >= 0x30 && b <= 0x3F:
+			param = param + string(b)
+		case b == 'i' && i == len(body)-1:
+			params := splitParams(param)
+			if len(params) != 1 {
+				return false, false
+			}
+			n, err := strconv.Atoi(params[0])
+			return false, err == nil && n == 4
+		default:
+			return false, false
+		}
+	}
+	return true, false
+}
+
+// CSI Ps i, CSI ? Ps i
+func csiMediaCopyHandler(params []string, terminal *Terminal) error {
+	n := "0"
+	if len(params) > 0 {
+		n = params[0]
+	}
+
+	switch n {
+	case "0", "":
+		// print screen, including any rows which haven't been written to yet
+		activeBuffer := terminal.ActiveBuffer()
+		lines := activeBuffer.GetVisibleLines()
+		text := ""
+		for i := 0; i < int(activeBuffer.ViewHeight()); i++ {
+			if i < len(lines) {
+				text += printableLine(lines[i])
+			} else {
+				text += "\n"
+			}
+		}
+		return terminal.print(text)
+	case "?1":
+		// print cursor line
+		activeBuffer := terminal.ActiveBuffer()
+		return terminal.print(printableLine(activeBuffer.GetVisibleLines()[activeBuffer.CursorLine()]))
+	case "4":
+		// printer controller mode is ended by processInput, so this can only turn off a controller that isn't on
+		return nil
+	case "5":
+		return terminal.startPrinterController()
+	case "?4":
+		return terminal.setAutoPrint(false)
+	case "?5":
+		return terminal.setAutoPrint(true)
+	}
+
+	return fmt.Errorf("Unsupported media copy: CSI %s i", n)
+}
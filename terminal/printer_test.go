@@ -0,0 +1,135 @@
+package terminal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/liamg/aminal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPrintingTerminal(t *testing.T) (*Terminal, string, func()) {
+	dir, err := ioutil.TempDir("", "aminal-print")
+	require.NoError(t, err)
+
+	terminal := newTestTerminal(10, 3)
+	terminal.settings.PrintFile = filepath.Join(dir, "printer")
+	return terminal, terminal.settings.PrintFile, func() { os.RemoveAll(dir) }
+}
+
+func printed(t *testing.T, path string) string {
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestPrintScreen(t *testing.T) {
+	terminal, path, cleanup := newPrintingTerminal(t)
+	defer cleanup()
+
+	process(terminal, "one\r\n  two\x1b[i")
+	assert.Equal(t, "one\n  two\n\n", printed(t, path))
+
+	// jobs are appended to the file
+	process(terminal, "\x1b[?1i")
+	assert.Equal(t, "one\n  two\n\n  two\n", printed(t, path))
+}
+
+func TestPrinterControllerMode(t *testing.T) {
+	terminal, path, cleanup := newPrintingTerminal(t)
+	defer cleanup()
+
+	process(terminal, "a\x1b[5i^XA\x1b[1m\x1b[4^XZ\x1b\x1b[4ib")
+	assert.Equal(t, "^XA\x1b[1m\x1b[4^XZ\x1b", printed(t, path))
+	assert.Equal(t, "ab", screenText(terminal)[0])
+	assert.False(t, terminal.ActiveBuffer().GetVisibleLines()[0].Cells()[1].Attr().Bold)
+}
+
+// newHeadlessPrinter creates a printing terminal which reads its input as raw bytes, as it would from a pty
+func newHeadlessPrinter(t *testing.T, mode config.C1Mode) (*Headless, string, func()) {
+	dir, err := ioutil.TempDir("", "aminal-print")
+	require.NoError(t, err)
+
+	headless := NewHeadless(10, 3, HeadlessOptions{})
+	headless.settings.PrintFile = filepath.Join(dir, "printer")
+	headless.settings.C1Controls = mode
+	return headless, headless.settings.PrintFile, func() {
+		headless.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestPrinterControllerModeEndsWithAnyFormOfCSI4i(t *testing.T) {
+	headless, path, cleanup := newHeadlessPrinter(t, config.C1NonUTF8)
+	defer cleanup()
+
+	headless.Feed([]byte("\x1b[5ione\x9b4ia"))
+	headless.Feed([]byte("\x1b[5i two\x1b[04ib"))
+	headless.Feed([]byte("\x1b[5i three\x9b0004ic"))
+	assert.Equal(t, "one two three", printed(t, path))
+	assert.Equal(t, "abc", screenText(headless.Terminal)[0])
+
+	// UTF-8 encoded C1 controls are only interpreted if c1_controls is always
+	headless.Feed([]byte("\x1b[5i\u009b4i\x1b[4i"))
+	assert.Equal(t, "one two three\u009b4i", printed(t, path))
+
+	headless, path, cleanup = newHeadlessPrinter(t, config.C1Always)
+	defer cleanup()
+	headless.Feed([]byte("\x1b[5i\u009b4i\x1b[5i\x9b4i"))
+	assert.Equal(t, "", printed(t, path))
+	assert.Nil(t, headless.printerController)
+}
+
+func TestPrinterControllerModePassesBytesThroughUnchanged(t *testing.T) {
+	data := make([]byte, 0x100)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	for _, mode := range []config.C1Mode{config.C1Never, config.C1NonUTF8, config.C1Always} {
+		headless, path, cleanup := newHeadlessPrinter(t, mode)
+		headless.Feed(append(append([]byte("\x1b[5i"), data...), "\x1b[4i"...))
+		assert.Equal(t, string(data), printed(t, path), string(mode))
+		assert.Nil(t, headless.printerController)
+		cleanup()
+	}
+}
+
+func TestPrinterControllerModePassesOnOtherSequences(t *testing.T) {
+	terminal, path, cleanup := newPrintingTerminal(t)
+	defer cleanup()
+
+	process(terminal, "\x1b[5i\x1b[?4i\x1b[4;5i\x1b[4 i\x1b[5i\x1b[4m\x1b[0000000000000000004i\x1b[4i")
+	assert.Equal(t, "\x1b[?4i\x1b[4;5i\x1b[4 i\x1b[5i\x1b[4m\x1b[0000000000000000004i", printed(t, path))
+	assert.Nil(t, terminal.printerController)
+}
+
+func TestAutoPrint(t *testing.T) {
+	terminal, path, cleanup := newPrintingTerminal(t)
+	defer cleanup()
+
+	process(terminal, "skipped\r\n\x1b[?5ifirst\r\n0123456789wrapped\x1b[?4i")
+	assert.Equal(t, "first\n0123456789\n", printed(t, path))
+
+	process(terminal, "\r\nnot printed\r\n")
+	assert.Equal(t, "first\n0123456789\n", printed(t, path))
+}
+
+func TestPrintCommand(t *testing.T) {
+	terminal, path, cleanup := newPrintingTerminal(t)
+	defer cleanup()
+
+	terminal.settings.PrintCommand = "tee " + path
+	terminal.settings.PrintFile = ""
+	process(terminal, "hello\x1b[i")
+	terminal.printJobs.Wait()
+	assert.Equal(t, "hello\n\n\n", printed(t, path))
+}
+
+func TestPrintWithoutDestination(t *testing.T) {
+	terminal := newTestTerminal(10, 3)
+	assert.Error(t, csiMediaCopyHandler([]string{"0"}, terminal))
+}
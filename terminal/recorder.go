@@ -0,0 +1,103 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/liamg/aminal/asciicast"
+)
+
+// recordingReader passes output from the pty to the recorder and the session log, if there are any, as it is read
+type recordingReader struct {
+	terminal *Terminal
+}
+
+func (reader *recordingReader) Read(p []byte) (int, error) {
+	n, err := reader.terminal.pty.Read(p)
+	if n > 0 {
+		reader.terminal.recordOutput(p[:n])
+		reader.terminal.logOutput(p[:n])
+	}
+	return n, err
+}
+
+// StartRecording writes the output of the terminal, and any changes in its size, to an asciicast v2 file
+func (terminal *Terminal) StartRecording(path string) error {
+	terminal.recordingLock.Lock()
+	defer terminal.recordingLock.Unlock()
+
+	if terminal.recording != nil {
+		return fmt.Errorf("Already recording to %s", terminal.recordingFile.Name())
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Failed to create recording: %s", err)
+	}
+
+	writer, err := asciicast.NewWriter(file, asciicast.Header{
+		Width:     int(terminal.size.Width),
+		Height:    int(terminal.size.Height),
+		Timestamp: time.Now().Unix(),
+		Title:     terminal.GetTitle(),
+		Env: map[string]string{
+			"TERM":  os.Getenv("TERM"),
+			"SHELL": os.Getenv("SHELL"),
+		},
+	})
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("Failed to write recording: %s", err)
+	}
+
+	terminal.recording = writer
+	terminal.recordingFile = file
+	return nil
+}
+
+// StopRecording finishes the current recording, if any
+func (terminal *Terminal) StopRecording() error {
+	terminal.recordingLock.Lock()
+	defer terminal.recordingLock.Unlock()
+
+	if terminal.recording == nil {
+		return nil
+	}
+	err := terminal.recording.Flush()
+	if closeErr := terminal.recordingFile.Close(); err == nil {
+		err = closeErr
+	}
+	terminal.recording = nil
+	terminal.recordingFile = nil
+	return err
+}
+
+// IsRecording returns true if the terminal is being recorded
+func (terminal *Terminal) IsRecording() bool {
+	terminal.recordingLock.Lock()
+	defer terminal.recordingLock.Unlock()
+	return terminal.recording != nil
+}
+
+func (terminal *Terminal) recordOutput(data []byte) {
+	terminal.recordingLock.Lock()
+	defer terminal.recordingLock.Unlock()
+
+	if terminal.recording != nil {
+		if err := terminal.recording.Output(data); err != nil {
+			terminal.logger.Errorf("Failed to write recording: %s", err)
+		}
+	}
+}
+
+func (terminal *Terminal) recordResize() {
+	terminal.recordingLock.Lock()
+	defer terminal.recordingLock.Unlock()
+
+	if terminal.recording != nil {
+		if err := terminal.recording.Resize(int(terminal.size.Width), int(terminal.size.Height)); err != nil {
+			terminal.logger.Errorf("Failed to write recording: %s", err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package terminal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/liamg/aminal/asciicast"
+	"github.com/liamg/aminal/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecording(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aminal")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session.cast")
+
+	settings := DefaultSettings()
+	pty, host := platform.NewPipePty()
+	terminal := New(pty, nil, settings)
+	terminal.SetSize(80, 24)
+
+	require.NoError(t, terminal.StartRecording(path))
+	assert.True(t, terminal.IsRecording())
+	assert.Error(t, terminal.StartRecording(path))
+
+	done := make(chan error)
+	go func() {
+		done <- terminal.Read()
+	}()
+
+	terminal.SetSize(100, 30)
+	host.Write([]byte("hello\r\n"))
+	host.Write([]byte("\x1b[1mworld"))
+	// the start of a character which never arrives is recorded when the recording stops
+	host.Write([]byte("!\xe2\x82"))
+	host.Close()
+	require.NoError(t, <-done)
+
+	require.NoError(t, terminal.StopRecording())
+	assert.False(t, terminal.IsRecording())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	reader, err := asciicast.NewReader(file)
+	require.NoError(t, err)
+	assert.Equal(t, 80, reader.Header.Width)
+	assert.Equal(t, 24, reader.Header.Height)
+
+	events := []asciicast.Event{}
+	for {
+		event, err := reader.Next()
+		if err != nil {
+			break
+		}
+		event.Time = 0
+		events = append(events, event)
+	}
+	assert.Equal(t, []asciicast.Event{
+		{Type: asciicast.EventResize, Data: "100x30"},
+		{Type: asciicast.EventOutput, Data: "hello\r\n"},
+		{Type: asciicast.EventOutput, Data: "\x1b[1mworld"},
+		{Type: asciicast.EventOutput, Data: "!"},
+		{Type: asciicast.EventOutput, Data: "\u00e2\u0082"},
+	}, events)
+}
@@ -0,0 +1,265 @@
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/liamg/aminal/buffer"
+)
+
+// https://vt100.net/docs/vt510-rm/chapter4.html#S4.12
+
+// parseNumericParam returns the numeric value of params[index], or def if it is missing, empty or zero
+func parseNumericParam(params []string, index int, def int) int {
+	if index >= len(params) || params[index] == "" {
+		return def
+	}
+	n, err := strconv.Atoi(params[index])
+	if err != nil || n < 1 {
+		return def
+	}
+	return n
+}
+
+// clampParam limits a one-based coordinate to the range min to max
+func clampParam(n int, min int, max int) int {
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+// parseRectangle reads Pt;Pl;Pb;Pr starting at params[offset], converting to zero-based view coordinates.
+// In Origin Mode (DECOM) the rows are relative to the scrolling region. Coordinates outside the screen, or the
+// scrolling region in Origin Mode, are clamped to it.
+func parseRectangle(params []string, offset int, terminal *Terminal) (buffer.Rectangle, error) {
+	return parseArea(params, offset, terminal, false)
+}
+
+// parseAttributeArea reads the area of DECCARA and DECRARA. With the stream extent selected by DECSACE the area
+// runs from the top left position to the bottom right one, wrapping at the line ends, so its left column may be
+// to the right of its right column if it covers more than one row.
+func parseAttributeArea(params []string, terminal *Terminal) (buffer.Rectangle, error) {
+	return parseArea(params, 0, terminal, !terminal.terminalState.RectangularAttributeExtent)
+}
+
+func parseArea(params []string, offset int, terminal *Terminal, stream bool) (buffer.Rectangle, error) {
+	activeBuffer := terminal.ActiveBuffer()
+
+	top := parseNumericParam(params, offset, 1)
+	left := parseNumericParam(params, offset+1, 1)
+	bottom := parseNumericParam(params, offset+2, int(activeBuffer.ViewHeight()))
+	right := parseNumericParam(params, offset+3, int(activeBuffer.ViewWidth()))
+
+	if top > bottom || left > right && (!stream || top == bottom) {
+		return buffer.Rectangle{}, fmt.Errorf("Invalid rectangular area: top=%d left=%d bottom=%d right=%d", top, left, bottom, right)
+	}
+
+	minRow, maxRow := 1, int(activeBuffer.ViewHeight())
+	if terminal.terminalState.OriginMode {
+		top = clampParam(top, 1, maxRow) + int(activeBuffer.TopMargin())
+		bottom = clampParam(bottom, 1, maxRow) + int(activeBuffer.TopMargin())
+		minRow, maxRow = int(activeBuffer.TopMargin())+1, int(activeBuffer.BottomMargin())+1
+	}
+
+	return buffer.Rectangle{
+		Top:    uint16(clampParam(top, minRow, maxRow) - 1),
+		Left:   uint16(clampParam(left, 1, int(activeBuffer.ViewWidth())) - 1),
+		Bottom: uint16(clampParam(bottom, minRow, maxRow) - 1),
+		Right:  uint16(clampParam(right, 1, int(activeBuffer.ViewWidth())) - 1),
+	}, nil
+}
+
+// CSI Pts ; Pls ; Pbs ; Prs ; Pps ; Ptd ; Pld ; Ppd $ v
+func csiCopyAreaHandler(params []string, terminal *Terminal) error {
+	area, err := parseRectangle(params, 0, terminal)
+	if err != nil {
+		return err
+	}
+
+	// we only have one page, so the page parameters (Pps, Ppd) are ignored
+	activeBuffer := terminal.ActiveBuffer()
+	destTop := clampParam(parseNumericParam(params, 5, 1), 1, int(activeBuffer.ViewHeight()))
+	destLeft := clampParam(parseNumericParam(params, 6, 1), 1, int(activeBuffer.ViewWidth()))
+	if terminal.terminalState.OriginMode {
+		destTop = clampParam(destTop+int(activeBuffer.TopMargin()), 1, int(activeBuffer.BottomMargin())+1)
+	}
+
+	activeBuffer.CopyArea(area, uint16(destTop-1), uint16(destLeft-1))
+	return nil
+}
+
+// CSI Pch ; Pt ; Pl ; Pb ; Pr $ x
+func csiFillAreaHandler(params []string, terminal *Terminal) error {
+	if len(params) == 0 {
+		return fmt.Errorf("Missing fill character for DECFRA")
+	}
+
+	ch, err := strconv.Atoi(params[0])
+	if err != nil || !((ch >= 32 && ch <= 126) || (ch >= 160 && ch <= 255)) {
+		return fmt.Errorf("Invalid fill character for DECFRA: %s", params[0])
+	}
+
+	area, err := parseRectangle(params, 1, terminal)
+	if err != nil {
+		return err
+	}
+
+	// the fill character is an 8-bit code, so those above 159 are in GR
+	r := rune(ch)
+	if r >= 160 {
+		r += rawByteBase
+	}
+	terminal.ActiveBuffer().FillArea(terminal.translateRune(r), area)
+	return nil
+}
+
+// CSI Pt ; Pl ; Pb ; Pr $ z
+func csiEraseAreaHandler(params []string, terminal *Terminal) error {
+	area, err := parseRectangle(params, 0, terminal)
+	if err != nil {
+		return err
+	}
+
+	terminal.ActiveBuffer().EraseArea(area)
+	return nil
+}
+
+// CSI Pt ; Pl ; Pb ; Pr $ {
+func csiSelectiveEraseAreaHandler(params []string, terminal *Terminal) error {
+	area, err := parseRectangle(params, 0, terminal)
+	if err != nil {
+		return err
+	}
+
+	terminal.ActiveBuffer().SelectiveEraseArea(area)
+	return nil
+}
+
+var allAreaAttributes = buffer.AttributeMask{Bold: true, Underline: true, Blink: true, Inverse: true}
+
+// parseAttributeChanges reads the SGR subset allowed by DECCARA and DECRARA, in order
+func parseAttributeChanges(params []string) (set buffer.AttributeMask, reset buffer.AttributeMask, err error) {
+	if len(params) == 0 {
+		params = []string{"0"}
+	}
+
+	for _, p := range params {
+		switch p {
+		case "0", "":
+			set = buffer.AttributeMask{}
+			reset = allAreaAttributes
+		case "1":
+			set.Bold, reset.Bold = true, false
+		case "4":
+			set.Underline, reset.Underline = true, false
+		case "5":
+			set.Blink, reset.Blink = true, false
+		case "7":
+			set.Inverse, reset.Inverse = true, false
+		case "22":
+			set.Bold, reset.Bold = false, true
+		case "24":
+			set.Underline, reset.Underline = false, true
+		case "25":
+			set.Blink, reset.Blink = false, true
+		case "27":
+			set.Inverse, reset.Inverse = false, true
+		default:
+			return set, reset, fmt.Errorf("Unsupported attribute in rectangular area change: %s", p)
+		}
+	}
+
+	return set, reset, nil
+}
+
+// CSI Pt ; Pl ; Pb ; Pr ; Ps $ r
+func csiChangeAreaAttributesHandler(params []string, terminal *Terminal) error {
+	area, err := parseAttributeArea(params, terminal)
+	if err != nil {
+		return err
+	}
+
+	var attrs []string
+	if len(params) > 4 {
+		attrs = params[4:]
+	}
+
+	set, reset, err := parseAttributeChanges(attrs)
+	if err != nil {
+		return err
+	}
+
+	terminal.ActiveBuffer().ChangeAreaAttributes(area, set, reset)
+	return nil
+}
+
+// CSI Pt ; Pl ; Pb ; Pr ; Ps $ t
+func csiReverseAreaAttributesHandler(params []string, terminal *Terminal) error {
+	area, err := parseAttributeArea(params, terminal)
+	if err != nil {
+		return err
+	}
+
+	var attrs []string
+	if len(params) > 4 {
+		attrs = params[4:]
+	}
+
+	set, reset, err := parseAttributeChanges(attrs)
+	if err != nil {
+		return err
+	}
+
+	// 0 reverses all attributes, whereas 22, 24 etc. have no meaning here
+	toggle := set
+	if reset == allAreaAttributes {
+		toggle = allAreaAttributes
+	}
+
+	terminal.ActiveBuffer().ReverseAreaAttributes(area, toggle)
+	return nil
+}
+
+// CSI Ps * x
+func csiSelectAttributeChangeExtentHandler(params []string, terminal *Terminal) error {
+	n := "0"
+	if len(params) > 0 {
+		n = params[0]
+	}
+
+	switch n {
+	case "0", "", "1":
+		terminal.terminalState.RectangularAttributeExtent = false
+	case "2":
+		terminal.terminalState.RectangularAttributeExtent = true
+	default:
+		return fmt.Errorf("Unsupported DECSACE: CSI %s * x", n)
+	}
+	return nil
+}
+
+// CSI Pid ; Pp ; Pt ; Pl ; Pb ; Pr * y
+func csiRequestAreaChecksumHandler(params []string, terminal *Terminal) error {
+	id := 0
+	if len(params) > 0 && params[0] != "" {
+		var err error
+		id, err = strconv.Atoi(params[0])
+		if err != nil {
+			return fmt.Errorf("Invalid DECRQCRA request id: %s", params[0])
+		}
+	}
+
+	checksum := uint16(0)
+	area, err := parseRectangle(params, 2, terminal)
+	if err == nil {
+		checksum = terminal.ActiveBuffer().AreaChecksum(area)
+	}
+
+	// DECCKSR
+	_ = terminal.Write([]byte(fmt.Sprintf("%s%d!~%04X%s", terminal.c1(c1DCS), id, checksum, terminal.c1(c1ST))))
+	return nil
+}
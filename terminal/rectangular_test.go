@@ -0,0 +1,70 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRectangleCoordinatesAreClamped(t *testing.T) {
+	terminal := newTestTerminal(6, 4)
+
+	// a bottom row of 65537 must not wrap around to the top row
+	process(terminal, "\x1b[42;1;1;65537;3$x")
+	assert.Equal(t, []string{"***", "***", "***", "***"}, screenText(terminal))
+
+	process(terminal, "\x1b[43;3;4;999;99999$x")
+	assert.Equal(t, []string{"***", "***", "***+++", "***+++"}, screenText(terminal))
+
+	process(terminal, "\x1b[2;2;65537;65537$z")
+	for _, line := range screenText(terminal)[1:] {
+		assert.Equal(t, "*", strings.TrimRight(line, "\x00 "))
+	}
+
+	// the area is empty when it starts past its end, before clamping
+	process(terminal, "\x1b[45;99;1;98;6$x")
+	assert.Equal(t, "***", screenText(terminal)[0])
+}
+
+func TestRectangleCoordinatesAreClampedToTheMarginsInOriginMode(t *testing.T) {
+	terminal := newTestTerminal(4, 6)
+	process(terminal, "\x1b[2;4r\x1b[?6h\x1b[42;1;1;65537;2$x")
+	assert.Equal(t, []string{"", "**", "**", "**"}, screenText(terminal))
+
+	process(terminal, "\x1b[43;99;3;99;4$x")
+	assert.Equal(t, []string{"", "**", "**", "**++"}, screenText(terminal))
+}
+
+func TestCopyAreaDestinationIsClamped(t *testing.T) {
+	terminal := newTestTerminal(4, 3)
+	process(terminal, "ab\x1b[1;1;1;2;1;65537;65539$v")
+	assert.Equal(t, []string{"ab", "", "\x00\x00\x00a"}, screenText(terminal))
+}
+
+// boldCells returns a row of the screen with the bold cells as B and the others as -
+func boldCells(terminal *Terminal, row uint16) string {
+	var out strings.Builder
+	for col := uint16(0); col < terminal.ActiveBuffer().ViewWidth(); col++ {
+		if terminal.ActiveBuffer().GetCell(col, row).Attr().Bold {
+			out.WriteByte('B')
+		} else {
+			out.WriteByte('-')
+		}
+	}
+	return out.String()
+}
+
+func TestStreamExtentAttributeChangeWrapsAcrossRows(t *testing.T) {
+	terminal := newTestTerminal(6, 3)
+	process(terminal, "abcdefghijklmnopqr")
+
+	process(terminal, "\x1b[1*x\x1b[1;5;2;2;1$r")
+	assert.Equal(t, []string{"----BB", "BB----", "------"},
+		[]string{boldCells(terminal, 0), boldCells(terminal, 1), boldCells(terminal, 2)})
+
+	// the same area is empty as a rectangle
+	process(terminal, "\x1b[2*x\x1b[2;5;3;2;1$r")
+	assert.Equal(t, "BB----", boldCells(terminal, 1))
+	assert.Equal(t, "------", boldCells(terminal, 2))
+}
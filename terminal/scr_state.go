@@ -1,28 +1,44 @@
 package terminal
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/liamg/aminal/buffer"
+)
 
 func screenStateHandler(pty chan rune, terminal *Terminal) error {
-	b := <-pty
+	b, ok := terminal.nextRune(pty)
+	if !ok {
+		return errTruncatedSequence
+	}
 	switch b {
+	case '3': // DECDHL -- Double Height Line, top half
+		terminal.ActiveBuffer().SetLineSize(buffer.LineSizeDoubleHeightTop)
+	case '4': // DECDHL -- Double Height Line, bottom half
+		terminal.ActiveBuffer().SetLineSize(buffer.LineSizeDoubleHeightBottom)
+	case '5': // DECSWL -- Single Width Line
+		terminal.ActiveBuffer().SetLineSize(buffer.LineSizeSingle)
+	case '6': // DECDWL -- Double Width Line
+		terminal.ActiveBuffer().SetLineSize(buffer.LineSizeDoubleWidth)
 	case '8': // DECALN -- Screen Alignment Pattern
 		// hide cursor?
-		buffer := terminal.ActiveBuffer()
+		activeBuffer := terminal.ActiveBuffer()
 		terminal.ResetVerticalMargins()
 		terminal.ScrollToEnd()
+		activeBuffer.ResetLineSizes()
 
 		// Fill the whole screen with E's
-		count := buffer.ViewHeight() * buffer.ViewWidth()
+		count := activeBuffer.ViewHeight() * activeBuffer.ViewWidth()
 		for count > 0 {
-			buffer.Write('E')
+			activeBuffer.Write('E')
 			count--
-			if count > 0 && !terminal.IsAutoWrap() && count%buffer.ViewWidth() == 0 {
-				buffer.Index()
-				buffer.CarriageReturn()
+			if count > 0 && !terminal.IsAutoWrap() && count%activeBuffer.ViewWidth() == 0 {
+				activeBuffer.Index()
+				activeBuffer.CarriageReturn()
 			}
 		}
 		// restore cursor
-		buffer.SetPosition(0, 0)
+		activeBuffer.SetPosition(0, 0)
 	default:
 		return fmt.Errorf("Screen State code not supported: 0x%02X [%v]", b, string(b))
 	}
@@ -0,0 +1,25 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/liamg/aminal/buffer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineSizeSequences(t *testing.T) {
+	terminal := newTestTerminal(20, 5)
+	process(terminal, "\x1b#3top\r\n\x1b#4bottom\r\n\x1b#6wide\r\n\x1b#6\x1b#5single")
+
+	lines := terminal.ActiveBuffer().GetVisibleLines()
+	assert.Equal(t, buffer.LineSizeDoubleHeightTop, lines[0].Size())
+	assert.Equal(t, buffer.LineSizeDoubleHeightBottom, lines[1].Size())
+	assert.Equal(t, buffer.LineSizeDoubleWidth, lines[2].Size())
+	assert.Equal(t, buffer.LineSizeSingle, lines[3].Size())
+
+	// DECALN returns every line to single width
+	process(terminal, "\x1b#8")
+	for _, line := range terminal.ActiveBuffer().GetVisibleLines() {
+		assert.Equal(t, buffer.LineSizeSingle, line.Size())
+	}
+}
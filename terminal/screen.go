@@ -0,0 +1,59 @@
+package terminal
+
+import (
+	"strings"
+
+	"github.com/liamg/aminal/buffer"
+)
+
+// lineText returns the text of a line, with blank cells as spaces and trailing spaces removed
+func lineText(line buffer.Line) string {
+	runes := []rune{}
+	for _, cell := range line.Cells() {
+		r := cell.Rune()
+		if r == 0 {
+			r = ' '
+		}
+		runes = append(runes, r)
+	}
+	return strings.TrimRight(string(runes), " ")
+}
+
+// Lines returns the text of every row on the screen
+func (terminal *Terminal) Lines() []string {
+	screen := terminal.ScreenBuffer()
+	lines := screen.GetVisibleLines()
+	text := make([]string, screen.ViewHeight())
+	for i := range text {
+		if i < len(lines) {
+			text[i] = lineText(lines[i])
+		}
+	}
+	return text
+}
+
+// Text returns the text on the screen, with rows separated by newlines
+func (terminal *Terminal) Text() string {
+	return strings.Join(terminal.Lines(), "\n")
+}
+
+// Cell returns a copy of the cell at the given screen position
+func (terminal *Terminal) Cell(col uint16, row uint16) *buffer.Cell {
+	if cell := terminal.ScreenBuffer().GetCell(col, row); cell != nil {
+		copied := *cell
+		return &copied
+	}
+	blank := terminal.terminalState.DefaultCell(false)
+	return &blank
+}
+
+// Cursor returns the zero-based cursor position on the screen
+func (terminal *Terminal) Cursor() (col uint16, row uint16) {
+	screen := terminal.ScreenBuffer()
+	return screen.CursorColumn(), screen.CursorLine()
+}
+
+// CursorVisible returns false if the host has hidden the cursor (DECTCEM)
+func (terminal *Terminal) CursorVisible() bool {
+	return terminal.modes.ShowCursor
+}
@@ -0,0 +1,141 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+)
+
+// sessionLog is a file which the output of the session is logged to
+type sessionLog struct {
+	file       *os.File
+	mode       config.SessionLogMode
+	pending    string // text of a logical line which may yet be continued by a wrapped line
+	hasPending bool
+}
+
+// StartSessionLog starts logging the session to the file given by the session_log_path template
+func (terminal *Terminal) StartSessionLog() error {
+	terminal.sessionLogLock.Lock()
+	defer terminal.sessionLogLock.Unlock()
+
+	if terminal.sessionLog != nil {
+		return fmt.Errorf("Already logging to %s", terminal.sessionLog.file.Name())
+	}
+
+	mode := terminal.settings.SessionLogMode
+	if mode != config.SessionLogRaw && mode != config.SessionLogText {
+		return fmt.Errorf("Unknown session log mode %q", mode)
+	}
+
+	path, err := config.ExpandPath(terminal.settings.SessionLogPath, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("Failed to create session log directory: %s", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("Failed to open session log: %s", err)
+	}
+
+	terminal.sessionLog = &sessionLog{file: file, mode: mode}
+	return nil
+}
+
+// StopSessionLog finishes the session log, if any. In text mode the lines still on the screen are logged first.
+func (terminal *Terminal) StopSessionLog() error {
+	terminal.sessionLogLock.Lock()
+	defer terminal.sessionLogLock.Unlock()
+
+	log := terminal.sessionLog
+	if log == nil {
+		return nil
+	}
+
+	if log.mode == config.SessionLogText {
+		screen := terminal.buffers[MainBuffer]
+		lines := screen.ViewLines()
+		cursorLine := int(screen.CursorLineAbsolute())
+		for i := 0; i < len(lines) && i <= cursorLine; i++ {
+			if i == cursorLine && lineText(lines[i]) == "" {
+				break // the prompt for the next command has not been written yet
+			}
+			log.writeLine(&lines[i])
+		}
+		log.flush()
+	}
+
+	terminal.sessionLog = nil
+	return log.file.Close()
+}
+
+// IsSessionLogging returns true if the session is being logged
+func (terminal *Terminal) IsSessionLogging() bool {
+	terminal.sessionLogLock.Lock()
+	defer terminal.sessionLogLock.Unlock()
+	return terminal.sessionLog != nil
+}
+
+// logOutput logs output from the pty, if logging in raw mode
+func (terminal *Terminal) logOutput(data []byte) {
+	terminal.sessionLogLock.Lock()
+	defer terminal.sessionLogLock.Unlock()
+
+	if terminal.sessionLog != nil && terminal.sessionLog.mode == config.SessionLogRaw {
+		if _, err := terminal.sessionLog.file.Write(data); err != nil {
+			terminal.logger.Errorf("Failed to write session log: %s", err)
+		}
+	}
+}
+
+// logScrolledLine logs a line of the main screen as it scrolls into the scrollback, if logging in text mode
+func (terminal *Terminal) logScrolledLine(line *buffer.Line) {
+	terminal.sessionLogLock.Lock()
+	defer terminal.sessionLogLock.Unlock()
+
+	if terminal.sessionLog != nil && terminal.sessionLog.mode == config.SessionLogText {
+		if err := terminal.sessionLog.writeLine(line); err != nil {
+			terminal.logger.Errorf("Failed to write session log: %s", err)
+		}
+	}
+}
+
+// writeLine adds a line to the log. Lines are held back until it is known that no wrapped line continues them.
+func (log *sessionLog) writeLine(line *buffer.Line) error {
+	text := []rune{}
+	for _, cell := range line.Cells() {
+		r := cell.Rune()
+		if r == 0 {
+			r = ' '
+		}
+		text = append(text, r)
+	}
+
+	if line.IsContinuation() && log.hasPending {
+		log.pending += string(text)
+		return nil
+	}
+
+	err := log.flush()
+	log.pending = string(text)
+	log.hasPending = true
+	return err
+}
+
+// flush writes the pending line
+func (log *sessionLog) flush() error {
+	if !log.hasPending {
+		return nil
+	}
+	_, err := log.file.WriteString(strings.TrimRight(log.pending, " ") + "\n")
+	log.pending = ""
+	log.hasPending = false
+	return err
+}
@@ -0,0 +1,100 @@
+package terminal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempLogPath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "aminal")
+	require.NoError(t, err)
+	return filepath.Join(dir, "logs", "session.log"), func() { os.RemoveAll(dir) }
+}
+
+func TestTextSessionLog(t *testing.T) {
+	path, cleanup := tempLogPath(t)
+	defer cleanup()
+
+	terminal := newTestTerminal(10, 3)
+	terminal.settings.SessionLogPath = path
+	terminal.settings.SessionLogMode = config.SessionLogText
+
+	process(terminal, "before\r\n")
+	require.NoError(t, terminal.StartSessionLog())
+	assert.True(t, terminal.IsSessionLogging())
+
+	// escape sequences are removed, overwrites are resolved, and wrapped lines are joined
+	process(terminal, "\x1b[1;31mred\x1b[m text\r\n")
+	process(terminal, "xxxxx\rab\r\n")
+	process(terminal, "\r\n")
+	process(terminal, "0123456789abcdef\r\n")
+	process(terminal, "last")
+
+	require.NoError(t, terminal.StopSessionLog())
+	assert.False(t, terminal.IsSessionLogging())
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	// lines which were already on the screen are logged when they scroll off it
+	assert.Equal(t, "before\nred text\nabxxx\n\n0123456789abcdef\nlast\n", string(data))
+}
+
+func TestTextSessionLogIgnoresAltScreen(t *testing.T) {
+	path, cleanup := tempLogPath(t)
+	defer cleanup()
+
+	terminal := newTestTerminal(10, 3)
+	terminal.settings.SessionLogPath = path
+	require.NoError(t, terminal.StartSessionLog())
+
+	process(terminal, "shell\r\n\x1b[?1049h1\r\n2\r\n3\r\n4\r\n\x1b[?1049l")
+	require.NoError(t, terminal.StopSessionLog())
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "shell\n", string(data))
+}
+
+func TestRawSessionLog(t *testing.T) {
+	path, cleanup := tempLogPath(t)
+	defer cleanup()
+
+	settings := DefaultSettings()
+	settings.SessionLogPath = path
+	settings.SessionLogMode = config.SessionLogRaw
+	pty, host := platform.NewPipePty()
+	terminal := New(pty, nil, settings)
+	terminal.SetSize(80, 24)
+	require.NoError(t, terminal.StartSessionLog())
+
+	done := make(chan error)
+	go func() {
+		done <- terminal.Read()
+	}()
+	host.Write([]byte("hello\r\n\x1b[1mworld\x1b[m"))
+	host.Close()
+	require.NoError(t, <-done)
+	require.NoError(t, terminal.StopSessionLog())
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "hello\r\n\x1b[1mworld\x1b[m", string(data))
+}
+
+func TestSessionLogMode(t *testing.T) {
+	path, cleanup := tempLogPath(t)
+	defer cleanup()
+
+	terminal := newTestTerminal(10, 3)
+	terminal.settings.SessionLogPath = path
+	terminal.settings.SessionLogMode = "html"
+	assert.Error(t, terminal.StartSessionLog())
+	assert.False(t, terminal.IsSessionLogging())
+}
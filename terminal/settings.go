@@ -0,0 +1,50 @@
+package terminal
+
+import (
+	"github.com/liamg/aminal/config"
+)
+
+// Settings are the parts of the configuration used by the terminal. The GUI fills them in from its config file.
+type Settings struct {
+	ColourScheme          config.ColourScheme
+	MaxLines              uint64 // lines of scrollback on the main screen
+	AltMaxLines           uint64 // lines of scrollback on the alternate screen, which never has less than the view
+	MaxScrollbackBytes    uint64 // 0 for no limit beyond the number of lines
+	ScrollbackMemoryLines uint64 // 0 to keep all of the scrollback in memory
+	ScrollbackSpill       bool   // compressed scrollback is written to an encrypted temporary file
+	TabWidth              uint16
+	C1Controls            config.C1Mode
+	PrintCommand          string
+	PrintFile             string
+	SessionLogPath        string
+	SessionLogMode        config.SessionLogMode
+	Slomo                 bool // output is processed slowly, for debugging
+}
+
+// DefaultSettings returns the settings which the GUI uses when there is no config file
+func DefaultSettings() Settings {
+	return Settings{
+		ColourScheme:          config.DefaultColourScheme,
+		MaxLines:              1000,
+		MaxScrollbackBytes:    64 * 1024 * 1024,
+		ScrollbackMemoryLines: 10000,
+		TabWidth:              4,
+		C1Controls:            config.C1NonUTF8,
+		SessionLogPath:        "~/aminal-logs/$DATE-$TIME-$HOST.log",
+		SessionLogMode:        config.SessionLogText,
+	}
+}
+
+// Logger receives the terminal's diagnostic messages. A *zap.SugaredLogger will do.
+type Logger interface {
+	Debugf(template string, args ...interface{})
+	Infof(template string, args ...interface{})
+	Errorf(template string, args ...interface{})
+}
+
+// nopLogger discards everything, for terminals created without a logger
+type nopLogger struct{}
+
+func (nopLogger) Debugf(template string, args ...interface{}) {}
+func (nopLogger) Infof(template string, args ...interface{})  {}
+func (nopLogger) Errorf(template string, args ...interface{}) {}
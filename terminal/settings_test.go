@@ -0,0 +1,27 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/liamg/aminal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultSettingsMatchTheDefaultConfig(t *testing.T) {
+	conf := config.DefaultConfig
+	assert.Equal(t, Settings{
+		ColourScheme:          conf.ColourScheme,
+		MaxLines:              conf.MaxLines,
+		AltMaxLines:           conf.AltMaxLines,
+		MaxScrollbackBytes:    conf.MaxScrollbackBytes,
+		ScrollbackMemoryLines: conf.ScrollbackMemoryLines,
+		ScrollbackSpill:       conf.ScrollbackSpill,
+		TabWidth:              conf.TabWidth,
+		C1Controls:            conf.C1Controls,
+		PrintCommand:          conf.PrintCommand,
+		PrintFile:             conf.PrintFile,
+		SessionLogPath:        conf.SessionLogPath,
+		SessionLogMode:        conf.SessionLogMode,
+		Slomo:                 conf.Slomo,
+	}, DefaultSettings())
+}
@@ -6,7 +6,6 @@ import (
 	"strings"
 
 	"github.com/liamg/aminal/buffer"
-	"github.com/liamg/aminal/config"
 )
 
 func sgrSequenceHandler(params []string, terminal *Terminal) error {
@@ -23,13 +22,14 @@ func sgrSequenceHandler(params []string, terminal *Terminal) error {
 		case "00", "0", "":
 			attr := terminal.ActiveBuffer().CursorAttr()
 			*attr = buffer.CellAttributes{
-				FgColour: terminal.config.ColourScheme.Foreground,
-				BgColour: terminal.config.ColourScheme.Background,
+				Protected: attr.Protected, // DECSCA is not affected by SGR
 			}
 		case "1", "01":
 			terminal.ActiveBuffer().CursorAttr().Bold = true
 		case "2", "02":
 			terminal.ActiveBuffer().CursorAttr().Dim = true
+		case "3", "03":
+			terminal.ActiveBuffer().CursorAttr().Italic = true
 		case "4", "04":
 			terminal.ActiveBuffer().CursorAttr().Underline = true
 		case "5", "05":
@@ -43,7 +43,7 @@ func sgrSequenceHandler(params []string, terminal *Terminal) error {
 		case "22":
 			terminal.ActiveBuffer().CursorAttr().Dim = false
 		case "23":
-			// not italic
+			terminal.ActiveBuffer().CursorAttr().Italic = false
 		case "24":
 			terminal.ActiveBuffer().CursorAttr().Underline = false
 		case "25":
@@ -55,73 +55,73 @@ func sgrSequenceHandler(params []string, terminal *Terminal) error {
 		case "29":
 			// not strikethrough
 		case "39":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.Foreground
+			terminal.ActiveBuffer().CursorAttr().FgColour = buffer.DefaultColour
 		case "30":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.Black
+			terminal.ActiveBuffer().CursorAttr().FgColour = buffer.PaletteColour(0)
 		case "31":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.Red
+			terminal.ActiveBuffer().CursorAttr().FgColour = buffer.PaletteColour(1)
 		case "32":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.Green
+			terminal.ActiveBuffer().CursorAttr().FgColour = buffer.PaletteColour(2)
 		case "33":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.Yellow
+			terminal.ActiveBuffer().CursorAttr().FgColour = buffer.PaletteColour(3)
 		case "34":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.Blue
+			terminal.ActiveBuffer().CursorAttr().FgColour = buffer.PaletteColour(4)
 		case "35":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.Magenta
+			terminal.ActiveBuffer().CursorAttr().FgColour = buffer.PaletteColour(5)
 		case "36":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.Cyan
+			terminal.ActiveBuffer().CursorAttr().FgColour = buffer.PaletteColour(6)
 		case "37":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.White
+			terminal.ActiveBuffer().CursorAttr().FgColour = buffer.PaletteColour(7)
 		case "90":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.DarkGrey
+			terminal.ActiveBuffer().CursorAttr().FgColour = buffer.PaletteColour(8)
 		case "91":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.LightRed
+			terminal.ActiveBuffer().CursorAttr().FgColour = buffer.PaletteColour(9)
 		case "92":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.LightGreen
+			terminal.ActiveBuffer().CursorAttr().FgColour = buffer.PaletteColour(10)
 		case "93":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.LightYellow
+			terminal.ActiveBuffer().CursorAttr().FgColour = buffer.PaletteColour(11)
 		case "94":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.LightBlue
+			terminal.ActiveBuffer().CursorAttr().FgColour = buffer.PaletteColour(12)
 		case "95":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.LightMagenta
+			terminal.ActiveBuffer().CursorAttr().FgColour = buffer.PaletteColour(13)
 		case "96":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.LightCyan
+			terminal.ActiveBuffer().CursorAttr().FgColour = buffer.PaletteColour(14)
 		case "97":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.White
+			terminal.ActiveBuffer().CursorAttr().FgColour = buffer.PaletteColour(15)
 		case "49":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.Background
+			terminal.ActiveBuffer().CursorAttr().BgColour = buffer.DefaultColour
 		case "40":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.Black
+			terminal.ActiveBuffer().CursorAttr().BgColour = buffer.PaletteColour(0)
 		case "41":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.Red
+			terminal.ActiveBuffer().CursorAttr().BgColour = buffer.PaletteColour(1)
 		case "42":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.Green
+			terminal.ActiveBuffer().CursorAttr().BgColour = buffer.PaletteColour(2)
 		case "43":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.Yellow
+			terminal.ActiveBuffer().CursorAttr().BgColour = buffer.PaletteColour(3)
 		case "44":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.Blue
+			terminal.ActiveBuffer().CursorAttr().BgColour = buffer.PaletteColour(4)
 		case "45":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.Magenta
+			terminal.ActiveBuffer().CursorAttr().BgColour = buffer.PaletteColour(5)
 		case "46":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.Cyan
+			terminal.ActiveBuffer().CursorAttr().BgColour = buffer.PaletteColour(6)
 		case "47":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.White
+			terminal.ActiveBuffer().CursorAttr().BgColour = buffer.PaletteColour(7)
 		case "100":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.DarkGrey
+			terminal.ActiveBuffer().CursorAttr().BgColour = buffer.PaletteColour(8)
 		case "101":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.LightRed
+			terminal.ActiveBuffer().CursorAttr().BgColour = buffer.PaletteColour(9)
 		case "102":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.LightGreen
+			terminal.ActiveBuffer().CursorAttr().BgColour = buffer.PaletteColour(10)
 		case "103":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.LightYellow
+			terminal.ActiveBuffer().CursorAttr().BgColour = buffer.PaletteColour(11)
 		case "104":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.LightBlue
+			terminal.ActiveBuffer().CursorAttr().BgColour = buffer.PaletteColour(12)
 		case "105":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.LightMagenta
+			terminal.ActiveBuffer().CursorAttr().BgColour = buffer.PaletteColour(13)
 		case "106":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.LightCyan
+			terminal.ActiveBuffer().CursorAttr().BgColour = buffer.PaletteColour(14)
 		case "107":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.White
+			terminal.ActiveBuffer().CursorAttr().BgColour = buffer.PaletteColour(15)
 		case "38": // set foreground
 			c, err := terminal.getANSIColour(params[i:])
 			if err != nil {
@@ -146,7 +146,7 @@ func sgrSequenceHandler(params []string, terminal *Terminal) error {
 	return nil
 }
 
-func (terminal *Terminal) getANSIColour(params []string) (config.Colour, error) {
+func (terminal *Terminal) getANSIColour(params []string) (buffer.Colour, error) {
 
 	if len(params) > 2 {
 		switch params[1] {
@@ -155,97 +155,72 @@ func (terminal *Terminal) getANSIColour(params []string) (config.Colour, error)
 			colNum, err := strconv.Atoi(params[2])
 
 			if err != nil || colNum >= 256 || colNum < 0 {
-				return [3]float32{0, 0, 0}, fmt.Errorf("Invalid 8-bit colour specifier")
+				return 0, fmt.Errorf("Invalid 8-bit colour specifier")
 			}
-			return terminal.get8BitSGRColour(uint8(colNum)), nil
+			return buffer.PaletteColour(uint8(colNum)), nil
 
 		case "2":
 			if len(params) < 4 {
-				return [3]float32{0, 0, 0}, fmt.Errorf("Invalid true colour specifier")
+				return 0, fmt.Errorf("Invalid true colour specifier")
 			}
 			// 24 bit colour
 			if len(params) == 5 { // standard true colour
 
 				r, err := strconv.Atoi(params[2])
 				if err != nil {
-					return [3]float32{0, 0, 0}, fmt.Errorf("Invalid true colour specifier")
+					return 0, fmt.Errorf("Invalid true colour specifier")
 				}
 				g, err := strconv.Atoi(params[3])
 				if err != nil {
-					return [3]float32{0, 0, 0}, fmt.Errorf("Invalid true colour specifier")
+					return 0, fmt.Errorf("Invalid true colour specifier")
 				}
 				b, err := strconv.Atoi(params[4])
 				if err != nil {
-					return [3]float32{0, 0, 0}, fmt.Errorf("Invalid true colour specifier")
+					return 0, fmt.Errorf("Invalid true colour specifier")
 				}
-				return [3]float32{
-					float32(r) / 0xff,
-					float32(g) / 0xff,
-					float32(b) / 0xff,
-				}, nil
+				return trueColour(r, g, b), nil
 			} else if len(params) > 5 { // ISO/IEC International Standard 8613-6
 				r, err := strconv.Atoi(params[3])
 				if err != nil {
-					return [3]float32{0, 0, 0}, fmt.Errorf("Invalid true colour specifier")
+					return 0, fmt.Errorf("Invalid true colour specifier")
 				}
 				g, err := strconv.Atoi(params[4])
 				if err != nil {
-					return [3]float32{0, 0, 0}, fmt.Errorf("Invalid true colour specifier")
+					return 0, fmt.Errorf("Invalid true colour specifier")
 				}
 				b, err := strconv.Atoi(params[5])
 				if err != nil {
-					return [3]float32{0, 0, 0}, fmt.Errorf("Invalid true colour specifier")
+					return 0, fmt.Errorf("Invalid true colour specifier")
 				}
-				return [3]float32{
-					float32(r) / 0xff,
-					float32(g) / 0xff,
-					float32(b) / 0xff,
-				}, nil
+				return trueColour(r, g, b), nil
 			}
 		}
 	}
 
-	return [3]float32{}, fmt.Errorf("Unknown ANSI colour format identifier")
+	return 0, fmt.Errorf("Unknown ANSI colour format identifier")
 
 }
 
+// trueColour creates a colour from the components given to SGR 38;2 or 48;2, which should be in the range 0-255
+func trueColour(r int, g int, b int) buffer.Colour {
+	var components [3]uint8
+	for i, c := range []int{r, g, b} {
+		if c > 0xff {
+			c = 0xff
+		}
+		if c > 0 {
+			components[i] = uint8(c)
+		}
+	}
+	return buffer.RGBColour(components[0], components[1], components[2])
+}
+
 func (terminal *Terminal) get8BitSGRColour(colNum uint8) [3]float32 {
 
 	// https://en.wikipedia.org/wiki/ANSI_escape_code#8-bit
 
-	switch colNum {
-	case 0:
-		return terminal.config.ColourScheme.Black
-	case 1:
-		return terminal.config.ColourScheme.Red
-	case 2:
-		return terminal.config.ColourScheme.Green
-	case 3:
-		return terminal.config.ColourScheme.Yellow
-	case 4:
-		return terminal.config.ColourScheme.Blue
-	case 5:
-		return terminal.config.ColourScheme.Magenta
-	case 6:
-		return terminal.config.ColourScheme.Cyan
-	case 7:
-		return terminal.config.ColourScheme.White
-	case 8:
-		return terminal.config.ColourScheme.DarkGrey
-	case 9:
-		return terminal.config.ColourScheme.LightRed
-	case 10:
-		return terminal.config.ColourScheme.LightGreen
-	case 11:
-		return terminal.config.ColourScheme.LightYellow
-	case 12:
-		return terminal.config.ColourScheme.LightBlue
-	case 13:
-		return terminal.config.ColourScheme.LightMagenta
-	case 14:
-		return terminal.config.ColourScheme.LightCyan
-	case 15:
-		return terminal.config.ColourScheme.White
+	if colNum < 16 {
+		return *terminal.settings.ColourScheme.ANSIColours()[colNum]
 	}
 
 	if colNum < 232 {
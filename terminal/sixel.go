@@ -13,11 +13,14 @@ import (
 
 type boolFormRuneFunc func(rune) bool
 
-func swallowByFunction(pty chan rune, isTerminator boolFormRuneFunc) {
+func swallowByFunction(pty chan rune, terminal *Terminal, isTerminator boolFormRuneFunc) error {
 	for {
-		b := <-pty
+		b, ok := terminal.nextRune(pty)
+		if !ok {
+			return errTruncatedSequence
+		}
 		if isTerminator(b) {
-			break
+			return nil
 		}
 	}
 }
@@ -32,7 +35,8 @@ func filter(src []rune) []rune {
 	return result
 }
 
-func sixelHandler(pty chan rune, terminal *Terminal) error {
+// sixelHandler draws a sixel image. The prefix holds any characters the DCS dispatcher has already read.
+func sixelHandler(prefix []rune, pty chan rune, terminal *Terminal) error {
 	debug := ""
 
 	// data := []rune{}
@@ -46,11 +50,26 @@ func sixelHandler(pty chan rune, terminal *Terminal) error {
 	yStartWithOffset := y + scrollOffset
 	matrix := matrix.NewAutoMatrix() // a simplified version of Buffer
 	for {
-		b := <-pty
+		var b rune
+		if len(prefix) > 0 {
+			b, prefix = prefix[0], prefix[1:]
+		} else {
+			var ok bool
+			b, ok = terminal.nextRune(pty)
+			if !ok {
+				return errTruncatedSequence
+			}
+		}
 		if b == 0x1b {
-			t := <-pty
+			t, ok := terminal.nextRune(pty)
+			if !ok {
+				return errTruncatedSequence
+			}
 			if t == '[' { // Windows injected a CSI sequence
-				final, param, _ := loadCSI(pty)
+				final, param, _, err := loadCSI(pty, terminal)
+				if err != nil {
+					return err
+				}
 
 				if final == 'H' {
 					// position cursor
@@ -66,7 +85,9 @@ func sixelHandler(pty chan rune, terminal *Terminal) error {
 			}
 			if t == ']' { // Windows injected an OSC sequence
 				// TODO: pass through as if it came via normal stream
-				swallowByFunction(pty, terminal.IsOSCTerminator)
+				if err := swallowByFunction(pty, terminal, terminal.IsOSCTerminator); err != nil {
+					return err
+				}
 				debug += "[OSC]"
 				continue
 			}
@@ -158,8 +179,8 @@ func drawSixel(six *sixel.Sixel, terminal *Terminal) {
 	for offsetY := 0; offsetY < lines-1; offsetY++ {
 		for offsetX := 0; offsetX < cols-1; offsetX++ {
 
-			cell := terminal.ActiveBuffer().GetCell(x+uint16(offsetX), y+uint16((lines-2)-offsetY))
-			if cell == nil {
+			col, row := x+uint16(offsetX), y+uint16((lines-2)-offsetY)
+			if terminal.ActiveBuffer().GetCell(col, row) == nil {
 				continue
 			}
 			img := originalImage.SubImage(image.Rect(
@@ -171,7 +192,7 @@ func drawSixel(six *sixel.Sixel, terminal *Terminal) {
 
 			rgba := image.NewRGBA(image.Rect(0, 0, int(terminal.charWidth), int(terminal.charHeight)))
 			draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
-			cell.SetImage(rgba)
+			terminal.ActiveBuffer().SetCellImage(col, row, rgba)
 		}
 	}
 }
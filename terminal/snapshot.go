@@ -0,0 +1,213 @@
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/liamg/aminal/buffer"
+)
+
+var lineSizeNames = map[buffer.LineSize]string{
+	buffer.LineSizeDoubleWidth:        "double-width",
+	buffer.LineSizeDoubleHeightTop:    "double-height-top",
+	buffer.LineSizeDoubleHeightBottom: "double-height-bottom",
+}
+
+// Snapshot describes the screen in a readable text format which can be compared against golden files. It records
+// the text of each row, the attributes which differ from the defaults, line sizes, the cursor, the modes and the
+// cells holding wide characters. The buffer keeps a wide character in a single cell, with no continuation cell
+// after it, so only the wide cells themselves are listed.
+//
+//	size 20x3
+//	cursor 5,1 visible
+//	modes autowrap linefeed
+//	rows
+//	0 |hello|
+//	1 |world| double-width
+//	2 |漢字|
+//	attributes
+//	0 0-4 bold fg=#ff0000
+//	wide
+//	2 0-1
+func (terminal *Terminal) Snapshot() []byte {
+	screen := terminal.ScreenBuffer()
+	out := &bytes.Buffer{}
+
+	col, row := terminal.Cursor()
+	visibility := "visible"
+	if !terminal.CursorVisible() {
+		visibility = "hidden"
+	}
+	fmt.Fprintf(out, "size %dx%d\n", screen.ViewWidth(), screen.ViewHeight())
+	fmt.Fprintf(out, "cursor %d,%d %s\n", col, row, visibility)
+	fmt.Fprintf(out, "modes %s\n", strings.Join(terminal.snapshotModes(), " "))
+
+	lines := screen.GetVisibleLines()
+	defaults := buffer.CellAttributes{
+		FgColour: buffer.ColourFromFloats(terminal.settings.ColourScheme.Foreground),
+		BgColour: buffer.ColourFromFloats(terminal.settings.ColourScheme.Background),
+	}
+
+	fmt.Fprintln(out, "rows")
+	for i := 0; i < int(screen.ViewHeight()); i++ {
+		text := ""
+		size := ""
+		if i < len(lines) {
+			text = lineText(lines[i])
+			if name, ok := lineSizeNames[lines[i].Size()]; ok {
+				size = " " + name
+			}
+		}
+		fmt.Fprintf(out, "%d |%s|%s\n", i, text, size)
+	}
+
+	fmt.Fprintln(out, "attributes")
+	for i := 0; i < len(lines) && i < int(screen.ViewHeight()); i++ {
+		cells := lines[i].Cells()
+		for start := 0; start < len(cells); {
+			attr := snapshotAttributes(&cells[start])
+			end := start
+			for end+1 < len(cells) {
+				next := snapshotAttributes(&cells[end+1])
+				if next != attr {
+					break
+				}
+				end++
+			}
+			if attr != defaults {
+				fmt.Fprintf(out, "%d %d-%d %s\n", i, start, end, describeAttributes(attr, defaults))
+			}
+			start = end + 1
+		}
+	}
+
+	fmt.Fprintln(out, "wide")
+	for i := 0; i < len(lines) && i < int(screen.ViewHeight()); i++ {
+		cells := lines[i].Cells()
+		for start := 0; start < len(cells); start++ {
+			if !isWide(cells[start].Rune()) {
+				continue
+			}
+			end := start
+			for end+1 < len(cells) && isWide(cells[end+1].Rune()) {
+				end++
+			}
+			if end > start {
+				fmt.Fprintf(out, "%d %d-%d\n", i, start, end)
+			} else {
+				fmt.Fprintf(out, "%d %d\n", i, start)
+			}
+			start = end
+		}
+	}
+
+	return out.Bytes()
+}
+
+// wideCharacters are the East Asian wide and fullwidth characters, which take up two columns
+var wideCharacters = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x1100, 0x115f, 1}, // Hangul Jamo initial consonants
+		{0x2329, 0x232a, 1},
+		{0x2e80, 0x303e, 1}, // CJK radicals, Kangxi radicals, ideographic description and CJK symbols
+		{0x3041, 0x33ff, 1}, // Hiragana, Katakana, Bopomofo, Hangul compatibility Jamo, Kanbun and CJK compatibility
+		{0x3400, 0x4dbf, 1}, // CJK unified ideographs extension A
+		{0x4e00, 0x9fff, 1}, // CJK unified ideographs
+		{0xa000, 0xa4cf, 1}, // Yi
+		{0xa960, 0xa97f, 1}, // Hangul Jamo extended A
+		{0xac00, 0xd7a3, 1}, // Hangul syllables
+		{0xf900, 0xfaff, 1}, // CJK compatibility ideographs
+		{0xfe10, 0xfe19, 1}, // vertical forms
+		{0xfe30, 0xfe6f, 1}, // CJK compatibility forms and small form variants
+		{0xff00, 0xff60, 1}, // fullwidth forms
+		{0xffe0, 0xffe6, 1},
+	},
+	R32: []unicode.Range32{
+		{0x1f300, 0x1f64f, 1}, // pictographs and emoticons
+		{0x1f900, 0x1f9ff, 1}, // supplemental symbols and pictographs
+		{0x20000, 0x2fffd, 1}, // CJK unified ideographs extensions
+		{0x30000, 0x3fffd, 1},
+	},
+}
+
+// isWide returns true if the character takes up two columns
+func isWide(r rune) bool {
+	return r >= 0x1100 && unicode.Is(wideCharacters, r)
+}
+
+// snapshotModes returns the names of the modes which are enabled
+func (terminal *Terminal) snapshotModes() []string {
+	state := terminal.terminalState
+	flags := []struct {
+		name    string
+		enabled bool
+	}{
+		{"alt-screen", terminal.ScreenBuffer() == terminal.buffers[1]},
+		{"app-cursor-keys", terminal.modes.ApplicationCursorKeys},
+		{"autowrap", state.AutoWrap},
+		{"bracketed-paste", terminal.bracketedPasteMode},
+		{"linefeed", state.LineFeedMode},
+		{"origin", state.OriginMode},
+		{"replace", state.ReplaceMode},
+		{"reverse-screen", state.ScreenMode},
+		{"vt52", terminal.modes.VT52},
+	}
+
+	names := []string{}
+	for _, flag := range flags {
+		if flag.enabled {
+			names = append(names, flag.name)
+		}
+	}
+	if terminal.mouseMode != MouseModeNone {
+		names = append(names, fmt.Sprintf("mouse=%d", terminal.mouseMode))
+	}
+	return names
+}
+
+// snapshotAttributes returns the attributes of the cell as a snapshot shows them: with the colours they resolve to,
+// and without the protection, which has no visible effect
+func snapshotAttributes(cell *buffer.Cell) buffer.CellAttributes {
+	attr := cell.Attr()
+	fg, bg := cell.Colours()
+	attr.FgColour, attr.BgColour = buffer.ColourFromFloats(fg), buffer.ColourFromFloats(bg)
+	attr.Protected = false
+	return attr
+}
+
+// describeAttributes lists the attributes which differ from the defaults
+func describeAttributes(attr buffer.CellAttributes, defaults buffer.CellAttributes) string {
+	flags := []struct {
+		name    string
+		enabled bool
+	}{
+		{"bold", attr.Bold},
+		{"dim", attr.Dim},
+		{"italic", attr.Italic},
+		{"underline", attr.Underline},
+		{"blink", attr.Blink},
+		{"inverse", attr.Inverse},
+		{"hidden", attr.Hidden},
+	}
+
+	parts := []string{}
+	for _, flag := range flags {
+		if flag.enabled {
+			parts = append(parts, flag.name)
+		}
+	}
+	if attr.FgColour != defaults.FgColour {
+		parts = append(parts, "fg="+colourHex(attr.FgColour))
+	}
+	if attr.BgColour != defaults.BgColour {
+		parts = append(parts, "bg="+colourHex(attr.BgColour))
+	}
+	return strings.Join(parts, " ")
+}
+
+func colourHex(colour buffer.Colour) string {
+	r, g, b := colour.RGB()
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
@@ -0,0 +1,101 @@
+package terminal
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/liamg/aminal/buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateSnapshots = flag.Bool("update", false, "rewrite the golden snapshot files instead of comparing against them")
+
+const snapshotDir = "testdata/snapshots"
+
+// checkSnapshot replays a stream of output through a headless terminal and compares the screen against a golden file
+func checkSnapshot(t *testing.T, name string, stream []byte) {
+	headless := NewHeadless(80, 24, HeadlessOptions{})
+	defer headless.Close()
+	headless.Feed(stream)
+	actual := headless.Snapshot()
+
+	golden := filepath.Join(snapshotDir, name+".snap")
+	if *updateSnapshots {
+		require.NoError(t, ioutil.WriteFile(golden, actual, 0644))
+		return
+	}
+
+	expected, err := ioutil.ReadFile(golden)
+	require.NoError(t, err, "missing golden file, run the tests with -update to create it")
+	assert.Equal(t, string(expected), string(actual))
+}
+
+func TestDemoSnapshots(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is needed to run the demo scripts")
+	}
+
+	scripts, err := filepath.Glob("../demo/*.sh")
+	require.NoError(t, err)
+	require.NotEmpty(t, scripts)
+
+	for _, script := range scripts {
+		name := "demo-" + strings.TrimSuffix(filepath.Base(script), ".sh")
+		t.Run(name, func(t *testing.T) {
+			// the pauses between steps are skipped, and the width is fixed for scripts which size their output to the terminal
+			cmd := exec.Command("bash", "-c", `sleep() { :; }; . "$0"`, script)
+			cmd.Env = append(os.Environ(), "width=80", "COLUMNS=80", "LINES=24")
+			output, err := cmd.Output()
+			require.NoError(t, err)
+			// a pty would translate new lines for us (onlcr)
+			checkSnapshot(t, name, bytes.Replace(output, []byte("\n"), []byte("\r\n"), -1))
+		})
+	}
+}
+
+// TestVttestSnapshots replays the output of vttest screens, recorded as vttest/*.stream beside the screenshots of
+// them, e.g. with script -q -c vttest. None have been recorded yet, since the streams must come from vttest itself.
+func TestVttestSnapshots(t *testing.T) {
+	streams, err := filepath.Glob("../vttest/*.stream")
+	require.NoError(t, err)
+	if len(streams) == 0 {
+		t.Skip("no vttest streams have been recorded")
+	}
+
+	for _, stream := range streams {
+		name := "vttest-" + strings.TrimSuffix(filepath.Base(stream), ".stream")
+		t.Run(name, func(t *testing.T) {
+			data, err := ioutil.ReadFile(stream)
+			require.NoError(t, err)
+			checkSnapshot(t, name, data)
+		})
+	}
+}
+
+func TestSnapshotFormat(t *testing.T) {
+	headless := NewHeadless(10, 3, HeadlessOptions{})
+	defer headless.Close()
+	headless.FeedString("ab\x1b[1;31mcd\x1b[m\r\n\x1b#6wide\r\n漢字a가\x1b[?25l")
+
+	expected := `size 10x3
+cursor 4,2 hidden
+modes autowrap linefeed
+rows
+0 |abcd|
+1 |wide| double-width
+2 |漢字a가|
+attributes
+0 2-3 bold fg=` + colourHex(buffer.ColourFromFloats(headless.settings.ColourScheme.Red)) + `
+wide
+2 0-1
+2 3
+`
+	assert.Equal(t, expected, string(headless.Snapshot()))
+}
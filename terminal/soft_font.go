@@ -0,0 +1,229 @@
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// https://vt100.net/docs/vt510-rm/DECDLD.html
+
+const (
+	// softFontRuneBase is the start of the runes that soft font characters are mapped into, so that they pass
+	// through the buffer like any other character. They are past the end of Unicode, so unlike the private use
+	// areas, which icon fonts use, they can't come from decoding the output of a program.
+	softFontRuneBase rune = 0x110000
+	// maxSoftFontSize limits the glyph matrix, to avoid huge allocations from bad parameters
+	maxSoftFontSize = 64
+	// maxSoftFonts limits the number of soft fonts, each of which has a block of 0x80 runes; loading another
+	// replaces the oldest
+	maxSoftFonts = 16
+)
+
+// SoftGlyph is the bitmap of a character loaded with DECDLD
+type SoftGlyph struct {
+	Width  int
+	Height int
+	pixels []bool
+}
+
+func newSoftGlyph(width int, height int) *SoftGlyph {
+	return &SoftGlyph{
+		Width:  width,
+		Height: height,
+		pixels: make([]bool, width*height),
+	}
+}
+
+// At returns true if the pixel at the given position is set
+func (glyph *SoftGlyph) At(x int, y int) bool {
+	if x < 0 || y < 0 || x >= glyph.Width || y >= glyph.Height {
+		return false
+	}
+	return glyph.pixels[y*glyph.Width+x]
+}
+
+func (glyph *SoftGlyph) set(x int, y int) {
+	if x < glyph.Width && y < glyph.Height {
+		glyph.pixels[y*glyph.Width+x] = true
+	}
+}
+
+// softFont is a dynamically redefinable character set (DRCS)
+type softFont struct {
+	is96    bool
+	base    rune          // first rune of the block allocated to this font
+	charset map[rune]rune // the G set table, mapping loaded characters into the block
+}
+
+// SoftGlyph returns the soft font bitmap for the given rune, or nil if it is an ordinary character
+func (terminal *Terminal) SoftGlyph(r rune) *SoftGlyph {
+	if r < softFontRuneBase {
+		return nil
+	}
+	terminal.softFontLock.RLock()
+	defer terminal.softFontLock.RUnlock()
+	return terminal.softGlyphs[r]
+}
+
+// lookupSoftFont returns the charset table of the soft font with the given name, if there is one of the right size
+func (terminal *Terminal) lookupSoftFont(name string, is96 bool) *map[rune]rune {
+	font, ok := terminal.softFonts[name]
+	if !ok || font.is96 != is96 {
+		return nil
+	}
+	return &font.charset
+}
+
+// parseSoftFontParam returns params[index] as a number, or zero if it is missing or invalid
+func parseSoftFontParam(params []string, index int) int {
+	if index >= len(params) {
+		return 0
+	}
+	n, err := strconv.Atoi(params[index])
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// softFontMatrix returns the glyph size selected by Pcmw and Pcmh
+func softFontMatrix(pcmw int, pcmh int) (int, int) {
+	width, height := pcmw, pcmh
+
+	switch pcmw {
+	case 0, 1:
+		width = 10
+	case 2, 3, 4:
+		// VT220 compatible 5x10, 6x10 and 7x10 matrices
+		width = pcmw + 3
+		if height == 0 {
+			height = 10
+		}
+	}
+	if height == 0 {
+		height = 16
+	}
+
+	if width > maxSoftFontSize {
+		width = maxSoftFontSize
+	}
+	if height > maxSoftFontSize {
+		height = maxSoftFontSize
+	}
+	return width, height
+}
+
+// DCS Pfn ; Pcn ; Pe ; Pcmw ; Pss ; Pt ; Pcmh ; Pcss { Dscs Sxbp1 ; Sxbp2 ; ... ST
+func softFontHandler(param string, pty chan rune, terminal *Terminal) error {
+	data, err := readStringTerminatedData(pty, terminal)
+	if err != nil {
+		return err
+	}
+
+	params := splitParams(param)
+	first := parseSoftFontParam(params, 1)
+	erase := parseSoftFontParam(params, 2)
+	width, height := softFontMatrix(parseSoftFontParam(params, 3), parseSoftFontParam(params, 6))
+	is96 := parseSoftFontParam(params, 7) == 1
+
+	// Dscs is up to two intermediates followed by a final character
+	i := 0
+	for i < len(data) && i < 2 && data[i] >= 0x20 && data[i] <= 0x2f {
+		i++
+	}
+	if i >= len(data) || data[i] < 0x30 || data[i] > 0x7e {
+		return fmt.Errorf("Invalid soft font designator in DECDLD")
+	}
+	name := string(data[:i+1])
+	data = data[i+1:]
+
+	terminal.softFontLock.Lock()
+	defer terminal.softFontLock.Unlock()
+
+	if erase == 2 {
+		// erase all soft fonts, although their allocated blocks are kept for reuse
+		for _, font := range terminal.softFonts {
+			terminal.eraseSoftFont(font)
+		}
+	}
+
+	font, ok := terminal.softFonts[name]
+	if !ok {
+		font = &softFont{
+			base:    softFontRuneBase + rune(len(terminal.softFonts))*0x80,
+			charset: map[rune]rune{},
+		}
+		if len(terminal.softFontNames) >= maxSoftFonts {
+			// take over the block of the oldest font, which is left empty for any G set still using it
+			oldest := terminal.softFonts[terminal.softFontNames[0]]
+			terminal.eraseSoftFont(oldest)
+			delete(terminal.softFonts, terminal.softFontNames[0])
+			terminal.softFontNames = terminal.softFontNames[1:]
+			font.base = oldest.base
+		}
+		terminal.softFonts[name] = font
+		terminal.softFontNames = append(terminal.softFontNames, name)
+	}
+	if font.is96 != is96 {
+		terminal.eraseSoftFont(font)
+		font.is96 = is96
+	} else if erase == 0 {
+		terminal.eraseSoftFont(font)
+	}
+
+	// a 94 character set has no glyphs for SP and DEL
+	min, max := rune(0x21), rune(0x7e)
+	if is96 {
+		min, max = 0x20, 0x7f
+	}
+
+	// each glyph is a series of sixel rows separated by '/', and glyphs are separated by ';'
+	ch := rune(0x20 + first)
+	glyph := newSoftGlyph(width, height)
+	x, y := 0, 0
+	pending := false
+	store := func() {
+		if ch >= min && ch <= max {
+			r := font.base + ch
+			font.charset[ch] = r
+			terminal.softGlyphs[r] = glyph
+		}
+		ch++
+		glyph = newSoftGlyph(width, height)
+		x, y = 0, 0
+		pending = false
+	}
+
+	for _, b := range data {
+		pending = true
+		switch {
+		case b == ';':
+			store()
+		case b == '/':
+			x = 0
+			y += 6
+		case b >= '?' && b <= '~':
+			bits := b - '?'
+			for bit := 0; bit < 6; bit++ {
+				if bits&(1<<uint(bit)) != 0 {
+					glyph.set(x, y+bit)
+				}
+			}
+			x++
+		}
+	}
+	if pending {
+		store()
+	}
+
+	terminal.SetDirty()
+	return nil
+}
+
+// eraseSoftFont removes all characters from the font, leaving its charset table in place for any G set using it
+func (terminal *Terminal) eraseSoftFont(font *softFont) {
+	for ch, r := range font.charset {
+		delete(terminal.softGlyphs, r)
+		delete(font.charset, ch)
+	}
+}
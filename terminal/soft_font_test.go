@@ -0,0 +1,145 @@
+package terminal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func firstLineRunes(terminal *Terminal) []rune {
+	runes := []rune{}
+	for _, cell := range terminal.ActiveBuffer().GetVisibleLines()[0].Cells() {
+		runes = append(runes, cell.Rune())
+	}
+	return runes
+}
+
+func TestSoftFontLoadAndDesignate(t *testing.T) {
+	terminal := newTestTerminal(20, 4)
+
+	// two glyphs in a 10x16 matrix, starting at '!'
+	process(terminal, "\x1bP0;1;0;0;0;0;0;0{ @~@/~;?A\x1b\\")
+	process(terminal, "\x1b( @!\"#\x1b(B!")
+
+	runes := firstLineRunes(terminal)
+	require.Len(t, runes, 4)
+	assert.Equal(t, '#', runes[2])
+	assert.Equal(t, '!', runes[3])
+
+	glyph := terminal.SoftGlyph(runes[0])
+	require.NotNil(t, glyph)
+	assert.Equal(t, 10, glyph.Width)
+	assert.Equal(t, 16, glyph.Height)
+	for y := 0; y < 12; y++ {
+		assert.True(t, glyph.At(0, y), "pixel 0,%d should be set", y)
+	}
+	assert.True(t, glyph.At(1, 0))
+	assert.False(t, glyph.At(1, 1))
+	assert.False(t, glyph.At(0, 12))
+
+	glyph = terminal.SoftGlyph(runes[1])
+	require.NotNil(t, glyph)
+	assert.True(t, glyph.At(1, 1))
+	assert.False(t, glyph.At(0, 0))
+
+	assert.Nil(t, terminal.SoftGlyph('#'))
+}
+
+func TestSoftFontsLeavePrivateUseCharactersAlone(t *testing.T) {
+	terminal := newTestTerminal(20, 4)
+	process(terminal, "\x1bP0;1;0;0;0;0;0;0{ @~@/~;?A\x1b\\")
+	process(terminal, "\x1b( @!\x1b(B\U000f0021\U000f0022")
+
+	runes := firstLineRunes(terminal)
+	require.Len(t, runes, 3)
+	assert.NotNil(t, terminal.SoftGlyph(runes[0]))
+	assert.Equal(t, []rune{0xf0021, 0xf0022}, runes[1:])
+	assert.Nil(t, terminal.SoftGlyph(0xf0021))
+	assert.Nil(t, terminal.SoftGlyph(0xf0022))
+}
+
+func TestSoftFontErase(t *testing.T) {
+	terminal := newTestTerminal(20, 4)
+	process(terminal, "\x1bP0;1;0;2;0;0;0;0{ @~;~\x1b\\")
+
+	// Pe=1 keeps the characters that are not reloaded
+	process(terminal, "\x1bP0;2;1;2;0;0;0;0{ @?\x1b\\\x1b( @!\"")
+	runes := firstLineRunes(terminal)
+	glyph := terminal.SoftGlyph(runes[0])
+	require.NotNil(t, glyph)
+	assert.Equal(t, 5, glyph.Width)
+	assert.Equal(t, 10, glyph.Height)
+	assert.True(t, glyph.At(0, 0))
+	glyph = terminal.SoftGlyph(runes[1])
+	require.NotNil(t, glyph)
+	assert.False(t, glyph.At(0, 0))
+
+	// Pe=0 erases the whole set before loading
+	process(terminal, "\x1bP0;2;0;2;0;0;0;0{ @?\x1b\\\r!\"")
+	runes = firstLineRunes(terminal)
+	assert.Equal(t, '!', runes[0])
+	assert.NotNil(t, terminal.SoftGlyph(runes[1]))
+}
+
+func TestSoftFont96CharacterSet(t *testing.T) {
+	terminal := NewHeadless(20, 4, HeadlessOptions{})
+	defer terminal.Close()
+
+	// a 96 character set starts at SP, and can only be designated with a 96 character SCS
+	terminal.FeedString("\x1bP0;0;0;0;0;0;0;1{ A~\x1b\\")
+	terminal.Feed([]byte("\x1b( A \x1b- A\x1b~\xa0"))
+
+	runes := firstLineRunes(terminal.Terminal)
+	require.Len(t, runes, 2)
+	assert.Nil(t, terminal.SoftGlyph(runes[0]))
+	assert.NotNil(t, terminal.SoftGlyph(runes[1]))
+}
+
+func TestSoftFontInterruptedByAnotherSequence(t *testing.T) {
+	terminal := newTestTerminal(20, 4)
+	process(terminal, "\x1bP0;1;0;0;0;0;0;0{ @~~~\x1b[1mX")
+
+	assert.Empty(t, terminal.softGlyphs)
+	cells := terminal.ActiveBuffer().GetVisibleLines()[0].Cells()
+	require.Len(t, cells, 1)
+	assert.Equal(t, 'X', cells[0].Rune())
+	assert.True(t, cells[0].Attr().Bold)
+}
+
+func TestSoftFontBlocksAreReused(t *testing.T) {
+	terminal := newTestTerminal(20, 4)
+	for i := 0; i < maxSoftFonts+4; i++ {
+		process(terminal, fmt.Sprintf("\x1bP0;1;0;0;0;0;0;0{ %c~\x1b\\", '@'+i))
+	}
+
+	require.Len(t, terminal.softFonts, maxSoftFonts)
+	assert.Equal(t, " D", terminal.softFontNames[0])
+	assert.NotContains(t, terminal.softFonts, " C")
+	bases := map[rune]bool{}
+	for _, font := range terminal.softFonts {
+		assert.False(t, bases[font.base])
+		bases[font.base] = true
+		assert.True(t, font.base < softFontRuneBase+maxSoftFonts*0x80)
+	}
+	assert.Len(t, terminal.softGlyphs, maxSoftFonts)
+
+	process(terminal, fmt.Sprintf("\x1b( %c!", '@'+maxSoftFonts+3))
+	assert.NotNil(t, terminal.SoftGlyph(firstLineRunes(terminal)[0]))
+}
+
+func TestDCSStillDrawsSixel(t *testing.T) {
+	terminal := newTestTerminal(20, 8)
+	process(terminal, "\x1bPq#0;2;100;0;0#0!16~-!16~-!16~\x1b\\")
+
+	found := false
+	for _, line := range terminal.ActiveBuffer().GetVisibleLines() {
+		for _, cell := range line.Cells() {
+			if cell.Image() != nil {
+				found = true
+			}
+		}
+	}
+	assert.True(t, found)
+}
@@ -0,0 +1,102 @@
+package terminal
+
+import (
+	"fmt"
+
+	"github.com/liamg/aminal/buffer"
+)
+
+// https://vt100.net/docs/vt510-rm/DECSSDT.html
+// https://vt100.net/docs/vt510-rm/DECSASD.html
+
+type StatusLineType uint8
+
+const (
+	StatusLineNone         StatusLineType = iota // no status line is shown
+	StatusLineIndicator                          // the terminal shows its own status, such as the cursor position
+	StatusLineHostWritable                       // the host writes to the status line using DECSASD
+)
+
+// StatusLineType returns the kind of status line selected by DECSSDT
+func (terminal *Terminal) StatusLineType() StatusLineType {
+	return terminal.statusLineType
+}
+
+// StatusLine returns the one line buffer holding the host-writable status line
+func (terminal *Terminal) StatusLine() *buffer.Buffer {
+	return terminal.statusLine
+}
+
+// writingToStatusLine returns true if DECSASD has directed output to a host-writable status line
+func (terminal *Terminal) writingToStatusLine() bool {
+	return terminal.statusLineActive && terminal.statusLineType == StatusLineHostWritable
+}
+
+// screenHeight returns the number of rows available to the screen, excluding any status line
+func (terminal *Terminal) screenHeight() uint16 {
+	if terminal.statusLineType != StatusLineNone && terminal.size.Height > 1 {
+		return terminal.size.Height - 1
+	}
+	return terminal.size.Height
+}
+
+// SetStatusLineType shows or hides the status line, giving the row it needs to or from the screen
+func (terminal *Terminal) SetStatusLineType(statusLineType StatusLineType) error {
+	if terminal.statusLineType == statusLineType {
+		return nil
+	}
+
+	terminal.lock.Lock()
+	defer terminal.lock.Unlock()
+
+	if statusLineType == StatusLineHostWritable {
+		terminal.statusLine.Clear()
+	} else {
+		terminal.statusLineActive = false
+	}
+
+	terminal.statusLineType = statusLineType
+	err := terminal.applySize()
+	terminal.SetDirty()
+	return err
+}
+
+// CSI Ps $ ~
+func csiSelectStatusLineTypeHandler(params []string, terminal *Terminal) error {
+	n := "0"
+	if len(params) > 0 {
+		n = params[0]
+	}
+
+	switch n {
+	case "0", "":
+		return terminal.SetStatusLineType(StatusLineNone)
+	case "1":
+		return terminal.SetStatusLineType(StatusLineIndicator)
+	case "2":
+		return terminal.SetStatusLineType(StatusLineHostWritable)
+	}
+
+	return fmt.Errorf("Unsupported DECSSDT: CSI %s $ ~", n)
+}
+
+// CSI Ps $ }
+func csiSelectActiveStatusDisplayHandler(params []string, terminal *Terminal) error {
+	n := "0"
+	if len(params) > 0 {
+		n = params[0]
+	}
+
+	switch n {
+	case "0", "":
+		terminal.statusLineActive = false
+	case "1":
+		// output is only sent to the status line if it is host-writable, otherwise it stays on the screen
+		terminal.statusLineActive = true
+	default:
+		return fmt.Errorf("Unsupported DECSASD: CSI %s $ }", n)
+	}
+
+	terminal.SetDirty()
+	return nil
+}
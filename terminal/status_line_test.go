@@ -0,0 +1,60 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusLineTakesScreenRow(t *testing.T) {
+	terminal := newTestTerminal(20, 5)
+	assert.Equal(t, StatusLineNone, terminal.StatusLineType())
+
+	process(terminal, "\x1b[2$~")
+	assert.Equal(t, StatusLineHostWritable, terminal.StatusLineType())
+	assert.Equal(t, uint16(4), terminal.ScreenBuffer().ViewHeight())
+	assert.Equal(t, uint16(20), terminal.StatusLine().ViewWidth())
+
+	process(terminal, "\x1b[1$~")
+	assert.Equal(t, StatusLineIndicator, terminal.StatusLineType())
+	assert.Equal(t, uint16(4), terminal.ScreenBuffer().ViewHeight())
+
+	process(terminal, "\x1b[0$~")
+	assert.Equal(t, StatusLineNone, terminal.StatusLineType())
+	assert.Equal(t, uint16(5), terminal.ScreenBuffer().ViewHeight())
+}
+
+func TestHostWritableStatusLine(t *testing.T) {
+	terminal := newTestTerminal(20, 5)
+	process(terminal, "\x1b[2$~\x1b[3;4Hmain")
+
+	process(terminal, "\x1b[1$}\x1b[1mbuild 42%")
+	assert.Equal(t, "build 42%", terminal.StatusLine().GetVisibleLines()[0].String())
+	assert.True(t, terminal.StatusLine().GetVisibleLines()[0].Cells()[0].Attr().Bold)
+
+	// the screen keeps its own cursor and attributes
+	process(terminal, "\x1b[0$}!")
+	assert.Equal(t, "\x00\x00\x00main!", screenText(terminal)[2])
+	assert.False(t, terminal.ScreenBuffer().GetVisibleLines()[2].Cells()[7].Attr().Bold)
+
+	// returning to the status line continues where it left off
+	process(terminal, "\x1b[1$} done\x1b[0$}")
+	assert.Equal(t, "build 42% done", terminal.StatusLine().GetVisibleLines()[0].String())
+	col, line := cursorPosition(terminal)
+	assert.Equal(t, uint16(8), col)
+	assert.Equal(t, uint16(2), line)
+}
+
+func TestStatusLineOutputNeedsHostWritableType(t *testing.T) {
+	terminal := newTestTerminal(20, 5)
+	process(terminal, "\x1b[1$}text")
+	assert.Equal(t, "text", screenText(terminal)[0])
+
+	// hiding the status line also returns output to the screen
+	process(terminal, "\x1b[2$~\x1b[1$}status\x1b[0$~ more")
+	assert.Equal(t, "text more", screenText(terminal)[0])
+
+	// and selecting it again starts with a blank status line
+	process(terminal, "\x1b[2$~")
+	assert.Equal(t, "", terminal.StatusLine().GetVisibleLines()[0].String())
+}
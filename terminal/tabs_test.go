@@ -0,0 +1,44 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTabStopReport(t *testing.T) {
+	pty := &replyPty{}
+	terminal := newTestTerminal(20, 5)
+	terminal.pty = pty
+
+	// the default tab width is 4
+	process(terminal, "\x1b[2$w")
+	assert.Equal(t, "\x1bP2$u5/9/13/17\x1b\\", string(pty.replies))
+
+	pty.replies = nil
+	process(terminal, "\x1b[3g\x1b[1;6H\x1bH\x1b[1;12H\x1bH\x1b[2$w")
+	assert.Equal(t, "\x1bP2$u6/12\x1b\\", string(pty.replies))
+	assert.Equal(t, []uint16{5, 11}, terminal.terminalState.TabStops())
+
+	// with 8-bit controls selected the reply uses DCS and ST
+	pty.replies = nil
+	process(terminal, "\x1b G\x1b[2$w")
+	assert.Equal(t, "\x902$u6/12\x9c", string(pty.replies))
+
+	pty.replies = nil
+	process(terminal, "\x1b[3g\x1b[2$w")
+	assert.Equal(t, "\x902$u\x9c", string(pty.replies))
+}
+
+func TestSetTabStopsEvery8Columns(t *testing.T) {
+	pty := &replyPty{}
+	terminal := newTestTerminal(20, 5)
+	terminal.pty = pty
+
+	process(terminal, "\x1b[3g\x1b[1;4H\x1bH\x1b[?5W")
+	assert.Equal(t, []uint16{8, 16}, terminal.terminalState.TabStops())
+
+	process(terminal, "\x1b[H\tx\x1b[2$w")
+	assert.Equal(t, "        x", screenText(terminal)[0])
+	assert.Equal(t, "\x1bP2$u9/17\x1b\\", string(pty.replies))
+}
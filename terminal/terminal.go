@@ -4,12 +4,12 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"os"
 	"sync"
 
+	"github.com/liamg/aminal/asciicast"
 	"github.com/liamg/aminal/buffer"
-	"github.com/liamg/aminal/config"
 	"github.com/liamg/aminal/platform"
-	"go.uber.org/zap"
 )
 
 const (
@@ -39,10 +39,10 @@ type Terminal struct {
 	activeBuffer              *buffer.Buffer
 	lock                      sync.Mutex
 	pty                       platform.Pty
-	logger                    *zap.SugaredLogger
+	logger                    Logger
 	title                     string
 	size                      Winsize
-	config                    *config.Config
+	settings                  Settings
 	titleHandlers             []chan bool
 	resizeHandlers            []chan bool
 	reverseHandlers           []chan bool
@@ -55,13 +55,38 @@ type Terminal struct {
 	charHeight                float32
 	lastBuffer                uint8
 	terminalState             *buffer.TerminalState
+	statusLine                *buffer.Buffer
+	statusLineState           *buffer.TerminalState
+	statusLineType            StatusLineType
+	statusLineActive          bool // DECSASD - output goes to the status line rather than the screen
+	softFonts                 map[string]*softFont
+	softFontNames             []string // the names of the soft fonts, oldest first
+	softGlyphs                map[rune]*SoftGlyph
+	softFontLock              sync.RWMutex
+	vt52SavedG0               *map[rune]rune // the G0 charset from before VT52 graphics mode, to go back to after it
+	printerController         io.WriteCloser // the print job receiving output in printer controller mode
+	printerControllerPending  []rune         // output which may be the start of the sequence ending printer controller mode
+	autoPrintJob              io.WriteCloser
+	printJobs                 sync.WaitGroup // print commands which are still running
+	recording                 *asciicast.Writer
+	recordingFile             *os.File
+	recordingLock             sync.Mutex
+	sessionLog                *sessionLog
+	sessionLogLock            sync.Mutex
 	platformDependentSettings platform.PlatformDependentSettings
+	inputRead                 int            // runes the parser has read from the pty
+	pendingRune               rune           // the second half of a C1 control translated into ESC Fe, to be read next
+	inputWaiting              func(read int) // called when the parser has read all of its input, before it waits for more
 }
 
 type Modes struct {
 	ShowCursor            bool
 	ApplicationCursorKeys bool
 	BlinkingCursor        bool
+	EightBitControls      bool // S8C1T - whether replies use 8-bit C1 controls
+	VT52                  bool // DECANM reset - escape sequences are interpreted as by a VT52
+	VT52Graphics          bool // ESC F - G0 is the line drawing charset until ESC G
+	AutoPrint             bool // lines are sent to the printer as the cursor leaves them
 }
 
 type Winsize struct {
@@ -71,16 +96,19 @@ type Winsize struct {
 	y      uint16 //ignored, but necessary for ioctl calls
 }
 
-func New(pty platform.Pty, logger *zap.SugaredLogger, config *config.Config) *Terminal {
+// New creates a terminal reading output from the pty. The logger may be nil.
+func New(pty platform.Pty, logger Logger, settings Settings) *Terminal {
+	if logger == nil {
+		logger = nopLogger{}
+	}
 	t := &Terminal{
-		terminalState: buffer.NewTerminalState(1, 1, buffer.CellAttributes{
-			FgColour: config.ColourScheme.Foreground,
-			BgColour: config.ColourScheme.Background,
-		}, config.MaxLines),
+		terminalState: buffer.NewTerminalState(1, 1, buffer.CellAttributes{}, settings.MaxLines),
 		pty:           pty,
 		logger:        logger,
-		config:        config,
+		settings:      settings,
 		titleHandlers: []chan bool{},
+		softFonts:     map[string]*softFont{},
+		softGlyphs:    map[rune]*SoftGlyph{},
 		modes: Modes{
 			ShowCursor: true,
 		},
@@ -92,10 +120,29 @@ func New(pty platform.Pty, logger *zap.SugaredLogger, config *config.Config) *Te
 		buffer.NewBuffer(t.terminalState),
 	}
 	t.activeBuffer = t.buffers[0]
+	t.statusLineState = buffer.NewTerminalState(1, 1, buffer.CellAttributes{}, 0)
+	t.statusLine = buffer.NewBuffer(t.statusLineState)
+	t.applyPalette(t.palette())
+	t.buffers[AltBuffer].SetMaxLines(settings.AltMaxLines)
+	t.buffers[MainBuffer].SetScrollbackHandler(t.logScrolledLine)
+	if settings.ScrollbackMemoryLines > 0 {
+		t.buffers[MainBuffer].EnableScrollbackStore(settings.ScrollbackMemoryLines, settings.ScrollbackSpill)
+	}
+	for _, b := range t.buffers {
+		b.SetMaxBytes(settings.MaxScrollbackBytes)
+	}
+	if settings.TabWidth > 0 {
+		t.terminalState.SetTabWidth(settings.TabWidth)
+	}
 	return t
 
 }
 
+// SetSlomo switches slow motion, in which output is processed slowly enough to watch, for debugging
+func (terminal *Terminal) SetSlomo(enabled bool) {
+	terminal.settings.Slomo = enabled
+}
+
 func (terminal *Terminal) SetProgram(program uint32) {
 	terminal.program = program
 }
@@ -107,7 +154,8 @@ func (terminal *Terminal) SetBracketedPasteMode(enabled bool) {
 func (terminal *Terminal) CheckDirty() bool {
 	d := terminal.isDirty
 	terminal.isDirty = false
-	return d || terminal.ActiveBuffer().IsDirty()
+	statusLineDirty := terminal.statusLine.IsDirty()
+	return terminal.ScreenBuffer().IsDirty() || statusLineDirty || d
 }
 
 func (terminal *Terminal) SetDirty() {
@@ -149,6 +197,17 @@ func (terminal *Terminal) UseAltBuffer() {
 	terminal.SetSize(uint(terminal.size.Width), uint(terminal.size.Height))
 }
 
+// enterAltScreen saves the cursor, then switches to a cleared alternate screen with the cursor where it was (1049)
+func (terminal *Terminal) enterAltScreen() {
+	main := terminal.buffers[MainBuffer]
+	alt := terminal.buffers[AltBuffer]
+
+	main.SaveCursor()
+	terminal.UseAltBuffer()
+	alt.EraseDisplay()
+	alt.SetPosition(main.CursorColumn(), main.CursorLine())
+}
+
 func (terminal *Terminal) UseInternalBuffer() {
 	terminal.activeBuffer = terminal.buffers[InternalBuffer]
 	terminal.SetSize(uint(terminal.size.Width), uint(terminal.size.Height))
@@ -158,7 +217,17 @@ func (terminal *Terminal) ExitInternalBuffer() {
 	terminal.activeBuffer = terminal.buffers[terminal.lastBuffer]
 }
 
+// ActiveBuffer returns the buffer which receives output from the host. This is the current screen,
+// unless DECSASD has directed output to the status line.
 func (terminal *Terminal) ActiveBuffer() *buffer.Buffer {
+	if terminal.writingToStatusLine() {
+		return terminal.statusLine
+	}
+	return terminal.activeBuffer
+}
+
+// ScreenBuffer returns the buffer of the screen currently on display
+func (terminal *Terminal) ScreenBuffer() *buffer.Buffer {
 	return terminal.activeBuffer
 }
 
@@ -172,7 +241,7 @@ func (terminal *Terminal) GetScrollOffset() uint {
 
 func (terminal *Terminal) ScreenScrollDown(lines uint16) {
 	defer terminal.SetDirty()
-	buffer := terminal.ActiveBuffer()
+	buffer := terminal.ScreenBuffer()
 
 	if buffer.Height() < int(buffer.ViewHeight()) {
 		return
@@ -200,21 +269,32 @@ func (terminal *Terminal) AreaScrollDown(lines uint16) {
 
 func (terminal *Terminal) ScreenScrollUp(lines uint16) {
 	defer terminal.SetDirty()
-	buffer := terminal.ActiveBuffer()
+	buffer := terminal.ScreenBuffer()
 
-	if buffer.Height() < int(buffer.ViewHeight()) {
+	if buffer.TotalHeight() < int(buffer.ViewHeight()) {
 		return
 	}
 
 	offset := terminal.terminalState.GetScrollOffset()
 
-	if uint(lines)+offset >= (uint(buffer.Height()) - uint(buffer.ViewHeight())) {
-		terminal.terminalState.SetScrollOffset(uint(buffer.Height()) - uint(buffer.ViewHeight()))
+	if uint(lines)+offset >= (uint(buffer.TotalHeight()) - uint(buffer.ViewHeight())) {
+		terminal.terminalState.SetScrollOffset(uint(buffer.TotalHeight()) - uint(buffer.ViewHeight()))
 	} else {
 		terminal.terminalState.SetScrollOffset(offset + uint(lines))
 	}
 }
 
+// ClearScrollback discards the history of the main screen
+func (terminal *Terminal) ClearScrollback() {
+	defer terminal.SetDirty()
+	terminal.buffers[MainBuffer].ClearScrollback()
+}
+
+// CloseScrollback releases the temporary file used to hold the scrollback, if any
+func (terminal *Terminal) CloseScrollback() error {
+	return terminal.buffers[MainBuffer].Close()
+}
+
 func (terminal *Terminal) ScrollPageDown() {
 	terminal.ScreenScrollDown(terminal.terminalState.ViewHeight())
 }
@@ -228,11 +308,11 @@ func (terminal *Terminal) ScrollToEnd() {
 }
 
 func (terminal *Terminal) GetVisibleLines() []buffer.Line {
-	return terminal.ActiveBuffer().GetVisibleLines()
+	return terminal.ScreenBuffer().GetVisibleLines()
 }
 
 func (terminal *Terminal) GetCell(col uint16, row uint16) *buffer.Cell {
-	return terminal.ActiveBuffer().GetCell(col, row)
+	return terminal.ScreenBuffer().GetCell(col, row)
 }
 
 func (terminal *Terminal) AttachTitleChangeHandler(handler chan bool) {
@@ -276,19 +356,19 @@ func (terminal *Terminal) emitReverse(reverse bool) {
 }
 
 func (terminal *Terminal) GetLogicalCursorX() uint16 {
-	if terminal.ActiveBuffer().CursorColumn() >= terminal.ActiveBuffer().Width() {
+	if terminal.ScreenBuffer().CursorColumn() >= terminal.ScreenBuffer().CursorLineWidth() {
 		return 0
 	}
 
-	return terminal.ActiveBuffer().CursorColumn()
+	return terminal.ScreenBuffer().CursorColumn()
 }
 
 func (terminal *Terminal) GetLogicalCursorY() uint16 {
-	if terminal.ActiveBuffer().CursorColumn() >= terminal.ActiveBuffer().Width() {
-		return terminal.ActiveBuffer().CursorLineAbsolute() + 1
+	if terminal.ScreenBuffer().CursorColumn() >= terminal.ScreenBuffer().CursorLineWidth() {
+		return terminal.ScreenBuffer().CursorLineAbsolute() + 1
 	}
 
-	return terminal.ActiveBuffer().CursorLineAbsolute()
+	return terminal.ScreenBuffer().CursorLineAbsolute()
 }
 
 func (terminal *Terminal) GetTitle() string {
@@ -307,11 +387,7 @@ func (terminal *Terminal) Write(data []byte) error {
 }
 
 func (terminal *Terminal) WriteReturn() error {
-	if terminal.terminalState.IsNewLineMode() {
-		return terminal.Write([]byte{0x0d, 0x0a})
-	} else {
-		return terminal.Write([]byte{0x0d})
-	}
+	return terminal.Write(terminal.returnSequence())
 }
 
 func (terminal *Terminal) Paste(data []byte) error {
@@ -328,11 +404,12 @@ func (terminal *Terminal) Read() error {
 
 	buffer := make(chan rune, 0xffff)
 
-	reader := bufio.NewReader(terminal.pty)
+	reader := bufio.NewReader(&recordingReader{terminal: terminal})
 
 	go terminal.processInput(buffer)
+	defer close(buffer)
 	for {
-		r, _, err := reader.ReadRune()
+		r, err := readRune(reader)
 		if err != nil {
 			if err == io.EOF {
 				break
@@ -362,20 +439,32 @@ func (terminal *Terminal) SetSize(newCols uint, newLines uint) error {
 		return nil
 	}
 
-	err := terminal.pty.Resize(int(newCols), int(newLines))
-	if err != nil {
-		return fmt.Errorf("Failed to set terminal size vai ioctl: Error no %d", err)
-	}
-
 	terminal.size.Width = uint16(newCols)
 	terminal.size.Height = uint16(newLines)
 
-	terminal.ActiveBuffer().ResizeView(terminal.size.Width, terminal.size.Height)
+	if err := terminal.applySize(); err != nil {
+		return err
+	}
 
+	terminal.recordResize()
 	terminal.emitResize()
 	return nil
 }
 
+// applySize resizes the pty and the buffers to fit the terminal size, leaving a row for the status line if shown
+func (terminal *Terminal) applySize() error {
+	height := terminal.screenHeight()
+
+	err := terminal.pty.Resize(int(terminal.size.Width), int(height))
+	if err != nil {
+		return fmt.Errorf("Failed to set terminal size vai ioctl: Error no %d", err)
+	}
+
+	terminal.activeBuffer.ResizeView(terminal.size.Width, height)
+	terminal.statusLine.ResizeView(terminal.size.Width, 1)
+	return nil
+}
+
 func (terminal *Terminal) SetAutoWrap(enabled bool) {
 	terminal.terminalState.AutoWrap = enabled
 }
@@ -413,10 +502,13 @@ func (terminal *Terminal) SetScreenMode(enabled bool) {
 	if terminal.terminalState.ScreenMode == enabled {
 		return
 	}
+	// the colours of the cells are swapped as they are read, rather than changing them
 	terminal.terminalState.ScreenMode = enabled
-	terminal.terminalState.CursorAttr.ReverseVideo()
-	for _, buffer := range terminal.buffers {
-		buffer.ReverseVideo()
-	}
+	terminal.SetDirty()
 	terminal.emitReverse(enabled)
 }
+
+// IsScreenReversed returns true if reverse screen mode (DECSCNM) is enabled
+func (terminal *Terminal) IsScreenReversed() bool {
+	return terminal.terminalState.ScreenMode
+}
@@ -0,0 +1,112 @@
+package terminal
+
+// https://vt100.net/docs/vt100-ug/chapter3.html#S3.3.5
+
+// vt52SequenceMap replaces ansiSequenceMap while the terminal is in VT52 mode (DECANM reset)
+var vt52SequenceMap = map[rune]escapeSequenceHandler{
+	'A': vt52CursorHandler(0, -1), // cursor up
+	'B': vt52CursorHandler(0, 1),  // cursor down
+	'C': vt52CursorHandler(1, 0),  // cursor right
+	'D': vt52CursorHandler(-1, 0), // cursor left
+	'F': vt52GraphicsHandler(true),
+	'G': vt52GraphicsHandler(false),
+	'H': vt52HomeHandler,
+	'I': reverseIndexHandler, // reverse line feed
+	'J': vt52EraseDisplayHandler,
+	'K': vt52EraseLineHandler,
+	'Y': vt52CursorAddressHandler,
+	'Z': vt52IdentifyHandler,
+	'=': swallowHandler(0), // alternate keypad mode  //@todo
+	'>': swallowHandler(0), // exit alternate keypad mode  //@todo
+	'<': vt52ExitHandler,
+}
+
+// IsVT52ModeEnabled returns true if the terminal is emulating a VT52 (DECANM reset)
+func (terminal *Terminal) IsVT52ModeEnabled() bool {
+	return terminal.modes.VT52
+}
+
+func vt52CursorHandler(x int16, y int16) escapeSequenceHandler {
+	return func(pty chan rune, terminal *Terminal) error {
+		terminal.ActiveBuffer().MovePosition(x, y)
+		return nil
+	}
+}
+
+func vt52HomeHandler(pty chan rune, terminal *Terminal) error {
+	terminal.ActiveBuffer().SetPosition(0, 0)
+	return nil
+}
+
+func vt52EraseDisplayHandler(pty chan rune, terminal *Terminal) error {
+	terminal.ActiveBuffer().EraseDisplayFromCursor()
+	return nil
+}
+
+func vt52EraseLineHandler(pty chan rune, terminal *Terminal) error {
+	terminal.ActiveBuffer().EraseLineFromCursor()
+	return nil
+}
+
+// ESC Y line column - both are offset by 32, so ESC Y SP SP is the home position
+func vt52CursorAddressHandler(pty chan rune, terminal *Terminal) error {
+	line, ok := terminal.nextRune(pty)
+	if !ok {
+		return errTruncatedSequence
+	}
+	col, ok := terminal.nextRune(pty)
+	if !ok {
+		return errTruncatedSequence
+	}
+
+	activeBuffer := terminal.ActiveBuffer()
+	x, y := int(col)-32, int(line)-32
+	if x < 0 {
+		x = 0
+	}
+	// a line outside the screen leaves the cursor on its current line, whereas
+	// a column too far right moves it to the right margin
+	if y < 0 || y >= int(activeBuffer.ViewHeight()) {
+		y = int(activeBuffer.CursorLine())
+	}
+
+	activeBuffer.SetPosition(uint16(x), uint16(y))
+	return nil
+}
+
+// ESC Z - a VT100 in VT52 mode identifies itself with ESC / Z
+func vt52IdentifyHandler(pty chan rune, terminal *Terminal) error {
+	_ = terminal.Write([]byte{0x1b, '/', 'Z'})
+	return nil
+}
+
+// vt52GraphicsHandler enters or exits graphics mode, which maps the lower case letters and
+// some punctuation to line drawing characters via the G0 charset
+func vt52GraphicsHandler(enabled bool) escapeSequenceHandler {
+	return func(pty chan rune, terminal *Terminal) error {
+		terminal.setVT52Graphics(enabled)
+		return nil
+	}
+}
+
+// setVT52Graphics switches graphics mode, putting back the G0 charset that was in use before it on the way out
+func (terminal *Terminal) setVT52Graphics(enabled bool) {
+	if enabled == terminal.modes.VT52Graphics {
+		return
+	}
+	terminal.modes.VT52Graphics = enabled
+	if enabled {
+		terminal.vt52SavedG0 = terminal.terminalState.Charsets[0]
+		terminal.terminalState.Charsets[0] = &decSpecGraphics
+	} else {
+		terminal.terminalState.Charsets[0] = terminal.vt52SavedG0
+		terminal.vt52SavedG0 = nil
+	}
+}
+
+// ESC < - return to ANSI mode, leaving graphics mode too
+func vt52ExitHandler(pty chan rune, terminal *Terminal) error {
+	terminal.setVT52Graphics(false)
+	terminal.modes.VT52 = false
+	return nil
+}
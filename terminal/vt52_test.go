@@ -0,0 +1,106 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// replyPty keeps everything the terminal writes back to the host
+type replyPty struct {
+	nullPty
+	replies []byte
+}
+
+func (pty *replyPty) Write(p []byte) (int, error) {
+	pty.replies = append(pty.replies, p...)
+	return len(p), nil
+}
+
+func TestVT52ModeEnterAndExit(t *testing.T) {
+	terminal := newTestTerminal(20, 5)
+	assert.False(t, terminal.IsVT52ModeEnabled())
+
+	// setting DECANM does nothing, only resetting it enters VT52 mode
+	process(terminal, "\x1b[?2h")
+	assert.False(t, terminal.IsVT52ModeEnabled())
+	process(terminal, "\x1b[?2l")
+	assert.True(t, terminal.IsVT52ModeEnabled())
+
+	// CSI sequences are not recognised in VT52 mode
+	process(terminal, "\x1b[2J")
+	assert.True(t, terminal.IsVT52ModeEnabled())
+
+	process(terminal, "\x1b<")
+	assert.False(t, terminal.IsVT52ModeEnabled())
+}
+
+func TestVT52CursorMovement(t *testing.T) {
+	terminal := newTestTerminal(20, 5)
+	process(terminal, "\x1b[?2l")
+
+	process(terminal, "\x1bY\"%")
+	col, line := cursorPosition(terminal)
+	assert.Equal(t, uint16(5), col)
+	assert.Equal(t, uint16(2), line)
+
+	process(terminal, "\x1bA\x1bC\x1bC")
+	col, line = cursorPosition(terminal)
+	assert.Equal(t, uint16(7), col)
+	assert.Equal(t, uint16(1), line)
+
+	process(terminal, "\x1bB\x1bB\x1bD")
+	col, line = cursorPosition(terminal)
+	assert.Equal(t, uint16(6), col)
+	assert.Equal(t, uint16(3), line)
+
+	// a line beyond the screen keeps the current line, a column beyond it goes to the right margin
+	process(terminal, "\x1bY~~")
+	col, line = cursorPosition(terminal)
+	assert.Equal(t, uint16(19), col)
+	assert.Equal(t, uint16(3), line)
+
+	process(terminal, "\x1bH")
+	col, line = cursorPosition(terminal)
+	assert.Equal(t, uint16(0), col)
+	assert.Equal(t, uint16(0), line)
+}
+
+func TestVT52Erase(t *testing.T) {
+	terminal := newTestTerminal(10, 3)
+	process(terminal, "aaaaaaaaaa\r\nbbbbbbbbbb\r\ncccccccccc")
+	process(terminal, "\x1b[?2l\x1bY ' \x1bK\x1bY!$\x1bJ")
+
+	text := screenText(terminal)
+	assert.Equal(t, "aaaaaaa", text[0])
+	assert.Equal(t, "bbbb", text[1])
+	assert.Equal(t, "", text[2])
+}
+
+func TestVT52GraphicsMode(t *testing.T) {
+	terminal := newTestTerminal(10, 3)
+	process(terminal, "\x1b[?2l\x1bFq\x1bGq")
+	assert.Equal(t, "─q", firstLine(terminal))
+}
+
+func TestVT52GraphicsModeRestoresG0(t *testing.T) {
+	terminal := newTestTerminal(10, 3)
+
+	// UK G0 is in use before graphics mode, and comes back after it, however often graphics mode is entered
+	process(terminal, "\x1b(A\x1b[?2l#\x1bF\x1bFq\x1bG#\x1bG#")
+	assert.Equal(t, "£─££", firstLine(terminal))
+
+	// leaving VT52 mode leaves graphics mode too
+	process(terminal, "\x1bFq\x1b<q")
+	assert.Equal(t, "£─££─q", firstLine(terminal))
+	assert.False(t, terminal.modes.VT52Graphics)
+}
+
+func TestVT52Identify(t *testing.T) {
+	pty := &replyPty{}
+	terminal := newTestTerminal(10, 3)
+	terminal.pty = pty
+
+	process(terminal, "\x1b[?2l\x1bZ")
+	assert.Equal(t, "\x1b/Z", string(pty.replies))
+}